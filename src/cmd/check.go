@@ -0,0 +1,34 @@
+package main
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// runCheck is meant for the deploy pipelines: it fails if the service
+// would not start or would not become ready.
+func runCheck(args []string) error {
+	fs, configFile := newFlagSet("check", "")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	fmt.Println("config: ok")
+
+	if err := initDB(cfg.Database); err != nil {
+		return err
+	}
+	readiness := service.NewHealthService(cfg.Health.CheckTimeout).CheckReadiness(context.Background())
+	fmt.Printf("database: %s %s\n", readiness.Database.Status, readiness.Database.Error)
+	fmt.Printf("schema: %s version %d, expected %d %s\n", readiness.Schema.Status,
+		readiness.Schema.Version, readiness.Schema.ExpectedVersion, readiness.Schema.Error)
+	if readiness.Status != model.HealthStatusOk {
+		return errors.New("check failed")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"encoding/json"
+	"os"
+)
+
+func runIntegrity(args []string) error {
+	fs, configFile := newFlagSet("integrity", "\n\nprints the issues as JSON, exits with 1 if any are found and not repaired")
+	repair := fs.Bool("repair", false, "repair the issues, as a dry run unless --apply is set")
+	apply := fs.Bool("apply", false, "commit the repairs")
+	fs.Parse(args)
+	if fs.NArg() > 0 || (*apply && !*repair) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if err := initDB(cfg.Database); err != nil {
+		return err
+	}
+
+	srv := service.NewIntegrityService(cfg.AdminUsernames)
+	var report *model.IntegrityReport
+	if *repair {
+		report, err = srv.Repair(!*apply)
+	} else {
+		report, err = srv.Check()
+	}
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	if len(report.Issues) > 0 && !report.Repaired {
+		os.Exit(1)
+	}
+	return nil
+}
@@ -3,63 +3,116 @@ package main
 import (
 	"avito-back-test/internal/config"
 	"avito-back-test/internal/db"
-	"avito-back-test/internal/server"
 	"context"
-	"errors"
+	"database/sql"
+	"flag"
+	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"time"
+	"strings"
 )
 
-func initDB(dsn string) error {
-	done := make(chan bool, 1)
-	var err error
-	go func() {
-		err = db.InitDB(dsn)
-		done <- true
-	}()
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
 
-	timeout := time.After(10 * time.Second)
+var commands = []command{
+	{"serve", "run the API server (default)", runServe},
+	{"migrate", "apply or revert the database migrations", runMigrate},
+	{"seed", "load demo organizations, employees, tenders and bids", runSeed},
+	{"check", "validate the config and the database connectivity", runCheck},
+	{"user", "manage employees and organization responsibles", runUser},
+	{"integrity", "report and repair inconsistent tenders and bids", runIntegrity},
+	{"org", "export and import the archive of an organization", runOrg},
+}
 
-	select {
-	case <-done:
-		return err
-	case <-timeout:
-		return errors.New("db connection timed out")
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: avito_service <command> [flags] [arguments]\n\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", c.name, c.summary)
 	}
+	fmt.Fprintln(os.Stderr, "\nrun avito_service <command> -h for the flags of a command")
 }
 
 func main() {
-	config, err := config.LoadConfig()
+	args := os.Args[1:]
+	// without a command the flags belong to serve, as before the subcommands
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage()
+		return
+	}
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(args); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// newFlagSet adds the --config flag shared by all the commands.
+func newFlagSet(name, arguments string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: avito_service %s [flags] %s\n\nflags:\n", name, arguments)
+		fs.PrintDefaults()
+	}
+	return fs, configFile
+}
+
+// loadConfig loads the configuration of a command and applies the database
+// retry policy, before any of the connections, the migrator's included, is opened.
+func loadConfig(configFile string) (*config.Config, error) {
+	cfg, err := config.LoadConfig(configFile)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	db.SetRetryPolicy(db.RetryPolicy{
+		Attempts:       cfg.Database.RetryAttempts,
+		InitialBackoff: cfg.Database.RetryInitialBackoff,
+		MaxBackoff:     cfg.Database.RetryMaxBackoff,
+	})
+	return cfg, nil
+}
 
-	if err := initDB(config.PostgresConnUrl); err != nil {
-		log.Fatal(err)
-	} else {
-		log.Println("db init complete")
+func initDB(cfg config.DatabaseConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	if err := db.Connect(ctx, cfg.Driver, cfg.URL); err != nil {
+		return fmt.Errorf("db connection failed: %w", err)
 	}
-	defer db.DB.Close()
 
-	server := server.NewServer(config)
+	setPool(db.DB, cfg)
+	return nil
+}
 
-	go func() {
-		err := server.ListenAndServe()
+// initReplicas opens the read replicas, the primary has to be connected first.
+func initReplicas(cfg config.DatabaseConfig) error {
+	db.SetReadYourWritesWindow(cfg.ReadYourWritesWindow)
+	for _, dsn := range cfg.ReplicaURLs {
+		replica, err := db.AddReplica(dsn)
 		if err != nil {
-			log.Println(err)
+			return fmt.Errorf("db replica: %w", err)
 		}
-	}()
-
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	<-c
-
-	context, cancel := context.WithTimeout(context.Background(), time.Second*20)
-	defer cancel()
-	server.Shutdown(context)
+		setPool(replica, cfg)
+	}
+	return nil
+}
 
-	log.Println("shutting down")
-	os.Exit(0)
+func setPool(d *sql.DB, cfg config.DatabaseConfig) {
+	d.SetMaxOpenConns(cfg.MaxOpenConns)
+	d.SetMaxIdleConns(cfg.MaxIdleConns)
+	d.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	d.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"avito-back-test/internal/config"
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/migration"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const migrateArguments = `[up [N] | down [N] | goto V | version | status | force V]
+
+  up [N]      apply all or N pending migrations (default)
+  down [N]    revert N migrations, 1 by default
+  goto V      migrate up or down to the version V
+  version     print the current version
+  status      list the migrations and whether they are applied
+  force V     set the version without migrating, -1 for none`
+
+func runMigrate(args []string) error {
+	fs, configFile := newFlagSet("migrate", migrateArguments)
+	fs.Parse(args)
+	args = fs.Args()
+	command := "up"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	run, err := parseMigrateCommand(command, args)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	return withMigrator(cfg.Database, run)
+}
+
+func migrateUp(cfg config.DatabaseConfig) error {
+	return withMigrator(cfg, func(m *migration.Migrator) error {
+		return m.Up(0)
+	})
+}
+
+// withMigrator runs fn on a connection of its own, the migrator closes
+// the database when it is done.
+func withMigrator(cfg config.DatabaseConfig, fn func(m *migration.Migrator) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+	database, err := db.Open(ctx, cfg.Driver, cfg.URL)
+	if err != nil {
+		return err
+	}
+
+	m, err := migration.New(context.Background(), database)
+	if err != nil {
+		database.Close()
+		return err
+	}
+	defer m.Close()
+	return fn(m)
+}
+
+// parseMigrateCommand checks the arguments before the database is touched.
+func parseMigrateCommand(command string, args []string) (func(m *migration.Migrator) error, error) {
+	switch command {
+	case "up":
+		n, err := optionalArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return func(m *migration.Migrator) error { return m.Up(n) }, nil
+	case "down":
+		n, err := optionalArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return func(m *migration.Migrator) error { return m.Down(n) }, nil
+	case "goto":
+		v, err := requiredArg(args)
+		if err != nil {
+			return nil, err
+		}
+		if v < 0 {
+			return nil, errors.New("version can't be negative")
+		}
+		return func(m *migration.Migrator) error { return m.Goto(uint(v)) }, nil
+	case "force":
+		v, err := requiredArg(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(m *migration.Migrator) error { return m.Force(v) }, nil
+	case "version":
+		if len(args) > 0 {
+			return nil, errors.New(migrateArguments)
+		}
+		return printVersion, nil
+	case "status":
+		if len(args) > 0 {
+			return nil, errors.New(migrateArguments)
+		}
+		return printStatus, nil
+	}
+	return nil, fmt.Errorf("unknown migrate command %q\n%s", command, migrateArguments)
+}
+
+func printVersion(m *migration.Migrator) error {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		fmt.Printf("%d (dirty)\n", version)
+	} else {
+		fmt.Println(version)
+	}
+	return nil
+}
+
+func printStatus(m *migration.Migrator) error {
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Print(status)
+	return nil
+}
+
+func optionalArg(args []string, defaultValue int) (int, error) {
+	if len(args) == 0 {
+		return defaultValue, nil
+	}
+	return requiredArg(args)
+}
+
+func requiredArg(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New(migrateArguments)
+	}
+	return strconv.Atoi(args[0])
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"avito-back-test/internal/service"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+const orgArguments = `export ORGANIZATION_ID | import FILE
+
+  export      write the archive of an organization to --out or stdout
+  import      restore an archive, "-" reads it from stdin`
+
+func runOrg(args []string) error {
+	fs, configFile := newFlagSet("org", orgArguments)
+	out := fs.String("out", "", "export: the file to write the archive to")
+	compress := fs.Bool("zip", false, "export: write a ZIP archive instead of JSON")
+	into := fs.String("organization", "", "import: the id of an existing organization to import the tenders into")
+	fs.Parse(args)
+	args = fs.Args()
+	if len(args) != 2 || (args[0] != "export" && args[0] != "import") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var organizationID *uuid.UUID
+	if args[0] == "export" || *into != "" {
+		idArg := args[1]
+		if args[0] == "import" {
+			idArg = *into
+		}
+		id, err := uuid.Parse(idArg)
+		if err != nil {
+			return fmt.Errorf("invalid organization id %q", idArg)
+		}
+		organizationID = &id
+	}
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if err := initDB(cfg.Database); err != nil {
+		return err
+	}
+	srv := service.NewArchiveService(cfg.AdminUsernames)
+
+	if args[0] == "export" {
+		archive, err := srv.Export(*organizationID)
+		if err != nil {
+			return err
+		}
+		if *out == "" {
+			return service.EncodeArchive(os.Stdout, archive, *compress)
+		}
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		if err := service.EncodeArchive(f, archive, *compress); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	var data []byte
+	if args[1] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(args[1])
+	}
+	if err != nil {
+		return err
+	}
+	archive, err := service.DecodeArchive(data)
+	if err != nil {
+		return err
+	}
+	result, conflicts, err := srv.Import(archive, organizationID)
+	if err == service.ErrImportConflicts {
+		for _, c := range conflicts {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", c.Kind, c.Detail)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
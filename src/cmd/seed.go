@@ -0,0 +1,51 @@
+package main
+
+import (
+	"avito-back-test/fixtures"
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+func runSeed(args []string) error {
+	fs, configFile := newFlagSet("seed", "[fixture.yaml]\n\nwithout a file the embedded demo fixture is loaded")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	data := fixtures.Demo
+	if fs.NArg() == 1 {
+		var err error
+		if data, err = os.ReadFile(fs.Arg(0)); err != nil {
+			return err
+		}
+	}
+	var fixture model.Fixture
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&fixture); err != nil {
+		return fmt.Errorf("fixture: %w", err)
+	}
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if err := initDB(cfg.Database); err != nil {
+		return err
+	}
+
+	result, err := service.NewSeedService().Seed(&fixture)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("employees: %d, organizations: %d, new tenders: %d, new bids: %d\n",
+		result.Employees, result.Organizations, result.Tenders, result.Bids)
+	return nil
+}
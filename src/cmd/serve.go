@@ -0,0 +1,118 @@
+package main
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/handler"
+	"avito-back-test/internal/server"
+	"avito-back-test/internal/service"
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// closeExpiredAuctions finishes reverse auctions as soon as their time runs out,
+// even if nobody looks at them.
+func closeExpiredAuctions(stop <-chan struct{}) {
+	auctionService := service.NewAuctionService()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := auctionService.CloseExpiredAuctions(); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+func runServe(args []string) error {
+	fs, configFile := newFlagSet("serve", "")
+	printConfig := fs.Bool("print-config", false, "print the effective config with the secrets redacted and exit")
+	migrate := fs.Bool("migrate", false, "apply the pending migrations before serving")
+	fs.Parse(args)
+
+	config, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if *printConfig {
+		return config.Print(os.Stdout)
+	}
+
+	if *migrate {
+		if err := migrateUp(config.Database); err != nil {
+			return err
+		}
+	}
+
+	if err := initDB(config.Database); err != nil {
+		return err
+	}
+	log.Println("db init complete")
+	defer db.DB.Close()
+	if err := initReplicas(config.Database); err != nil {
+		return err
+	}
+	defer db.CloseReplicas()
+
+	stopAuctions := make(chan struct{})
+	go closeExpiredAuctions(stopAuctions)
+	stopReplicaChecks := make(chan struct{})
+	go db.CheckReplicas(config.Database.ReplicaCheckInterval, config.Health.CheckTimeout, stopReplicaChecks)
+	stopCacheListener := make(chan struct{})
+	if config.Cache.Enabled {
+		service.SetTenderCache(config.Cache.Size, config.Cache.TTL)
+		go service.ListenTenderChanges(config.Database.URL, stopCacheListener)
+	}
+
+	metricsServer := server.NewMetricsServer(config)
+	server, err := server.NewServer(config)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		var err error
+		if config.TLS.Enabled() {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+	if metricsServer != nil {
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	<-c
+	handler.StartDraining()
+	log.Println("draining")
+	time.Sleep(config.Health.DrainDelay)
+	close(stopAuctions)
+	close(stopReplicaChecks)
+	close(stopCacheListener)
+
+	context, cancel := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
+	defer cancel()
+	server.Shutdown(context)
+	if metricsServer != nil {
+		metricsServer.Shutdown(context)
+	}
+
+	log.Println("shutting down")
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"avito-back-test/internal/service"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+const userArguments = `list | add USERNAME [FIRST_NAME [LAST_NAME]] | remove USERNAME |
+       grant USERNAME ORGANIZATION_ID | revoke USERNAME ORGANIZATION_ID
+
+  list        list the employees
+  add         create an employee or rename the existing one
+  remove      delete an employee
+  grant       make an employee responsible for an organization
+  revoke      remove an employee from the responsibles of an organization`
+
+func runUser(args []string) error {
+	fs, configFile := newFlagSet("user", userArguments)
+	fs.Parse(args)
+	args = fs.Args()
+	valid := len(args) > 0
+	if valid {
+		switch args[0] {
+		case "list":
+			valid = len(args) == 1
+		case "add":
+			valid = len(args) >= 2 && len(args) <= 4
+		case "remove":
+			valid = len(args) == 2
+		case "grant", "revoke":
+			valid = len(args) == 3
+		default:
+			valid = false
+		}
+	}
+	if !valid {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if err := initDB(cfg.Database); err != nil {
+		return err
+	}
+	srv := service.NewUserService()
+
+	switch args[0] {
+	case "list":
+		employees, err := srv.GetEmployees()
+		if err != nil {
+			return err
+		}
+		for _, e := range employees {
+			fmt.Printf("%s %-20s %s %s\n", e.ID, e.Username, e.FirstName, e.LastName)
+		}
+	case "add":
+		names := append(args[2:], "", "")
+		employee, err := srv.SaveEmployee(args[1], names[0], names[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println(employee.ID)
+	case "remove":
+		return srv.DeleteEmployee(args[1])
+	case "grant", "revoke":
+		organizationID, err := uuid.Parse(args[2])
+		if err != nil {
+			return err
+		}
+		if args[0] == "grant" {
+			return srv.GrantResponsible(args[1], organizationID)
+		}
+		return srv.RevokeResponsible(args[1], organizationID)
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+// Package fixtures embeds the demo data of the seed command.
+package fixtures
+
+import _ "embed"
+
+//go:embed demo.yaml
+var Demo []byte
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"container/list"
+	"expvar"
+	"sync"
+	"time"
+)
+
+// The hit, miss, eviction and invalidation counters of every cache
+// are published on /debug/vars, prefixed with the name of the cache.
+var stats = expvar.NewMap("cache")
+
+// LRU keeps up to size entries, dropping the least recently used one
+// when it is full. The entries also expire after the TTL.
+// A nil LRU is a disabled cache, it always misses.
+type LRU[K comparable, V any] struct {
+	name string
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[K]*list.Element
+	// generation changes with every invalidation, see Load
+	generation uint64
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+func NewLRU[K comparable, V any](name string, size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		name:    name,
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[K]*list.Element),
+	}
+}
+
+// Get returns the value cached for the key, if it hasn't expired.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	var zero V
+	if c == nil {
+		return zero, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		stats.Add(c.name+"_misses", 1)
+		return zero, false
+	}
+	e := element.Value.(*entry[K, V])
+	if time.Now().After(e.expires) {
+		c.remove(element)
+		stats.Add(c.name+"_misses", 1)
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	stats.Add(c.name+"_hits", 1)
+	return e.value, true
+}
+
+// Load returns the cached value or caches the one made by fill. A value
+// filled while the cache was invalidated may be stale, it is returned
+// but not cached.
+func (c *LRU[K, V]) Load(key K, fill func() (V, error)) (V, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+	if c == nil {
+		return fill()
+	}
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	value, err := fill()
+	if err != nil {
+		return value, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.set(key, value)
+	}
+	return value, nil
+}
+
+func (c *LRU[K, V]) set(key K, value V) {
+	expires := time.Now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry[K, V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		stats.Add(c.name+"_evictions", 1)
+	}
+}
+
+func (c *LRU[K, V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry[K, V]).key)
+}
+
+// Remove invalidates the entry of the key.
+func (c *LRU[K, V]) Remove(key K) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	stats.Add(c.name+"_invalidations", 1)
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// Purge invalidates all the entries.
+func (c *LRU[K, V]) Purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	stats.Add(c.name+"_invalidations", 1)
+	c.order.Init()
+	clear(c.entries)
+}
@@ -1,53 +1,446 @@
 package config
 
 import (
+	"bytes"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"net/netip"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
 )
 
+// Config is the configuration of the service. It is read from an optional
+// YAML or TOML file, then the environment variables override the file.
 type Config struct {
-	ServerAddress   string
-	PostgresConnUrl string
-	LogLevel        string
+	Server         ServerConfig      `yaml:"server" toml:"server"`
+	Database       DatabaseConfig    `yaml:"database" toml:"database"`
+	Pagination     PaginationConfig  `yaml:"pagination" toml:"pagination"`
+	Bids           BidsConfig        `yaml:"bids" toml:"bids"`
+	Health         HealthConfig      `yaml:"health" toml:"health"`
+	Cache          CacheConfig       `yaml:"cache" toml:"cache"`
+	Idempotency    IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	LogLevel       string            `yaml:"logLevel" toml:"logLevel"`
+	AdminUsernames []string          `yaml:"adminUsernames" toml:"adminUsernames"`
+	RateLimit      RateLimitConfig   `yaml:"rateLimit" toml:"rateLimit"`
+	CORS           CORSConfig        `yaml:"cors" toml:"cors"`
+	TLS            TLSConfig         `yaml:"tls" toml:"tls"`
 }
 
-func GetEnv(key, defaultValue string, required bool) (string, error) {
-	if value, exists := os.LookupEnv(key); exists {
-		return value, nil
-	}
-	if required {
-		return "", fmt.Errorf("required environment key %s is not set", key)
-	}
-	return defaultValue, nil
+type ServerConfig struct {
+	Address      string        `yaml:"address" toml:"address"`
+	ReadTimeout  time.Duration `yaml:"readTimeout" toml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout" toml:"writeTimeout"`
+	IdleTimeout  time.Duration `yaml:"idleTimeout" toml:"idleTimeout"`
+	// ShutdownTimeout is the grace period for the requests in flight
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+	// MetricsAddress is the separate listener of GET /debug/vars, it is meant
+	// to be reachable by the monitoring only; empty disables the metrics
+	MetricsAddress string `yaml:"metricsAddress" toml:"metricsAddress"`
+	// Middlewares wrap the whole API in this order, the first one runs first,
+	// see ServerMiddlewares
+	Middlewares []string `yaml:"middlewares" toml:"middlewares"`
+}
+
+// ServerMiddlewares are the names of the middlewares server.middlewares can list.
+var ServerMiddlewares = []string{"recovery", "securityHeaders", "cors"}
+
+type DatabaseConfig struct {
+	// Driver is "postgres" or "sqlite", the latter for the local development and the tests
+	Driver string `yaml:"driver" toml:"driver"`
+	// URL is the Postgres connection string, it may contain the password,
+	// or the SQLite database file
+	URL string `yaml:"url" toml:"url"`
+	// ConnectTimeout is how long the startup keeps retrying to reach the database
+	ConnectTimeout  time.Duration `yaml:"connectTimeout" toml:"connectTimeout"`
+	MaxOpenConns    int           `yaml:"maxOpenConns" toml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns" toml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime" toml:"connMaxLifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime" toml:"connMaxIdleTime"`
+	// RetryAttempts bounds the runs of a transaction failing with
+	// a serialization failure, a deadlock or a lost connection
+	RetryAttempts       int           `yaml:"retryAttempts" toml:"retryAttempts"`
+	RetryInitialBackoff time.Duration `yaml:"retryInitialBackoff" toml:"retryInitialBackoff"`
+	RetryMaxBackoff     time.Duration `yaml:"retryMaxBackoff" toml:"retryMaxBackoff"`
+	// ReplicaURLs are the read replicas serving the heavy listings
+	ReplicaURLs []string `yaml:"replicaUrls" toml:"replicaUrls"`
+	// ReadYourWritesWindow is how long the listings of a user who has
+	// changed data read from the primary, it should exceed the replication lag
+	ReadYourWritesWindow time.Duration `yaml:"readYourWritesWindow" toml:"readYourWritesWindow"`
+	// ReplicaCheckInterval is the period of the replica health checks
+	ReplicaCheckInterval time.Duration `yaml:"replicaCheckInterval" toml:"replicaCheckInterval"`
+}
+
+type PaginationConfig struct {
+	// DefaultLimit is used when a listing has no limit parameter
+	DefaultLimit int `yaml:"defaultLimit" toml:"defaultLimit"`
+	MaxLimit     int `yaml:"maxLimit" toml:"maxLimit"`
+}
+
+type BidsConfig struct {
+	// DecisionQuorum caps the number of approvals a bid needs to win the tender
+	DecisionQuorum int `yaml:"decisionQuorum" toml:"decisionQuorum"`
 }
 
-func processConfig(config *Config) error {
-	serverAddress, err := GetEnv("SERVER_ADDRESS", ":8080", false)
+type HealthConfig struct {
+	// CheckTimeout bounds the database checks of the readiness probe
+	CheckTimeout time.Duration `yaml:"checkTimeout" toml:"checkTimeout"`
+	// DrainDelay is how long the readiness probe fails before the shutdown,
+	// it should exceed the probe period of the load balancer
+	DrainDelay time.Duration `yaml:"drainDelay" toml:"drainDelay"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins lists the origins of the browser clients, "*" allows any,
+	// CORS is off if the list is empty
+	AllowedOrigins   []string      `yaml:"allowedOrigins" toml:"allowedOrigins"`
+	AllowedMethods   []string      `yaml:"allowedMethods" toml:"allowedMethods"`
+	AllowedHeaders   []string      `yaml:"allowedHeaders" toml:"allowedHeaders"`
+	ExposedHeaders   []string      `yaml:"exposedHeaders" toml:"exposedHeaders"`
+	AllowCredentials bool          `yaml:"allowCredentials" toml:"allowCredentials"`
+	MaxAge           time.Duration `yaml:"maxAge" toml:"maxAge"`
+}
+
+// RateBudget allows Requests per Period with bursts of up to Requests.
+// In files and variables it is written like "10/1m".
+type RateBudget struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (b RateBudget) MarshalText() ([]byte, error) {
+	return []byte(strconv.Itoa(b.Requests) + "/" + b.Period.String()), nil
+}
+
+func (b *RateBudget) UnmarshalText(text []byte) error {
+	budget, err := ParseRateBudget(string(text))
 	if err != nil {
 		return err
 	}
-	config.ServerAddress = serverAddress
+	*b = budget
+	return nil
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Backend is "memory" for a single replica or "postgres" to share budgets between replicas
+	Backend string `yaml:"backend" toml:"backend"`
+	// TrustProxy takes the client IP from X-Forwarded-For: the rightmost
+	// address that wasn't added by one of the TrustedProxies
+	TrustProxy bool `yaml:"trustProxy" toml:"trustProxy"`
+	// TrustedProxies are the addresses or CIDRs of the proxies in front of
+	// the API, only the direct peer is trusted if there are none
+	TrustedProxies []string   `yaml:"trustedProxies" toml:"trustedProxies"`
+	Default        RateBudget `yaml:"default" toml:"default"`
+	// Routes maps "METHOD /path/template" to the budget of the route
+	Routes map[string]RateBudget `yaml:"routes" toml:"routes"`
+}
+
+// CacheConfig bounds the in-process cache of the public tender catalog.
+type CacheConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Size is the number of tenders and of listing pages kept
+	Size int           `yaml:"size" toml:"size"`
+	TTL  time.Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// IdempotencyConfig keeps the responses of the create, status and decision
+// endpoints to replay them to the retries with the same Idempotency-Key.
+type IdempotencyConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// TTL is how long a key is remembered
+	TTL time.Duration `yaml:"ttl" toml:"ttl"`
+}
+
+type TLSConfig struct {
+	// TLS is served if both files are set, they are reloaded when changed
+	CertFile string `yaml:"certFile" toml:"certFile"`
+	KeyFile  string `yaml:"keyFile" toml:"keyFile"`
+	// MinVersion is "1.2" or "1.3"
+	MinVersion string `yaml:"minVersion" toml:"minVersion"`
+	// ClientCAFile enables mutual TLS with client certificates signed by these CAs
+	ClientCAFile string `yaml:"clientCAFile" toml:"clientCAFile"`
+	// ClientAuth is "request" to accept clients without a certificate or "require"
+	ClientAuth string `yaml:"clientAuth" toml:"clientAuth"`
+	// ClientIdentities maps a certificate subject (its common name or full DN)
+	// to the identity of the client
+	ClientIdentities map[string]ClientIdentity `yaml:"clientIdentities" toml:"clientIdentities"`
+	ReloadInterval   time.Duration             `yaml:"reloadInterval" toml:"reloadInterval"`
+}
+
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+func (c *TLSConfig) MinTLSVersion() uint16 {
+	if c.MinVersion == "1.3" {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
 
-	postgresConnUrl, err := GetEnv("POSTGRES_CONN", "", true)
-	if err != nil {
-		return err
+type ClientIdentity struct {
+	// Username is set for certificates of an employee
+	Username string `yaml:"username,omitempty" toml:"username,omitempty"`
+	// OrganizationID is set for certificates of an organization
+	OrganizationID string `yaml:"organizationId,omitempty" toml:"organizationId,omitempty"`
+}
+
+// Default returns the configuration used for everything the file
+// and the environment leave out.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Address:         ":8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     20 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+			Middlewares:     []string{"recovery", "securityHeaders", "cors"},
+		},
+		Database: DatabaseConfig{
+			Driver:               "postgres",
+			ConnectTimeout:       time.Minute,
+			MaxOpenConns:         25,
+			MaxIdleConns:         25,
+			ConnMaxLifetime:      30 * time.Minute,
+			ConnMaxIdleTime:      5 * time.Minute,
+			RetryAttempts:        3,
+			RetryInitialBackoff:  50 * time.Millisecond,
+			RetryMaxBackoff:      time.Second,
+			ReadYourWritesWindow: 5 * time.Second,
+			ReplicaCheckInterval: 5 * time.Second,
+		},
+		Pagination: PaginationConfig{
+			DefaultLimit: 5,
+			MaxLimit:     100,
+		},
+		Bids: BidsConfig{
+			DecisionQuorum: 3,
+		},
+		Health: HealthConfig{
+			CheckTimeout: 2 * time.Second,
+			DrainDelay:   5 * time.Second,
+		},
+		Cache: CacheConfig{
+			Enabled: true,
+			Size:    1000,
+			TTL:     30 * time.Second,
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled: true,
+			TTL:     24 * time.Hour,
+		},
+		LogLevel: "info",
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Backend: "memory",
+			Default: RateBudget{Requests: 120, Period: time.Minute},
+			// the writes and the heavy listings get tighter budgets out of the box
+			Routes: map[string]RateBudget{
+				"POST /api/bids/new":            {Requests: 20, Period: time.Minute},
+				"POST /api/tenders/new":         {Requests: 20, Period: time.Minute},
+				"GET /api/tenders":              {Requests: 60, Period: time.Minute},
+				"GET /api/bids/{tenderId}/list": {Requests: 60, Period: time.Minute},
+			},
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "Idempotency-Key"},
+			ExposedHeaders: []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+				"RateLimit-Policy", "Retry-After", "Idempotent-Replayed"},
+			MaxAge: 10 * time.Minute,
+		},
+		TLS: TLSConfig{
+			MinVersion:       "1.2",
+			ClientAuth:       "require",
+			ClientIdentities: map[string]ClientIdentity{},
+			ReloadInterval:   10 * time.Second,
+		},
 	}
-	config.PostgresConnUrl = postgresConnUrl
+}
 
-	logLevel, err := GetEnv("LOG_LEVEL", "info", false)
+// LoadConfig reads the file at path, if any, applies the environment
+// overrides and validates the result.
+func LoadConfig(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return fmt.Errorf("config file: %w", err)
 	}
-	config.LogLevel = logLevel
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), cfg)
+		if err == nil && len(meta.Undecoded()) > 0 {
+			err = fmt.Errorf("unknown keys %v", meta.Undecoded())
+		}
+	default:
+		return fmt.Errorf("config file %s: only .yaml, .yml and .toml are supported", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports all the invalid values at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Address != "", "server.address is required")
+	check(c.Server.ReadTimeout > 0, "server.readTimeout has to be positive")
+	check(c.Server.WriteTimeout > 0, "server.writeTimeout has to be positive")
+	check(c.Server.IdleTimeout > 0, "server.idleTimeout has to be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdownTimeout has to be positive")
+	check(c.Server.MetricsAddress != c.Server.Address, "server.metricsAddress has to differ from server.address")
+	for i, name := range c.Server.Middlewares {
+		check(contains(ServerMiddlewares, name), "server.middlewares: unknown middleware %q, known are %s",
+			name, strings.Join(ServerMiddlewares, ", "))
+		check(!contains(c.Server.Middlewares[:i], name), "server.middlewares: %q is listed twice", name)
+	}
+
+	check(c.Database.Driver == "postgres" || c.Database.Driver == "sqlite",
+		"database.driver has to be postgres or sqlite, got %q", c.Database.Driver)
+	check(c.Database.URL != "", "database.url is required (POSTGRES_CONN or DB_URL)")
+	check(c.Database.ConnectTimeout > 0, "database.connectTimeout has to be positive")
+	check(c.Database.MaxOpenConns >= 0, "database.maxOpenConns can't be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.maxIdleConns can't be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.maxIdleConns can't exceed database.maxOpenConns")
+	check(c.Database.ConnMaxLifetime >= 0, "database.connMaxLifetime can't be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.connMaxIdleTime can't be negative")
+	check(c.Database.RetryAttempts > 0, "database.retryAttempts has to be positive")
+	check(c.Database.RetryInitialBackoff > 0, "database.retryInitialBackoff has to be positive")
+	check(c.Database.RetryMaxBackoff >= c.Database.RetryInitialBackoff,
+		"database.retryMaxBackoff can't be less than database.retryInitialBackoff")
+	check(len(c.Database.ReplicaURLs) == 0 || c.Database.Driver == "postgres",
+		"database.replicaUrls are only supported with the postgres driver")
+	check(c.Database.ReadYourWritesWindow >= 0, "database.readYourWritesWindow can't be negative")
+	check(c.Database.ReplicaCheckInterval > 0, "database.replicaCheckInterval has to be positive")
+
+	check(c.Pagination.DefaultLimit > 0, "pagination.defaultLimit has to be positive")
+	check(c.Pagination.MaxLimit >= c.Pagination.DefaultLimit,
+		"pagination.maxLimit can't be less than pagination.defaultLimit")
+	check(c.Bids.DecisionQuorum > 0, "bids.decisionQuorum has to be positive")
+	check(c.Health.CheckTimeout > 0, "health.checkTimeout has to be positive")
+	check(c.Health.DrainDelay >= 0, "health.drainDelay can't be negative")
+	check(!c.Cache.Enabled || c.Cache.Size > 0, "cache.size has to be positive")
+	check(!c.Cache.Enabled || c.Cache.TTL > 0, "cache.ttl has to be positive")
+	check(!c.Idempotency.Enabled || c.Idempotency.TTL > 0, "idempotency.ttl has to be positive")
 
+	check(c.RateLimit.Backend == "memory" || c.RateLimit.Backend == "postgres",
+		"rateLimit.backend has to be memory or postgres, got %q", c.RateLimit.Backend)
+	check(c.RateLimit.Default.Requests > 0 && c.RateLimit.Default.Period > 0,
+		"rateLimit.default has to allow requests")
+	for route, budget := range c.RateLimit.Routes {
+		method, path, ok := strings.Cut(route, " ")
+		check(ok && method != "" && strings.HasPrefix(path, "/"),
+			"rateLimit.routes: %q has to look like \"METHOD /path\"", route)
+		check(budget.Requests > 0 && budget.Period > 0, "rateLimit.routes: %q has to allow requests", route)
+	}
+	for _, proxy := range c.RateLimit.TrustedProxies {
+		_, err := ParseProxy(proxy)
+		check(err == nil, "rateLimit.trustedProxies: %q has to be an address or a CIDR", proxy)
+	}
+
+	check(c.CORS.MaxAge >= 0, "cors.maxAge can't be negative")
+	check(!c.CORS.AllowCredentials || !contains(c.CORS.AllowedOrigins, "*"),
+		"cors.allowCredentials can't be used with the * origin")
+
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls.certFile and tls.keyFile have to be set together")
+	check(c.TLS.MinVersion == "1.2" || c.TLS.MinVersion == "1.3",
+		"tls.minVersion has to be 1.2 or 1.3, got %q", c.TLS.MinVersion)
+	check(c.TLS.ClientAuth == "request" || c.TLS.ClientAuth == "require",
+		"tls.clientAuth has to be request or require, got %q", c.TLS.ClientAuth)
+	check(c.TLS.ClientCAFile == "" || c.TLS.Enabled(), "tls.clientCAFile needs tls.certFile and tls.keyFile")
+	check(c.TLS.ReloadInterval > 0, "tls.reloadInterval has to be positive")
+	for subject, identity := range c.TLS.ClientIdentities {
+		check((identity.Username == "") != (identity.OrganizationID == ""),
+			"tls.clientIdentities: %q needs either a username or an organizationId", subject)
+		check(identity.OrganizationID == "" || uuid.Validate(identity.OrganizationID) == nil,
+			"tls.clientIdentities: %q has an invalid organizationId", subject)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
 	return nil
 }
 
-func LoadConfig() (*Config, error) {
-	var cfg Config
-	err := processConfig(&cfg)
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProxy parses a trusted proxy, an address or a CIDR.
+func ParseProxy(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
 	if err != nil {
-		return nil, err
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ParseRateBudget parses budgets like "10/1m" or "5/s".
+func ParseRateBudget(s string) (RateBudget, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok || period == "" {
+		return RateBudget{}, fmt.Errorf("invalid rate budget %q", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return RateBudget{}, fmt.Errorf("invalid rate budget %q", s)
+	}
+	// a bare unit means one of it
+	if _, err := strconv.Atoi(period[:1]); err != nil {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateBudget{}, fmt.Errorf("invalid rate budget %q", s)
 	}
-	return &cfg, nil
+	return RateBudget{Requests: n, Period: d}, nil
 }
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envOverrides maps the environment variables to the config values they override.
+var envOverrides = []struct {
+	key   string
+	apply func(c *Config, value string) error
+}{
+	{"SERVER_ADDRESS", setString(func(c *Config) *string { return &c.Server.Address })},
+	{"SERVER_READ_TIMEOUT", setDuration(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{"SERVER_WRITE_TIMEOUT", setDuration(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{"SERVER_IDLE_TIMEOUT", setDuration(func(c *Config) *time.Duration { return &c.Server.IdleTimeout })},
+	{"SERVER_SHUTDOWN_TIMEOUT", setDuration(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"SERVER_METRICS_ADDRESS", setString(func(c *Config) *string { return &c.Server.MetricsAddress })},
+	{"SERVER_MIDDLEWARES", setList(func(c *Config) *[]string { return &c.Server.Middlewares })},
+
+	{"DB_DRIVER", setString(func(c *Config) *string { return &c.Database.Driver })},
+	{"POSTGRES_CONN", setString(func(c *Config) *string { return &c.Database.URL })},
+	// DB_URL takes precedence, it reads better for SQLite
+	{"DB_URL", setString(func(c *Config) *string { return &c.Database.URL })},
+	{"DB_CONNECT_TIMEOUT", setDuration(func(c *Config) *time.Duration { return &c.Database.ConnectTimeout })},
+	{"DB_MAX_OPEN_CONNS", setInt(func(c *Config) *int { return &c.Database.MaxOpenConns })},
+	{"DB_MAX_IDLE_CONNS", setInt(func(c *Config) *int { return &c.Database.MaxIdleConns })},
+	{"DB_CONN_MAX_LIFETIME", setDuration(func(c *Config) *time.Duration { return &c.Database.ConnMaxLifetime })},
+	{"DB_CONN_MAX_IDLE_TIME", setDuration(func(c *Config) *time.Duration { return &c.Database.ConnMaxIdleTime })},
+	{"DB_RETRY_ATTEMPTS", setInt(func(c *Config) *int { return &c.Database.RetryAttempts })},
+	{"DB_RETRY_INITIAL_BACKOFF", setDuration(func(c *Config) *time.Duration { return &c.Database.RetryInitialBackoff })},
+	{"DB_RETRY_MAX_BACKOFF", setDuration(func(c *Config) *time.Duration { return &c.Database.RetryMaxBackoff })},
+	// comma separated connection strings of the read replicas
+	{"DB_REPLICA_URLS", setList(func(c *Config) *[]string { return &c.Database.ReplicaURLs })},
+	{"DB_READ_YOUR_WRITES_WINDOW", setDuration(func(c *Config) *time.Duration { return &c.Database.ReadYourWritesWindow })},
+	{"DB_REPLICA_CHECK_INTERVAL", setDuration(func(c *Config) *time.Duration { return &c.Database.ReplicaCheckInterval })},
+
+	{"PAGINATION_DEFAULT_LIMIT", setInt(func(c *Config) *int { return &c.Pagination.DefaultLimit })},
+	{"PAGINATION_MAX_LIMIT", setInt(func(c *Config) *int { return &c.Pagination.MaxLimit })},
+	{"BID_DECISION_QUORUM", setInt(func(c *Config) *int { return &c.Bids.DecisionQuorum })},
+	{"HEALTH_CHECK_TIMEOUT", setDuration(func(c *Config) *time.Duration { return &c.Health.CheckTimeout })},
+	{"HEALTH_DRAIN_DELAY", setDuration(func(c *Config) *time.Duration { return &c.Health.DrainDelay })},
+	{"CACHE_ENABLED", setBool(func(c *Config) *bool { return &c.Cache.Enabled })},
+	{"CACHE_SIZE", setInt(func(c *Config) *int { return &c.Cache.Size })},
+	{"CACHE_TTL", setDuration(func(c *Config) *time.Duration { return &c.Cache.TTL })},
+	{"IDEMPOTENCY_ENABLED", setBool(func(c *Config) *bool { return &c.Idempotency.Enabled })},
+	{"IDEMPOTENCY_TTL", setDuration(func(c *Config) *time.Duration { return &c.Idempotency.TTL })},
+
+	{"LOG_LEVEL", setString(func(c *Config) *string { return &c.LogLevel })},
+	// comma separated usernames of the service catalog administrators
+	{"ADMIN_USERNAMES", setList(func(c *Config) *[]string { return &c.AdminUsernames })},
+
+	{"RATE_LIMIT_ENABLED", setBool(func(c *Config) *bool { return &c.RateLimit.Enabled })},
+	{"RATE_LIMIT_BACKEND", setString(func(c *Config) *string { return &c.RateLimit.Backend })},
+	{"RATE_LIMIT_TRUST_PROXY", setBool(func(c *Config) *bool { return &c.RateLimit.TrustProxy })},
+	{"RATE_LIMIT_TRUSTED_PROXIES", setList(func(c *Config) *[]string { return &c.RateLimit.TrustedProxies })},
+	{"RATE_LIMIT_DEFAULT", func(c *Config, value string) error {
+		return c.RateLimit.Default.UnmarshalText([]byte(value))
+	}},
+	{"RATE_LIMIT_ROUTES", func(c *Config, value string) (err error) {
+		c.RateLimit.Routes, err = parseRateLimitRoutes(value)
+		return err
+	}},
+
+	{"CORS_ALLOWED_ORIGINS", setList(func(c *Config) *[]string { return &c.CORS.AllowedOrigins })},
+	{"CORS_ALLOWED_METHODS", setList(func(c *Config) *[]string { return &c.CORS.AllowedMethods })},
+	{"CORS_ALLOWED_HEADERS", setList(func(c *Config) *[]string { return &c.CORS.AllowedHeaders })},
+	{"CORS_EXPOSED_HEADERS", setList(func(c *Config) *[]string { return &c.CORS.ExposedHeaders })},
+	{"CORS_ALLOW_CREDENTIALS", setBool(func(c *Config) *bool { return &c.CORS.AllowCredentials })},
+	{"CORS_MAX_AGE", setDuration(func(c *Config) *time.Duration { return &c.CORS.MaxAge })},
+
+	{"TLS_CERT_FILE", setString(func(c *Config) *string { return &c.TLS.CertFile })},
+	{"TLS_KEY_FILE", setString(func(c *Config) *string { return &c.TLS.KeyFile })},
+	{"TLS_MIN_VERSION", setString(func(c *Config) *string { return &c.TLS.MinVersion })},
+	{"TLS_CLIENT_CA_FILE", setString(func(c *Config) *string { return &c.TLS.ClientCAFile })},
+	{"TLS_CLIENT_AUTH", setString(func(c *Config) *string { return &c.TLS.ClientAuth })},
+	{"TLS_CLIENT_IDENTITIES", func(c *Config, value string) (err error) {
+		c.TLS.ClientIdentities, err = parseClientIdentities(value)
+		return err
+	}},
+	{"TLS_RELOAD_INTERVAL", setDuration(func(c *Config) *time.Duration { return &c.TLS.ReloadInterval })},
+}
+
+func applyEnv(c *Config) error {
+	for _, override := range envOverrides {
+		value, ok := os.LookupEnv(override.key)
+		if !ok {
+			continue
+		}
+		if err := override.apply(c, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", override.key, err)
+		}
+	}
+	return nil
+}
+
+func GetEnv(key, defaultValue string, required bool) (string, error) {
+	if value, exists := os.LookupEnv(key); exists {
+		return value, nil
+	}
+	if required {
+		return "", fmt.Errorf("required environment key %s is not set", key)
+	}
+	return defaultValue, nil
+}
+
+func setString(field func(c *Config) *string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+func setInt(field func(c *Config) *int) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func setBool(field func(c *Config) *bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func setDuration(field func(c *Config) *time.Duration) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// setList splits a comma separated value, dropping empty items.
+func setList(field func(c *Config) *[]string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(c) = list
+		return nil
+	}
+}
+
+// parseRateLimitRoutes parses "POST /api/bids/new=10/1m;GET /api/tenders=60/1m".
+func parseRateLimitRoutes(s string) (map[string]RateBudget, error) {
+	routes := make(map[string]RateBudget)
+	for _, entry := range strings.Split(s, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, budget, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit route %q", entry)
+		}
+		b, err := ParseRateBudget(budget)
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.Join(strings.Fields(route), " ")] = b
+	}
+	return routes, nil
+}
+
+// parseClientIdentities parses "acme-gateway=user:jdoe;CN=partner,O=Partner=org:<uuid>".
+func parseClientIdentities(s string) (map[string]ClientIdentity, error) {
+	identities := make(map[string]ClientIdentity)
+	for _, entry := range strings.Split(s, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid client identity %q", entry)
+		}
+		subject, identity := strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1:])
+		kind, value, ok := strings.Cut(identity, ":")
+		switch {
+		case ok && kind == "user" && value != "":
+			identities[subject] = ClientIdentity{Username: value}
+		case ok && kind == "org" && value != "":
+			identities[subject] = ClientIdentity{OrganizationID: value}
+		default:
+			return nil, fmt.Errorf("invalid client identity %q", entry)
+		}
+	}
+	return identities, nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+var dsnPasswordRegexp = regexp.MustCompile(`(password\s*=\s*)('[^']*'|\S+)`)
+
+// Print writes the effective config as YAML with the secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	printed := *c
+	printed.Database.URL = redactDatabaseURL(c.Database.URL)
+	printed.Database.ReplicaURLs = make([]string, len(c.Database.ReplicaURLs))
+	for i, dsn := range c.Database.ReplicaURLs {
+		printed.Database.ReplicaURLs[i] = redactDatabaseURL(dsn)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&printed); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// redactDatabaseURL hides the password of both URL and key=value connection strings.
+func redactDatabaseURL(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		query := u.Query()
+		if query.Has("password") {
+			query.Set("password", redacted)
+			u.RawQuery = query.Encode()
+		}
+		return u.String()
+	}
+	return dsnPasswordRegexp.ReplaceAllString(dsn, "${1}"+redacted)
+}
@@ -2,19 +2,50 @@ package db
 
 import (
 	"database/sql"
-	_ "github.com/lib/pq"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// The supported databases, Postgres in production and SQLite
+// for the local development and the tests.
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
 )
 
 var DB *sql.DB
 
-func InitDB(dsn string) error {
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return err
+// Driver is the database DB connects to, set by Open.
+var Driver = Postgres
+
+// Pick returns the query written for the database in use. The repositories
+// share their SQL between the databases and keep a variant only where
+// the dialects differ.
+func Pick(postgres, sqlite string) string {
+	if Driver == SQLite {
+		return sqlite
 	}
-	if err := db.Ping(); err != nil {
-		return err
+	return postgres
+}
+
+// Array passes a slice as a single argument, see InArray.
+func Array(v any) any {
+	if Driver == SQLite {
+		data, err := json.Marshal(v)
+		if err != nil {
+			panic(fmt.Sprintf("db.Array: %v", err))
+		}
+		return string(data)
 	}
-	DB = db
-	return nil
+	return pq.Array(v)
+}
+
+// InArray is the condition matching column against an argument made by Array.
+func InArray(column, param string) string {
+	return Pick(
+		column+" = ANY("+param+")",
+		column+" IN (SELECT value FROM json_each("+param+"))",
+	)
 }
@@ -0,0 +1,43 @@
+// Package dbtest gives the tests a migrated database of their own.
+package dbtest
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/migration"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// OpenSQLite connects db.DB to a new SQLite database in a temporary directory
+// with all of the migrations applied, and closes it when the test ends.
+// The repositories keep the connection they are created with, so the services
+// and the server under test have to be created after the call.
+func OpenSQLite(t testing.TB) {
+	t.Helper()
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "test.db")
+
+	// the migrator closes the database it is given
+	database, err := db.Open(ctx, db.SQLite, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migration.New(ctx, database)
+	if err != nil {
+		database.Close()
+		t.Fatal(err)
+	}
+	err = m.Up(0)
+	m.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Connect(ctx, db.SQLite, dsn); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.DB.Close()
+	})
+}
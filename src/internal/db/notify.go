@@ -0,0 +1,48 @@
+package db
+
+import (
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Listen calls handle with the payload of every NOTIFY on the channel
+// until stop is closed. The listener reconnects on its own, handle gets
+// an empty payload after a reconnection, as the notifications sent
+// in between are lost. SQLite has no notifications, Listen returns at once.
+func Listen(dsn, channel string, stop <-chan struct{}, handle func(payload string)) {
+	if Driver != Postgres {
+		return
+	}
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			log.Printf("listen %s: disconnected: %v", channel, err)
+		case pq.ListenerEventReconnected:
+			log.Printf("listen %s: reconnected", channel)
+			handle("")
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Printf("listen %s: %v", channel, err)
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(channel); err != nil {
+		log.Printf("listen %s: %v", channel, err)
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case n := <-listener.Notify:
+			// a nil notification follows a reconnection, handled above
+			if n != nil {
+				handle(n.Extra)
+			}
+		case <-time.After(time.Minute):
+			// the listener can't notice a silently dropped connection by itself
+			go listener.Ping()
+		}
+	}
+}
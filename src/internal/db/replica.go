@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The replicas take the heavy listings off the primary, see Query.
+// A user who has just written reads from the primary for a while, so that
+// the replication lag doesn't hide their own changes. The writes are kept
+// in the memory of the instance, a user whose next request is balanced to
+// another instance may read from a replica within the window. The replicas
+// failing their health check are skipped until they recover.
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+
+	mu  sync.Mutex
+	err error
+}
+
+var (
+	replicas    []*replica
+	nextReplica atomic.Uint64
+
+	readYourWrites = 5 * time.Second
+	writesMu       sync.Mutex
+	lastWrites     = map[string]time.Time{}
+)
+
+// The routing counters are published on /debug/vars.
+var (
+	replicaStats     = expvar.NewMap("db_replicas")
+	replicaReads     = new(expvar.Int)
+	primaryReads     = new(expvar.Int)
+	replicaFailovers = new(expvar.Int)
+)
+
+func init() {
+	replicaStats.Set("replica_reads", replicaReads)
+	replicaStats.Set("primary_reads", primaryReads)
+	replicaStats.Set("failovers", replicaFailovers)
+}
+
+// ReplicaState is the outcome of the last health check of a replica.
+type ReplicaState struct {
+	Name    string
+	Healthy bool
+	Err     error
+}
+
+// SetReadYourWritesWindow is called once from the config before the server starts.
+func SetReadYourWritesWindow(d time.Duration) {
+	readYourWrites = d
+}
+
+// AddReplica opens a replica of the primary. It doesn't wait for the replica,
+// which only gets queries once it passes a health check, see CheckReplicas.
+func AddReplica(dsn string) (*sql.DB, error) {
+	db, err := sql.Open(Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	replicas = append(replicas, &replica{
+		name: fmt.Sprintf("replica%d", len(replicas)+1),
+		db:   db,
+	})
+	return db, nil
+}
+
+// CloseReplicas closes the replicas opened by AddReplica.
+func CloseReplicas() {
+	for _, r := range replicas {
+		r.db.Close()
+	}
+}
+
+// CheckReplicas pings the replicas every interval until stop is closed.
+func CheckReplicas(interval, timeout time.Duration, stop <-chan struct{}) {
+	checkReplicas(timeout)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			checkReplicas(timeout)
+			forgetWrites()
+		}
+	}
+}
+
+func checkReplicas(timeout time.Duration) {
+	for _, r := range replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		r.setHealth(r.db.PingContext(ctx))
+		cancel()
+	}
+}
+
+func (r *replica) setHealth(err error) {
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+	healthy := err == nil
+	if r.healthy.Swap(healthy) != healthy {
+		if healthy {
+			log.Printf("db %s is healthy, the listings read from it", r.name)
+		} else {
+			log.Printf("db %s is unhealthy, the listings read from the primary: %v", r.name, err)
+		}
+	}
+}
+
+// ReplicaStates reports the health of every replica.
+func ReplicaStates() []ReplicaState {
+	states := make([]ReplicaState, 0, len(replicas))
+	for _, r := range replicas {
+		r.mu.Lock()
+		states = append(states, ReplicaState{Name: r.name, Healthy: r.healthy.Load(), Err: r.err})
+		r.mu.Unlock()
+	}
+	return states
+}
+
+// MarkWritten sends the following reads of the user to the primary
+// for the read-your-writes window.
+func MarkWritten(username string) {
+	if len(replicas) == 0 || username == "" {
+		return
+	}
+	writesMu.Lock()
+	lastWrites[username] = time.Now()
+	writesMu.Unlock()
+}
+
+func wroteRecently(username string) bool {
+	if username == "" {
+		return false
+	}
+	writesMu.Lock()
+	defer writesMu.Unlock()
+	at, ok := lastWrites[username]
+	return ok && time.Since(at) < readYourWrites
+}
+
+// forgetWrites drops the writes older than the window, so that
+// the users who stopped writing don't pile up.
+func forgetWrites() {
+	writesMu.Lock()
+	defer writesMu.Unlock()
+	for username, at := range lastWrites {
+		if time.Since(at) >= readYourWrites {
+			delete(lastWrites, username)
+		}
+	}
+}
+
+// PrimaryReader is the reader of Query for the results kept past
+// the replication lag, such as the cached pages, it reads from the primary.
+const PrimaryReader = "\x00primary"
+
+// pickReplica takes the healthy replicas in turn, it returns nil
+// when the reader has to read from the primary.
+func pickReplica(reader string) *replica {
+	if len(replicas) == 0 || reader == PrimaryReader || wroteRecently(reader) {
+		return nil
+	}
+	start := nextReplica.Add(1)
+	for i := range uint64(len(replicas)) {
+		r := replicas[(start+i)%uint64(len(replicas))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// Query runs a read-only query on a healthy replica, or on primary when there
+// is none or reader, the username the query reads for, has written within the
+// read-your-writes window. The query runs again on primary if the replica
+// fails it with a retryable error, a replica losing the connection is
+// marked unhealthy until its next health check.
+func Query(primary *sql.DB, reader, query string, args ...any) (*sql.Rows, error) {
+	r := pickReplica(reader)
+	if r == nil {
+		primaryReads.Add(1)
+		return primary.Query(query, args...)
+	}
+	rows, err := r.db.Query(query, args...)
+	if err != nil && IsRetryable(err) {
+		// a query cancelled by a recovery conflict leaves the replica healthy
+		if !isPostgresError(err) {
+			r.setHealth(err)
+		}
+		replicaFailovers.Add(1)
+		primaryReads.Add(1)
+		return primary.Query(query, args...)
+	}
+	replicaReads.Add(1)
+	return rows, err
+}
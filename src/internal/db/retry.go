@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"expvar"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RetryPolicy bounds the retries of a transaction. The backoff doubles
+// after every attempt up to MaxBackoff, with a random jitter.
+type RetryPolicy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var retryPolicy = RetryPolicy{
+	Attempts:       3,
+	InitialBackoff: 50 * time.Millisecond,
+	MaxBackoff:     time.Second,
+}
+
+// SetRetryPolicy is called once from the config, before the first connection is opened.
+func SetRetryPolicy(p RetryPolicy) {
+	retryPolicy = p
+}
+
+// The retry counters are published on /debug/vars.
+var (
+	retryStats        = expvar.NewMap("db_retries")
+	retriedTx         = new(expvar.Int)
+	exhaustedTx       = new(expvar.Int)
+	retriedConnection = new(expvar.Int)
+)
+
+func init() {
+	retryStats.Set("transactions_retried", retriedTx)
+	retryStats.Set("transactions_exhausted", exhaustedTx)
+	retryStats.Set("connection_attempts_retried", retriedConnection)
+}
+
+// IsRetryable tells if the operation may succeed when run again:
+// serialization failures, deadlocks and lost connections,
+// or a SQLite database locked for too long.
+func IsRetryable(err error) bool {
+	if isSQLiteBusy(err) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "40001", pqErr.Code == "40P01":
+			return true
+		// connection exceptions and the server shutting down or starting up
+		case pqErr.Code.Class() == "08", pqErr.Code == "57P01", pqErr.Code == "57P03":
+			return true
+		}
+		return false
+	}
+	return isConnectionError(err)
+}
+
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// RunInTx runs fn in a transaction, retrying the whole transaction while
+// it fails with a retryable error. fn may run several times, so it must
+// not have side effects outside of tx.
+func RunInTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	backoff := retryPolicy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		committed, err := runInTx(db, fn)
+		// a commit lost with the connection may have been applied,
+		// only the errors reported by Postgres are safe to retry
+		if err == nil || !IsRetryable(err) || (committed && !isPostgresError(err)) {
+			return err
+		}
+		if attempt >= retryPolicy.Attempts {
+			exhaustedTx.Add(1)
+			return err
+		}
+		retriedTx.Add(1)
+		time.Sleep(jitter(backoff))
+		backoff = min(2*backoff, retryPolicy.MaxBackoff)
+	}
+}
+
+// runInTx reports whether the error came from the commit.
+func runInTx(db *sql.DB, fn func(tx *sql.Tx) error) (committed bool, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			committed = true
+			err = tx.Commit()
+		}
+	}()
+
+	err = fn(tx)
+
+	return false, err
+}
+
+func isPostgresError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr)
+}
+
+// Connect opens DB, see Open.
+func Connect(ctx context.Context, driver, dsn string) error {
+	db, err := Open(ctx, driver, dsn)
+	if err != nil {
+		return err
+	}
+	DB = db
+	return nil
+}
+
+// Open pings the database until it answers or ctx is done,
+// so the service survives Postgres starting after it.
+// driver is Postgres or SQLite, it becomes the Driver of the process.
+func Open(ctx context.Context, driver, dsn string) (*sql.DB, error) {
+	if driver == SQLite {
+		dsn = sqliteDSN(dsn)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	Driver = driver
+	backoff := retryPolicy.InitialBackoff
+	for {
+		err = db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+		if ctx.Err() != nil || !IsRetryable(err) {
+			db.Close()
+			return nil, err
+		}
+		retriedConnection.Add(1)
+		log.Printf("db is not reachable, retrying in %s: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, err
+		case <-time.After(jitter(backoff)):
+		}
+		// keep polling at least every 5 seconds during the startup
+		backoff = min(2*backoff, max(retryPolicy.MaxBackoff, 5*time.Second))
+	}
+}
+
+// jitter spreads the retries of concurrent clients over [d/2, d).
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
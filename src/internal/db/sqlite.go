@@ -0,0 +1,52 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteParams make the connections behave closer to Postgres: foreign keys
+// are enforced, the transactions take the write lock when they begin rather
+// than failing on their first write, and the writers wait for each other.
+// The times are stored in the format of CURRENT_TIMESTAMP, so they compare
+// as strings.
+var sqliteParams = []string{
+	"_pragma=foreign_keys(1)",
+	"_pragma=journal_mode(WAL)",
+	"_pragma=busy_timeout(10000)",
+	"_txlock=immediate",
+	"_time_format=sqlite",
+}
+
+func init() {
+	// the SQLite schema uses the same default for the ids as the Postgres one
+	sqlite.MustRegisterScalarFunction("uuid_generate_v4", 0,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			return uuid.NewString(), nil
+		})
+}
+
+// sqliteDSN adds sqliteParams to a file name or a file: URI.
+func sqliteDSN(dsn string) string {
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + strings.Join(sqliteParams, "&")
+}
+
+// isSQLiteBusy tells if the database stayed locked by another
+// connection for longer than the busy timeout.
+func isSQLiteBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code() & 0xff
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
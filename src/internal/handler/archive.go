@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"avito-back-test/internal/service"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// maxArchiveSize bounds the uploaded archives.
+const maxArchiveSize = 64 << 20
+
+type ArchiveHandler struct {
+	srv *service.ArchiveService
+}
+
+func NewArchiveHandler(adminUsernames []string) *ArchiveHandler {
+	srv := service.NewArchiveService(adminUsernames)
+	return &ArchiveHandler{
+		srv: srv,
+	}
+}
+
+// ExportOrganization sends the archive as JSON, or as ZIP with format=zip.
+func (h *ArchiveHandler) ExportOrganization(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+	compress := false
+	switch r.Form.Get("format") {
+	case "", "json":
+	case "zip":
+		compress = true
+	default:
+		JSONResponse(w, map[string]string{"reason": "format has to be json or zip"}, 400)
+		return
+	}
+	organizationID, err := uuid.Parse(mux.Vars(r)["organizationId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": "organizationId is not a valid uuid"}, 400)
+		return
+	}
+
+	archive, err := h.srv.ExportAsAdmin(r.Context(), organizationID, username)
+	if err == service.ErrNotAdmin || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err == service.ErrNoOrganization {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 500)
+		return
+	}
+
+	// encode first, so an error still gets a proper status
+	var body bytes.Buffer
+	if err := service.EncodeArchive(&body, archive, compress); err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 500)
+		return
+	}
+	if compress {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "organization-"+organizationID.String()+".zip"))
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(200)
+	w.Write(body.Bytes())
+}
+
+// ImportOrganization takes a JSON or ZIP archive as the body. The tenders are
+// imported into an existing organization if organizationId is passed.
+func (h *ArchiveHandler) ImportOrganization(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+	var into *uuid.UUID
+	if r.Form.Has("organizationId") {
+		id, err := uuid.Parse(r.Form.Get("organizationId"))
+		if err != nil {
+			JSONResponse(w, map[string]string{"reason": "organizationId is not a valid uuid"}, 400)
+			return
+		}
+		into = &id
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxArchiveSize))
+	if bodyTooLarge(err) {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 413)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	archive, err := service.DecodeArchive(data)
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+
+	result, conflicts, err := h.srv.ImportAsAdmin(r.Context(), archive, into, username)
+	if err == service.ErrNotAdmin || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err == service.ErrImportConflicts {
+		JSONResponse(w, map[string]any{"reason": err.Error(), "conflicts": conflicts}, 409)
+		return
+	}
+	if err == service.ErrArchiveFormat || errors.Is(err, service.ErrInvalidArchive) {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 500)
+		return
+	}
+	JSONResponse(w, *result, 201)
+}
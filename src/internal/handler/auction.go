@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type AuctionHandler struct {
+	srv *service.AuctionService
+}
+
+func NewAuctionHandler() *AuctionHandler {
+	srv := service.NewAuctionService()
+	return &AuctionHandler{
+		srv: srv,
+	}
+}
+
+func (h *AuctionHandler) GetAuction(w http.ResponseWriter, r *http.Request) {
+	var username *string
+
+	requestVars := mux.Vars(r)
+	tenderID, err := uuid.Parse(requestVars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if r.Form.Has("username") {
+		username = new(string)
+		*username = r.Form.Get("username")
+	}
+
+	auction, err := h.srv.GetAuction(r.Context(), tenderID, username)
+
+	if err == service.ErrNoTender || err == service.ErrNoAuction {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *auction, 200)
+}
+
+func (h *AuctionHandler) GetAuctionOffers(w http.ResponseWriter, r *http.Request) {
+	var (
+		offers   []model.AuctionOffer
+		username *string
+
+		// query parameters
+		limit, offset int
+	)
+
+	queryValues := r.URL.Query()
+	limit, offset, err := parseQueryLimitOffset(&queryValues)
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if queryValues.Has("username") {
+		username = new(string)
+		*username = queryValues.Get("username")
+	}
+	requestVars := mux.Vars(r)
+	tenderID, err := uuid.Parse(requestVars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+
+	offers, err = h.srv.GetAuctionOffers(r.Context(), tenderID, username, limit, offset)
+
+	if err == service.ErrNoTender || err == service.ErrNoAuction {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if offers == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(offers); err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 500)
+		return
+	}
+}
+
+func (h *AuctionHandler) SubmitOffer(w http.ResponseWriter, r *http.Request) {
+	var (
+		username string
+		price    float64
+	)
+	vars := mux.Vars(r)
+	bidID, err := uuid.Parse(vars["bidId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if r.Form.Has("username") {
+		username = r.Form.Get("username")
+	} else {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	if r.Form.Has("price") {
+		price, err = strconv.ParseFloat(r.Form.Get("price"), 64)
+		if err != nil {
+			JSONResponse(w, map[string]string{"reason": "invalid price"}, 400)
+			return
+		}
+	} else {
+		JSONResponse(w, map[string]string{"reason": "price is required"}, 400)
+		return
+	}
+
+	offer, err := h.srv.SubmitOffer(r.Context(), bidID, username, price)
+	if err == service.ErrNoBid || err == service.ErrNoAuction {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrBidCanceled || err == service.ErrBidNotPublished || err == service.ErrAuctionNotRunning {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrOfferTooHigh {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 409)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *offer, 200)
+}
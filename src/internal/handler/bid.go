@@ -16,9 +16,9 @@ type BidHandler struct {
 	decisionService *service.BidDecisionService
 }
 
-func NewBidHandler() *BidHandler {
+func NewBidHandler(decisionQuorum int) *BidHandler {
 	srv := service.NewBidService()
-	decisionService := service.NewBidDecisionService()
+	decisionService := service.NewBidDecisionService(decisionQuorum)
 	return &BidHandler{
 		srv:             srv,
 		decisionService: decisionService,
@@ -32,6 +32,7 @@ func (h *BidHandler) InsertNewBid(w http.ResponseWriter, r *http.Request) {
 		TenderID    string `json:"tenderId"`
 		AuthorType  string `json:"authorType"`
 		AuthorID    string `json:"authorId"`
+		LotID       string `json:"lotId"`
 	}
 
 	// Parse the JSON request body
@@ -62,17 +63,34 @@ func (h *BidHandler) InsertNewBid(w http.ResponseWriter, r *http.Request) {
 		AuthorType:  bidRequest.AuthorType,
 		AuthorID:    authorId,
 	}
+	if len(bidRequest.LotID) != 0 {
+		lotID, err := uuid.Parse(bidRequest.LotID)
+		if err != nil {
+			JSONResponse(w, map[string]string{"reason": "Invalid lotId format"}, 400)
+			return
+		}
+		newBid.LotID = &lotID
+	}
 
 	// Pass to the service
-	err := h.srv.InsertNewBid(&newBid)
+	err := h.srv.InsertNewBid(r.Context(), &newBid)
 	if err == service.ErrNoEmployee || err == service.ErrNoOrganization {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
 		return
 	}
-	if err == service.ErrNoTender {
+	if err == service.ErrNoTender || err == service.ErrNoLot {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
 		return
 	}
+	if err == service.ErrLotDecided || err == service.ErrNotQualified || err == service.ErrConflictOfInterest ||
+		err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrLotRequired {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
 	if err != nil {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 500)
 		return
@@ -86,8 +104,9 @@ func (h *BidHandler) GetMyBids(w http.ResponseWriter, r *http.Request) {
 		err  error
 
 		// query parameters
-		limit, offset int
-		username      []string
+		limit, offset  int
+		username       []string
+		includeHistory bool
 	)
 
 	queryValues := r.URL.Query()
@@ -96,13 +115,18 @@ func (h *BidHandler) GetMyBids(w http.ResponseWriter, r *http.Request) {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
 		return
 	}
+	includeHistory, err = parseQueryIncludeHistory(&queryValues)
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
 	username, ok := queryValues["username"]
 	if !ok {
 		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
 		return
 	}
 
-	bids, err = h.srv.GetUserBids(username[0], limit, offset)
+	bids, err = h.srv.GetUserBids(r.Context(), username[0], includeHistory, limit, offset)
 	if err == service.ErrNoEmployee {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
 		return
@@ -148,8 +172,17 @@ func (h *BidHandler) GetBidsByTender(w http.ResponseWriter, r *http.Request) {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
 		return
 	}
+	var lotID *uuid.UUID
+	if sLotID, ok := queryValues["lotId"]; ok {
+		id, err := uuid.Parse(sLotID[0])
+		if err != nil {
+			JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+			return
+		}
+		lotID = &id
+	}
 
-	bids, err = h.srv.GetBidsByTender(tenderID, username[0], limit, offset)
+	bids, err = h.srv.GetBidsByTender(r.Context(), tenderID, lotID, username[0], limit, offset)
 
 	if err == service.ErrNoEmployee {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
@@ -159,7 +192,7 @@ func (h *BidHandler) GetBidsByTender(w http.ResponseWriter, r *http.Request) {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible {
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
 		return
 	}
@@ -191,7 +224,7 @@ func (h *BidHandler) GetBidStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	status, err := h.srv.GetBidStatus(bidID, username[0])
+	status, err := h.srv.GetBidStatus(r.Context(), bidID, username[0])
 
 	if err == service.ErrNoEmployee {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
@@ -201,7 +234,7 @@ func (h *BidHandler) GetBidStatus(w http.ResponseWriter, r *http.Request) {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible {
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
 		return
 	}
@@ -231,20 +264,24 @@ func (h *BidHandler) UpdateBidStatus(w http.ResponseWriter, r *http.Request) {
 		ID:     bidID,
 		Status: status,
 	}
-	err = h.srv.UpdateBidStatus(&bid, username)
+	err = h.srv.UpdateBidStatus(r.Context(), &bid, username)
 
 	if err == service.ErrNoEmployee {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
 		return
 	}
-	if err == service.ErrNoBid {
+	if err == service.ErrNoBid || err == service.ErrNoTender {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible || err == service.ErrBidCanceled {
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
 		return
 	}
+	if err == service.ErrIllegalTransition {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 409)
+		return
+	}
 	if err != nil {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
 		return
@@ -252,6 +289,40 @@ func (h *BidHandler) UpdateBidStatus(w http.ResponseWriter, r *http.Request) {
 	JSONResponse(w, bid, 200)
 }
 
+func (h *BidHandler) GetBidTransitions(w http.ResponseWriter, r *http.Request) {
+	requestVars := mux.Vars(r)
+	bidID, err := uuid.Parse(requestVars["bidId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	transitions, err := h.srv.GetBidTransitions(r.Context(), bidID, username)
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err == service.ErrNoBid || err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *transitions, 200)
+}
+
 func (h *BidHandler) UpdateBid(w http.ResponseWriter, r *http.Request) {
 	var (
 		bidUpdate model.BidUpdate
@@ -279,12 +350,12 @@ func (h *BidHandler) UpdateBid(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedBid, err := h.srv.PatchBid(bidID, username, &bidUpdate)
+	updatedBid, err := h.srv.PatchBid(r.Context(), bidID, username, &bidUpdate)
 	if err == service.ErrNoBid {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible || err == service.ErrBidCanceled {
+	if err == service.ErrNotResponsible || err == service.ErrBidCanceled || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
 		return
 	}
@@ -328,12 +399,12 @@ func (h *BidHandler) RollbackBid(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedBid, err := h.srv.RollbackBid(bidID, username, version)
+	updatedBid, err := h.srv.RollbackBid(r.Context(), bidID, username, version)
 	if err == service.ErrNoBid {
 		JSONResponse(w, map[string]string{"reason": "no bid with specified version"}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible || err == service.ErrBidCanceled {
+	if err == service.ErrNotResponsible || err == service.ErrBidCanceled || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
 		return
 	}
@@ -373,13 +444,13 @@ func (h *BidHandler) LeaveFeedback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bid, err := h.srv.LeaveFeedback(username, bidID, feedback)
+	bid, err := h.srv.LeaveFeedback(r.Context(), username, bidID, feedback)
 
 	if err == service.ErrNoBid {
 		JSONResponse(w, map[string]string{"reason": "bid not found"}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible {
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
 		return
 	}
@@ -428,7 +499,7 @@ func (h *BidHandler) GetTenderReviewsOnUser(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	reviews, err = h.srv.GetTenderReviewsOnUser(tenderID, authorUsername[0], requesterUsername[0], limit, offset)
+	reviews, err = h.srv.GetTenderReviewsOnUser(r.Context(), tenderID, authorUsername[0], requesterUsername[0], limit, offset)
 
 	if err == service.ErrNoEmployee {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
@@ -438,7 +509,7 @@ func (h *BidHandler) GetTenderReviewsOnUser(w http.ResponseWriter, r *http.Reque
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible {
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
 		return
 	}
@@ -481,12 +552,22 @@ func (h *BidHandler) SubmitDecision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bid, err := h.decisionService.SubmitDecision(bidID, username, decision)
+	bid, err := h.decisionService.SubmitDecision(r.Context(), bidID, username, decision)
+	if err == service.ErrLotDecided || err == service.ErrConflictOfInterest {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	// the bid or the tender has left the status the decision moves it from,
+	// or the bid is on the whole tender while its lots are open
+	if err == service.ErrIllegalTransition || err == service.ErrLotRequired {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 409)
+		return
+	}
 	if err == service.ErrNoBid {
 		JSONResponse(w, map[string]string{"reason": "bid not found"}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible {
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
 		return
 	}
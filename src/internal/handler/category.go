@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type CategoryHandler struct {
+	srv *service.CategoryService
+}
+
+func NewCategoryHandler(adminUsernames []string) *CategoryHandler {
+	srv := service.NewCategoryService(adminUsernames)
+	return &CategoryHandler{
+		srv: srv,
+	}
+}
+
+// requestLocale takes the locale from the lang query parameter
+// or the first language of the Accept-Language header.
+func requestLocale(r *http.Request) string {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		return lang
+	}
+	lang, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	lang, _, _ = strings.Cut(lang, ";")
+	lang = strings.TrimSpace(lang)
+	if lang == "" || lang == "*" {
+		return model.DefaultLocale
+	}
+	return lang
+}
+
+func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.srv.GetCategories(requestLocale(r))
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 500)
+		return
+	}
+	if categories == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, categories, 200)
+}
+
+func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	category, err := h.srv.GetCategory(vars["code"], requestLocale(r))
+	if err == service.ErrNoCategory {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 500)
+		return
+	}
+	JSONResponse(w, *category, 200)
+}
+
+func (h *CategoryHandler) InsertCategory(w http.ResponseWriter, r *http.Request) {
+	var category model.ServiceCategory
+
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	if category.ParentCode != nil && *category.ParentCode == "" {
+		category.ParentCode = nil
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	err := h.srv.InsertCategory(r.Context(), &category, username, requestLocale(r))
+	if err == service.ErrNoCategory {
+		JSONResponse(w, map[string]string{"reason": "parent category not found"}, 404)
+		return
+	}
+	if err == service.ErrCategoryExists {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 409)
+		return
+	}
+	if err == service.ErrNotAdmin || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, category, 200)
+}
+
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	var update model.ServiceCategoryUpdate
+
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	if update.ParentCode == nil && update.Names == nil {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	vars := mux.Vars(r)
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	category, err := h.srv.UpdateCategory(r.Context(), vars["code"], &update, username, requestLocale(r))
+	if err == service.ErrNoCategory {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotAdmin || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrCategoryCycle {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 409)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *category, 200)
+}
+
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	err := h.srv.DeleteCategory(r.Context(), vars["code"], username)
+	if err == service.ErrNoCategory {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotAdmin || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrCategoryInUse {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 409)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, map[string]string{"code": vars["code"]}, 200)
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type ClarificationHandler struct {
+	srv *service.ClarificationService
+}
+
+func NewClarificationHandler() *ClarificationHandler {
+	srv := service.NewClarificationService()
+	return &ClarificationHandler{
+		srv: srv,
+	}
+}
+
+func (h *ClarificationHandler) AskQuestion(w http.ResponseWriter, r *http.Request) {
+	var questionRequest struct {
+		Question string `json:"question"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&questionRequest); err != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	if len(questionRequest.Question) == 0 {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	question, err := h.srv.AskQuestion(r.Context(), tenderID, username, questionRequest.Question)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrOwnTenderQuestion || err == service.ErrQuestionDeadlinePassed {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *question, 200)
+}
+
+func (h *ClarificationHandler) AnswerQuestion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	questionID, err := uuid.Parse(vars["questionId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") || !r.Form.Has("answer") {
+		JSONResponse(w, map[string]string{"reason": "username, answer are required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+	answer := r.Form.Get("answer")
+
+	question, err := h.srv.AnswerQuestion(r.Context(), tenderID, questionID, username, answer)
+	if err == service.ErrNoTender || err == service.ErrNoQuestion {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *question, 200)
+}
+
+func (h *ClarificationHandler) GetQuestions(w http.ResponseWriter, r *http.Request) {
+	var (
+		questions []model.Clarification
+		username  *string
+
+		// query parameters
+		limit, offset int
+	)
+
+	queryValues := r.URL.Query()
+	limit, offset, err := parseQueryLimitOffset(&queryValues)
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if queryValues.Has("username") {
+		username = new(string)
+		*username = queryValues.Get("username")
+	}
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+
+	questions, err = h.srv.GetQuestions(r.Context(), tenderID, username, limit, offset)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if questions == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, questions, 200)
+}
+
+func (h *ClarificationHandler) GetQuestionDeadline(w http.ResponseWriter, r *http.Request) {
+	var username *string
+
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if r.Form.Has("username") {
+		username = new(string)
+		*username = r.Form.Get("username")
+	}
+
+	deadline, err := h.srv.GetQuestionDeadline(r.Context(), tenderID, username)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, map[string]*time.Time{"deadline": deadline}, 200)
+}
+
+// SetQuestionDeadline sets the deadline in RFC 3339 format,
+// an empty deadline removes it.
+func (h *ClarificationHandler) SetQuestionDeadline(w http.ResponseWriter, r *http.Request) {
+	var deadline *time.Time
+
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") || !r.Form.Has("deadline") {
+		JSONResponse(w, map[string]string{"reason": "username, deadline are required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+	if sDeadline := r.Form.Get("deadline"); len(sDeadline) != 0 {
+		parsed, err := time.Parse(time.RFC3339, sDeadline)
+		if err != nil {
+			JSONResponse(w, map[string]string{"reason": "invalid deadline"}, 400)
+			return
+		}
+		deadline = &parsed
+	}
+
+	err = h.srv.SetQuestionDeadline(r.Context(), tenderID, username, deadline)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, map[string]*time.Time{"deadline": deadline}, 200)
+}
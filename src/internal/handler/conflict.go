@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type ConflictHandler struct {
+	srv *service.ConflictService
+}
+
+func NewConflictHandler(adminUsernames []string) *ConflictHandler {
+	srv := service.NewConflictService(adminUsernames)
+	return &ConflictHandler{
+		srv: srv,
+	}
+}
+
+func (h *ConflictHandler) DeclareRecusal(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+	reason := r.Form.Get("reason")
+	if len(reason) > 500 {
+		JSONResponse(w, map[string]string{"reason": "reason is too long"}, 400)
+		return
+	}
+
+	recusal, err := h.srv.DeclareRecusal(r.Context(), tenderID, username, reason)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *recusal, 200)
+}
+
+func (h *ConflictHandler) GetRecusals(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	recusals, err := h.srv.GetRecusals(r.Context(), tenderID, username)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if recusals == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, recusals, 200)
+}
+
+func (h *ConflictHandler) GetConflicts(w http.ResponseWriter, r *http.Request) {
+	var (
+		conflicts []model.ConflictRecord
+
+		// query parameters
+		limit, offset int
+	)
+
+	queryValues := r.URL.Query()
+	limit, offset, err := parseQueryLimitOffset(&queryValues)
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	username, ok := queryValues["username"]
+	if !ok {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+
+	conflicts, err = h.srv.GetConflicts(r.Context(), tenderID, username[0], limit, offset)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if conflicts == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, conflicts, 200)
+}
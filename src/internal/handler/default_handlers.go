@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
@@ -11,6 +12,12 @@ func JSONResponse(w http.ResponseWriter, response interface{}, code int) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// bodyTooLarge reports whether the request body exceeded its http.MaxBytesReader limit.
+func bodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
 func PingHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("ok"))
 }
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type EvaluationHandler struct {
+	srv *service.EvaluationService
+}
+
+func NewEvaluationHandler() *EvaluationHandler {
+	srv := service.NewEvaluationService()
+	return &EvaluationHandler{
+		srv: srv,
+	}
+}
+
+func (h *EvaluationHandler) GetCriteria(w http.ResponseWriter, r *http.Request) {
+	var username *string
+
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if r.Form.Has("username") {
+		username = new(string)
+		*username = r.Form.Get("username")
+	}
+
+	criteria, err := h.srv.GetCriteria(r.Context(), tenderID, username)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if criteria == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, criteria, 200)
+}
+
+func (h *EvaluationHandler) SubmitScores(w http.ResponseWriter, r *http.Request) {
+	var scores []model.BidScore
+
+	if err := json.NewDecoder(r.Body).Decode(&scores); err != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	if len(scores) == 0 {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	vars := mux.Vars(r)
+	bidID, err := uuid.Parse(vars["bidId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	err = h.srv.SubmitScores(r.Context(), bidID, username, scores)
+	if err == service.ErrNoBid {
+		JSONResponse(w, map[string]string{"reason": "bid not found"}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrTenderClosed || err == service.ErrConflictOfInterest {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, scores, 200)
+}
+
+func (h *EvaluationHandler) GetTenderEvaluation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	evaluations, err := h.srv.GetTenderEvaluation(r.Context(), tenderID, username)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if evaluations == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, evaluations, 200)
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// draining is set when the shutdown starts, so the load balancers
+// stop sending traffic before the server stops accepting it.
+var draining atomic.Bool
+
+func StartDraining() {
+	draining.Store(true)
+}
+
+type HealthHandler struct {
+	srv *service.HealthService
+}
+
+func NewHealthHandler(checkTimeout time.Duration) *HealthHandler {
+	srv := service.NewHealthService(checkTimeout)
+	return &HealthHandler{
+		srv: srv,
+	}
+}
+
+// Liveness only tells that the process serves requests.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	JSONResponse(w, map[string]string{"status": model.HealthStatusOk}, 200)
+}
+
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	if draining.Load() {
+		JSONResponse(w, map[string]any{"status": model.HealthStatusFail, "draining": true}, 503)
+		return
+	}
+	readiness := h.srv.CheckReadiness(r.Context())
+	if readiness.Status != model.HealthStatusOk {
+		JSONResponse(w, readiness, 503)
+		return
+	}
+	JSONResponse(w, readiness, 200)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"avito-back-test/internal/service"
+	"net/http"
+	"strconv"
+)
+
+type IntegrityHandler struct {
+	srv *service.IntegrityService
+}
+
+func NewIntegrityHandler(adminUsernames []string) *IntegrityHandler {
+	srv := service.NewIntegrityService(adminUsernames)
+	return &IntegrityHandler{
+		srv: srv,
+	}
+}
+
+func (h *IntegrityHandler) GetIntegrityReport(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	report, err := h.srv.CheckAsAdmin(r.Context(), username)
+	if err == service.ErrNotAdmin || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 500)
+		return
+	}
+	JSONResponse(w, *report, 200)
+}
+
+// RepairIntegrity is a dry run unless dryRun=false is passed.
+func (h *IntegrityHandler) RepairIntegrity(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+	dryRun := true
+	if r.Form.Has("dryRun") {
+		var err error
+		dryRun, err = strconv.ParseBool(r.Form.Get("dryRun"))
+		if err != nil {
+			JSONResponse(w, map[string]string{"reason": "dryRun has to be true or false"}, 400)
+			return
+		}
+	}
+
+	report, err := h.srv.RepairAsAdmin(r.Context(), username, dryRun)
+	if err == service.ErrNotAdmin || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 500)
+		return
+	}
+	JSONResponse(w, *report, 200)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type LotHandler struct {
+	srv *service.LotService
+}
+
+func NewLotHandler() *LotHandler {
+	srv := service.NewLotService()
+	return &LotHandler{
+		srv: srv,
+	}
+}
+
+func (h *LotHandler) InsertNewLot(w http.ResponseWriter, r *http.Request) {
+	var lotRequest struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		ServiceType string `json:"serviceType"`
+	}
+
+	// Parse the JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&lotRequest); err != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	if len(lotRequest.Name) == 0 || len(lotRequest.Description) == 0 {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	newLot := model.Lot{
+		TenderID:    tenderID,
+		Name:        lotRequest.Name,
+		Description: lotRequest.Description,
+		ServiceType: lotRequest.ServiceType,
+	}
+
+	err = h.srv.InsertNewLot(r.Context(), &newLot, username)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrLotlessBids {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 409)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, newLot, 200)
+}
+
+func (h *LotHandler) GetLots(w http.ResponseWriter, r *http.Request) {
+	var username *string
+
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if r.Form.Has("username") {
+		username = new(string)
+		*username = r.Form.Get("username")
+	}
+
+	lots, err := h.srv.GetLots(r.Context(), tenderID, username)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if lots == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, lots, 200)
+}
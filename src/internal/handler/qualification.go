@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// maxQualificationSize bounds the questionnaires and the submissions
+// with the documents encoded in them.
+const maxQualificationSize = 16 << 20
+
+type QualificationHandler struct {
+	srv *service.QualificationService
+}
+
+func NewQualificationHandler() *QualificationHandler {
+	srv := service.NewQualificationService()
+	return &QualificationHandler{
+		srv: srv,
+	}
+}
+
+func (h *QualificationHandler) SetQuestionnaire(w http.ResponseWriter, r *http.Request) {
+	var items []model.QuestionnaireItem
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxQualificationSize)).Decode(&items)
+	if bodyTooLarge(err) {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 413)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	for _, item := range items {
+		if len(item.Question) == 0 {
+			JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+			return
+		}
+	}
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	items, err = h.srv.SetQuestionnaire(r.Context(), tenderID, username, items)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrQuestionnaireInUse {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 409)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if items == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, items, 200)
+}
+
+func (h *QualificationHandler) GetQuestionnaire(w http.ResponseWriter, r *http.Request) {
+	var username *string
+
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if r.Form.Has("username") {
+		username = new(string)
+		*username = r.Form.Get("username")
+	}
+
+	items, err := h.srv.GetQuestionnaire(r.Context(), tenderID, username)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if items == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, items, 200)
+}
+
+func (h *QualificationHandler) SubmitQualification(w http.ResponseWriter, r *http.Request) {
+	var qualificationRequest struct {
+		OrganizationID string                      `json:"organizationId"`
+		Answers        []model.QualificationAnswer `json:"answers"`
+	}
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxQualificationSize)).Decode(&qualificationRequest)
+	if bodyTooLarge(err) {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 413)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid request payload"}, 400)
+		return
+	}
+	orgID, err := uuid.Parse(qualificationRequest.OrganizationID)
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid organization id format"}, 400)
+		return
+	}
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	qualification := model.Qualification{
+		TenderID:       tenderID,
+		OrganizationID: orgID,
+		Answers:        qualificationRequest.Answers,
+	}
+
+	err = h.srv.SubmitQualification(r.Context(), &qualification, username)
+	if err == service.ErrNoTender || err == service.ErrNoQuestionnaire {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrAlreadyQualified {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 409)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, qualification, 200)
+}
+
+func (h *QualificationHandler) GetQualifications(w http.ResponseWriter, r *http.Request) {
+	var (
+		qualifications []model.Qualification
+		status         *string
+
+		// query parameters
+		limit, offset int
+	)
+
+	queryValues := r.URL.Query()
+	limit, offset, err := parseQueryLimitOffset(&queryValues)
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	username, ok := queryValues["username"]
+	if !ok {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	if sStatus, ok := queryValues["status"]; ok {
+		if sStatus[0] != model.QualificationPending && sStatus[0] != model.QualificationApproved &&
+			sStatus[0] != model.QualificationRejected {
+			JSONResponse(w, map[string]string{"reason": "invalid status"}, 400)
+			return
+		}
+		status = &sStatus[0]
+	}
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+
+	qualifications, err = h.srv.GetQualifications(r.Context(), tenderID, username[0], status, limit, offset)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if qualifications == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, qualifications, 200)
+}
+
+func (h *QualificationHandler) GetQualification(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tenderID, err1 := uuid.Parse(vars["tenderId"])
+	qualificationID, err2 := uuid.Parse(vars["qualificationId"])
+	if err1 != nil || err2 != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid id format"}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	qualification, err := h.srv.GetQualification(r.Context(), tenderID, qualificationID, username)
+	if err == service.ErrNoTender || err == service.ErrNoQualification {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *qualification, 200)
+}
+
+func (h *QualificationHandler) ReviewQualification(w http.ResponseWriter, r *http.Request) {
+	var comment *string
+
+	vars := mux.Vars(r)
+	tenderID, err1 := uuid.Parse(vars["tenderId"])
+	qualificationID, err2 := uuid.Parse(vars["qualificationId"])
+	if err1 != nil || err2 != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid id format"}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") || !r.Form.Has("decision") {
+		JSONResponse(w, map[string]string{"reason": "username, decision are required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+	decision := r.Form.Get("decision")
+	if r.Form.Has("comment") {
+		comment = new(string)
+		*comment = r.Form.Get("comment")
+	}
+
+	qualification, err := h.srv.ReviewQualification(r.Context(), tenderID, qualificationID, username, decision, comment)
+	if err == service.ErrNoTender || err == service.ErrNoQualification {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *qualification, 200)
+}
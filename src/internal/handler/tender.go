@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"avito-back-test/internal/config"
 	"avito-back-test/internal/model"
 	"avito-back-test/internal/service"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -14,19 +16,29 @@ import (
 )
 
 type TenderHandler struct {
-	srv *service.TenderService
+	srv            *service.TenderService
+	auctionService *service.AuctionService
 }
 
 func NewTenderHandler() *TenderHandler {
 	srv := service.NewTenderService()
+	auctionService := service.NewAuctionService()
 	return &TenderHandler{
-		srv: srv,
+		srv:            srv,
+		auctionService: auctionService,
 	}
 }
 
+// pagination is set once from the config before the routes are served
+var pagination = config.PaginationConfig{DefaultLimit: 5, MaxLimit: 100}
+
+func SetPagination(cfg config.PaginationConfig) {
+	pagination = cfg
+}
+
 func parseQueryLimitOffset(query *url.Values) (int, int, error) {
 	var (
-		limit  = 5
+		limit  = pagination.DefaultLimit
 		offset = 0
 		err    error
 	)
@@ -36,6 +48,9 @@ func parseQueryLimitOffset(query *url.Values) (int, int, error) {
 		if err == nil && limit < 1 {
 			return 0, 0, errors.New("limit has to be positive")
 		}
+		if err == nil && limit > pagination.MaxLimit {
+			return 0, 0, fmt.Errorf("limit can't exceed %d", pagination.MaxLimit)
+		}
 	}
 
 	if err != nil {
@@ -45,14 +60,31 @@ func parseQueryLimitOffset(query *url.Values) (int, int, error) {
 	sOffset, ok := (*query)["offset"]
 	if ok {
 		offset, err = strconv.Atoi(sOffset[0])
-		if err == nil && limit < 1 {
+		if err == nil && offset < 0 {
 			return 0, 0, errors.New("offset has to be non-negative")
 		}
 	}
 
+	if err != nil {
+		return 0, 0, err
+	}
+
 	return limit, offset, nil
 }
 
+// parseQueryIncludeHistory reads the flag of the "my" listings,
+// they only show the current versions unless includeHistory=true.
+func parseQueryIncludeHistory(query *url.Values) (bool, error) {
+	if !query.Has("includeHistory") {
+		return false, nil
+	}
+	includeHistory, err := strconv.ParseBool(query.Get("includeHistory"))
+	if err != nil {
+		return false, errors.New("includeHistory has to be true or false")
+	}
+	return includeHistory, nil
+}
+
 func (h *TenderHandler) GetTenders(w http.ResponseWriter, r *http.Request) {
 	var (
 		tenders []model.Tender
@@ -69,12 +101,23 @@ func (h *TenderHandler) GetTenders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// invite-only tenders are listed to the invited employees only
+	var username *string
+	if queryValues.Has("username") {
+		username = new(string)
+		*username = queryValues.Get("username")
+	}
+
 	if serviceType, ok := queryValues["service_type"]; ok {
-		tenders, err = h.srv.GetTendersOfService(serviceType[0], limit, offset)
+		tenders, err = h.srv.GetTendersOfService(r.Context(), serviceType[0], username, limit, offset)
 	} else {
-		tenders, err = h.srv.GetTenders(limit, offset)
+		tenders, err = h.srv.GetTenders(r.Context(), username, limit, offset)
 	}
 
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
 	if err != nil {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
 		return
@@ -97,6 +140,12 @@ func (h *TenderHandler) InsertNewTender(w http.ResponseWriter, r *http.Request)
 		ServiceType     string `json:"serviceType"`
 		OrganizationID  string `json:"organizationId"`
 		CreatorUsername string `json:"creatorUsername"`
+		Visibility      string `json:"visibility"`
+
+		// optional, makes the tender a reverse auction
+		Auction *model.Auction `json:"auction,omitempty"`
+		// optional, weighted evaluation criteria of the bids
+		Criteria []model.Criterion `json:"criteria,omitempty"`
 	}
 
 	// Parse the JSON request body
@@ -111,6 +160,12 @@ func (h *TenderHandler) InsertNewTender(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if len(tenderRequest.Visibility) != 0 && tenderRequest.Visibility != model.TenderPublic &&
+		tenderRequest.Visibility != model.TenderInviteOnly {
+		JSONResponse(w, map[string]string{"reason": service.ErrWrongVisibility.Error()}, 400)
+		return
+	}
+
 	// Convert OrganizationID to UUID
 	orgID, err := uuid.Parse(tenderRequest.OrganizationID)
 	if err != nil {
@@ -123,15 +178,29 @@ func (h *TenderHandler) InsertNewTender(w http.ResponseWriter, r *http.Request)
 		Name:           tenderRequest.Name,
 		Description:    tenderRequest.Description,
 		ServiceType:    tenderRequest.ServiceType,
+		Visibility:     tenderRequest.Visibility,
 		OrganizationID: orgID,
 	}
 
 	// Pass to the service
-	err = h.srv.InsertNewTender(&newTender, tenderRequest.CreatorUsername)
+	if tenderRequest.Auction != nil {
+		err = h.auctionService.InsertNewAuctionTender(r.Context(), &newTender, tenderRequest.Auction,
+			tenderRequest.Criteria, tenderRequest.CreatorUsername)
+	} else {
+		err = h.srv.InsertNewTender(r.Context(), &newTender, tenderRequest.Criteria, tenderRequest.CreatorUsername)
+	}
+	if err == service.ErrInvalidAuction || err == service.ErrInvalidCriteria || err == service.ErrNoServiceType {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
 	if err == service.ErrNotResponsible {
 		JSONResponse(w, map[string]string{"reason": "the employee is not respnosible for the organization"}, 403)
 		return
 	}
+	if err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
 	if err == service.ErrNoEmployee {
 		JSONResponse(w, map[string]string{"reason": "no employee with set username"}, 401)
 		return
@@ -149,8 +218,9 @@ func (h *TenderHandler) GetMyTenders(w http.ResponseWriter, r *http.Request) {
 		err     error
 
 		// query parameters
-		limit, offset int
-		username      []string
+		limit, offset  int
+		username       []string
+		includeHistory bool
 	)
 
 	queryValues := r.URL.Query()
@@ -159,13 +229,18 @@ func (h *TenderHandler) GetMyTenders(w http.ResponseWriter, r *http.Request) {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
 		return
 	}
+	includeHistory, err = parseQueryIncludeHistory(&queryValues)
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
 	username, ok := queryValues["username"]
 	if !ok {
 		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
 		return
 	}
 
-	tenders, err = h.srv.GetUserTenders(username[0], limit, offset)
+	tenders, err = h.srv.GetUserTenders(r.Context(), username[0], includeHistory, limit, offset)
 
 	if err == service.ErrNoEmployee {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
@@ -204,23 +279,65 @@ func (h *TenderHandler) UpdateTenderStatus(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = h.srv.UpdateTenderStatus(&tender, username)
+	err = h.srv.UpdateTenderStatus(r.Context(), &tender, username)
 
 	if err == service.ErrNoTender {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible || err == service.ErrTenderClosed {
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
 		return
 	}
+	if err == service.ErrIllegalTransition {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 409)
+		return
+	}
 	if err == service.ErrNoEmployee {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
 		return
 	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
 	JSONResponse(w, tender, 200)
 }
 
+func (h *TenderHandler) GetTenderTransitions(w http.ResponseWriter, r *http.Request) {
+	requestVars := mux.Vars(r)
+	tenderID, err := uuid.Parse(requestVars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	transitions, err := h.srv.GetTenderTransitions(r.Context(), tenderID, username)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *transitions, 200)
+}
+
 func (h *TenderHandler) GetTenderStatus(w http.ResponseWriter, r *http.Request) {
 	var (
 		err      error
@@ -239,13 +356,13 @@ func (h *TenderHandler) GetTenderStatus(w http.ResponseWriter, r *http.Request)
 		*username = r.Form.Get("username")
 	}
 
-	tenderStatus, err := h.srv.GetTenderStatus(tenderID, username)
+	tenderStatus, err := h.srv.GetTenderStatus(r.Context(), tenderID, username)
 
 	if err == service.ErrNoTender {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible {
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": "not authorized"}, 403)
 		return
 	}
@@ -283,12 +400,12 @@ func (h *TenderHandler) UpdateTender(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedTender, err := h.srv.PatchTender(tenderID, username, &tenderUpdate)
+	updatedTender, err := h.srv.PatchTender(r.Context(), tenderID, username, &tenderUpdate)
 	if err == service.ErrNoTender {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible || err == service.ErrTenderClosed {
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
 		return
 	}
@@ -332,12 +449,12 @@ func (h *TenderHandler) RollbackTender(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedTender, err := h.srv.RollbackTender(tenderID, username, version)
+	updatedTender, err := h.srv.RollbackTender(r.Context(), tenderID, username, version)
 	if err == service.ErrNoTender {
 		JSONResponse(w, map[string]string{"reason": "no tender with specified version"}, 404)
 		return
 	}
-	if err == service.ErrNotResponsible || err == service.ErrTenderClosed {
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
 		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
 		return
 	}
@@ -351,3 +468,158 @@ func (h *TenderHandler) RollbackTender(w http.ResponseWriter, r *http.Request) {
 	}
 	JSONResponse(w, *updatedTender, 200)
 }
+
+func (h *TenderHandler) UpdateTenderVisibility(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("visibility") || !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "visibility, username are required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+	visibility := r.Form.Get("visibility")
+
+	updatedTender, err := h.srv.UpdateTenderVisibility(r.Context(), tenderID, username, visibility)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *updatedTender, 200)
+}
+
+func (h *TenderHandler) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	var (
+		invitations []model.TenderInvitation
+
+		// query parameters
+		limit, offset int
+	)
+
+	queryValues := r.URL.Query()
+	limit, offset, err := parseQueryLimitOffset(&queryValues)
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	username, ok := queryValues["username"]
+	if !ok {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	vars := mux.Vars(r)
+	tenderID, err := uuid.Parse(vars["tenderId"])
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+
+	invitations, err = h.srv.GetInvitations(r.Context(), tenderID, username[0], limit, offset)
+	if err == service.ErrNoTender {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	if invitations == nil {
+		JSONResponse(w, []map[string]string{}, 200)
+		return
+	}
+	JSONResponse(w, invitations, 200)
+}
+
+func (h *TenderHandler) InviteOrganization(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tenderID, err1 := uuid.Parse(vars["tenderId"])
+	organizationID, err2 := uuid.Parse(vars["organizationId"])
+	if err1 != nil || err2 != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid id format"}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	invitation, err := h.srv.InviteOrganization(r.Context(), tenderID, organizationID, username)
+	if err == service.ErrNoTender || err == service.ErrNoOrganization {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, *invitation, 200)
+}
+
+func (h *TenderHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	tenderID, err1 := uuid.Parse(vars["tenderId"])
+	organizationID, err2 := uuid.Parse(vars["organizationId"])
+	if err1 != nil || err2 != nil {
+		JSONResponse(w, map[string]string{"reason": "invalid id format"}, 400)
+		return
+	}
+	r.ParseForm()
+	if !r.Form.Has("username") {
+		JSONResponse(w, map[string]string{"reason": "username is required"}, 400)
+		return
+	}
+	username := r.Form.Get("username")
+
+	err := h.srv.RevokeInvitation(r.Context(), tenderID, organizationID, username)
+	if err == service.ErrNoTender || err == service.ErrNoInvitation {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 404)
+		return
+	}
+	if err == service.ErrNotResponsible || err == service.ErrTenderClosed || err == service.ErrCertificateMismatch {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 403)
+		return
+	}
+	if err == service.ErrNoEmployee {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 401)
+		return
+	}
+	if err != nil {
+		JSONResponse(w, map[string]string{"reason": err.Error()}, 400)
+		return
+	}
+	JSONResponse(w, map[string]string{"tenderId": tenderID.String(), "organizationId": organizationID.String()}, 200)
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"avito-back-test/internal/service"
+	"net/http"
+)
+
+// CallerCache keeps the caller resolved by the authorization checks for the
+// rest of the request, so that a request reads the employee and the organizations
+// the employee is responsible for once, see service.Authorizer.
+func CallerCache(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(service.WithCallerCache(r.Context())))
+	})
+}
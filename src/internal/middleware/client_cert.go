@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"avito-back-test/internal/config"
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"avito-back-test/internal/service"
+	"bytes"
+	"crypto/x509"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+type ClientCert struct {
+	identities map[string]model.ClientIdentity
+	auth       *service.Authorizer
+}
+
+func NewClientCert(identities map[string]config.ClientIdentity) *ClientCert {
+	c := &ClientCert{
+		identities: make(map[string]model.ClientIdentity, len(identities)),
+		auth:       service.NewAuthorizer(),
+	}
+	for subject, identity := range identities {
+		clientIdentity := model.ClientIdentity{Username: identity.Username}
+		// validated with the configuration
+		if identity.OrganizationID != "" {
+			organizationID := uuid.MustParse(identity.OrganizationID)
+			clientIdentity.OrganizationID = &organizationID
+		}
+		c.identities[subject] = clientIdentity
+	}
+	return c
+}
+
+// Middleware binds the requests made with a client certificate to its identity,
+// see service.WithClientIdentity. An employee certificate acts as that employee:
+// a missing username parameter is filled in and a different one is rejected.
+// An organization certificate only acts through the employees responsible
+// for the organization. The username is checked both in the query
+// and in a form body, the services check every employee they act for
+// again through service.Authorizer, whatever the parameter naming it.
+func (c *ClientCert) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		identity, ok := c.lookup(r.TLS.PeerCertificates[0])
+		if !ok {
+			if len(c.identities) > 0 {
+				errorResponse(w, "unknown client certificate", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := service.WithClientIdentity(r.Context(), identity)
+
+		usernames, err := requestUsernames(r)
+		if err != nil {
+			errorResponse(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		if len(usernames) == 0 && identity.Username != "" {
+			query := r.URL.Query()
+			query.Set("username", identity.Username)
+			r.URL.RawQuery = query.Encode()
+		}
+		for _, username := range usernames {
+			_, err := c.auth.Caller(ctx, username)
+			if err == service.ErrCertificateMismatch {
+				errorResponse(w, "username doesn't match the client certificate", http.StatusForbidden)
+				return
+			}
+			// an unknown employee is left for the endpoint to report
+			if err != nil && err != repository.ErrNoEmployee {
+				log.Println("client certificate:", err)
+				errorResponse(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (c *ClientCert) lookup(cert *x509.Certificate) (model.ClientIdentity, bool) {
+	if identity, ok := c.identities[cert.Subject.String()]; ok {
+		return identity, true
+	}
+	identity, ok := c.identities[cert.Subject.CommonName]
+	return identity, ok
+}
+
+// requestUsernames returns every username parameter the handler could read
+// with r.Form, from the query and from a form body. The body is left
+// for the handler to read again.
+func requestUsernames(r *http.Request) ([]string, error) {
+	usernames := r.URL.Query()["username"]
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.Body == nil || contentType != "application/x-www-form-urlencoded" {
+		return usernames, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	return append(usernames, form["username"]...), nil
+}
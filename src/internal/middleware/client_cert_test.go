@@ -0,0 +1,112 @@
+package middleware_test
+
+import (
+	"avito-back-test/fixtures"
+	"avito-back-test/internal/config"
+	"avito-back-test/internal/db/dbtest"
+	"avito-back-test/internal/middleware"
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"avito-back-test/internal/tlstest"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestClientCertIdentity(t *testing.T) {
+	// the demo employees and organizations, ivanov is responsible
+	// for the first organization
+	dbtest.OpenSQLite(t)
+	var fixture model.Fixture
+	if err := yaml.Unmarshal(fixtures.Demo, &fixture); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.NewSeedService().Seed(&fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	ca := tlstest.NewCA(t, "test CA")
+	clientCert := middleware.NewClientCert(map[string]config.ClientIdentity{
+		"employee":     {Username: "sidorov"},
+		"organization": {OrganizationID: "8a2b6b3e-1c4f-4f0e-9a57-0d2f1c9e0a01"},
+	})
+	// the handler answers with the username it acts as
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := service.ClientIdentityFromContext(r.Context()); !ok {
+			http.Error(w, "no client identity", http.StatusInternalServerError)
+			return
+		}
+		r.ParseForm()
+		io.WriteString(w, r.Form.Get("username"))
+	})
+	ts := httptest.NewUnstartedServer(clientCert.Middleware(next))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.Issue(t, "localhost").TLS},
+		ClientCAs:    ca.Pool(),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	clients := map[string]*http.Client{}
+	for _, name := range []string{"employee", "organization", "stranger"} {
+		clients[name] = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      ca.Pool(),
+			ServerName:   "localhost",
+			Certificates: []tls.Certificate{ca.Issue(t, name).TLS},
+		}}}
+	}
+
+	tests := []struct {
+		name       string
+		cert       string
+		method     string
+		query      string
+		form       string
+		wantStatus int
+		wantUser   string
+	}{
+		{name: "employee fills in the username", cert: "employee", wantStatus: 200, wantUser: "sidorov"},
+		{name: "employee as itself", cert: "employee", query: "username=sidorov", wantStatus: 200, wantUser: "sidorov"},
+		{name: "employee as another employee", cert: "employee", query: "username=ivanov", wantStatus: 403},
+		{name: "employee as another employee in the form", cert: "employee", method: http.MethodPut, form: "username=ivanov", wantStatus: 403},
+		{name: "employee with a second username", cert: "employee", query: "username=sidorov&username=ivanov", wantStatus: 403},
+		{name: "organization as its responsible", cert: "organization", query: "username=ivanov", wantStatus: 200, wantUser: "ivanov"},
+		{name: "organization as another employee", cert: "organization", query: "username=sidorov", wantStatus: 403},
+		{name: "organization as another employee in the form", cert: "organization", method: http.MethodPut, form: "username=sidorov", wantStatus: 403},
+		{name: "organization anonymously", cert: "organization", wantStatus: 200},
+		{name: "unknown certificate", cert: "stranger", query: "username=ivanov", wantStatus: 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, ts.URL+"/?"+tt.query, strings.NewReader(tt.form))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.form != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			resp, err := clients[tt.cert].Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("got %d %s, want %d", resp.StatusCode, body, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && string(body) != tt.wantUser {
+				t.Fatalf("acts as %q, want %q", body, tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"avito-back-test/internal/config"
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	// a request holding its key for longer has died with its instance,
+	// a retry takes the key over
+	idempotencyLockTimeout = time.Minute
+	// the wrapped creates, status changes and decisions are small,
+	// the body is buffered whole to fingerprint it
+	maxIdempotentBody = 1 << 20
+)
+
+// IdempotencyCaller names the caller the keys of the request belong to,
+// the body is read already.
+type IdempotencyCaller func(r *http.Request, body []byte) string
+
+// QueryCaller takes the caller from the query parameter.
+func QueryCaller(param string) IdempotencyCaller {
+	return func(r *http.Request, body []byte) string {
+		return r.URL.Query().Get(param)
+	}
+}
+
+// BodyCaller takes the caller from the fields of the JSON body,
+// the creates carry their author in the body.
+func BodyCaller(fields ...string) IdempotencyCaller {
+	return func(r *http.Request, body []byte) string {
+		var values map[string]any
+		if err := json.Unmarshal(body, &values); err != nil {
+			return ""
+		}
+		caller := make([]string, len(fields))
+		for i, field := range fields {
+			caller[i], _ = values[field].(string)
+		}
+		return strings.Join(caller, ":")
+	}
+}
+
+type Idempotency struct {
+	cfg             config.IdempotencyConfig
+	idempotencyRepo *repository.IdempotencyRepository
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewIdempotency(cfg config.IdempotencyConfig) *Idempotency {
+	return &Idempotency{
+		cfg:             cfg,
+		idempotencyRepo: repository.NewIdempotencyRepository(),
+		lastSweep:       time.Now(),
+	}
+}
+
+// Wrap makes the handler replay its first response to the retries carrying
+// the same Idempotency-Key header, for the TTL. The keys are per caller, a key
+// reused for a different request gets 422 and a retry racing the first
+// request gets 409. The failed requests, with a 5xx response, don't keep
+// their key, so that they can be retried.
+func (i *Idempotency) Wrap(callerOf IdempotencyCaller, next http.HandlerFunc) http.Handler {
+	if !i.cfg.Enabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			errorResponse(w, fmt.Sprintf("Idempotency-Key can't be longer than %d characters", maxIdempotencyKeyLength),
+				http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			errorResponse(w, "Request payload is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			errorResponse(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		caller := callerOf(r, body)
+		fingerprint := requestFingerprint(r, body)
+
+		claimed, record, err := i.claim(caller, key, fingerprint)
+		if err != nil {
+			// an unavailable store must not take the API down
+			log.Println("idempotency:", err)
+			next(w, r)
+			return
+		}
+		if !claimed {
+			switch {
+			case record.Fingerprint != fingerprint:
+				errorResponse(w, "the Idempotency-Key was used for a different request", http.StatusUnprocessableEntity)
+			case record.Response == nil:
+				errorResponse(w, "a request with the Idempotency-Key is in progress", http.StatusConflict)
+			default:
+				replay(w, record.Response)
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			// a panic or a failure frees the key
+			if !completed {
+				if err := i.idempotencyRepo.ReleaseKey(caller, key); err != nil {
+					log.Println("idempotency:", err)
+				}
+			}
+		}()
+		next(recorder, r)
+		if recorder.status >= http.StatusInternalServerError {
+			return
+		}
+		response := model.IdempotentResponse{
+			StatusCode:  recorder.status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := i.idempotencyRepo.CompleteKey(caller, key, &response); err != nil {
+			log.Println("idempotency:", err)
+			return
+		}
+		completed = true
+		i.sweep()
+	})
+}
+
+// claim takes the key, or returns the record of the request holding it.
+func (i *Idempotency) claim(caller, key, fingerprint string) (bool, *model.IdempotencyRecord, error) {
+	for range 2 {
+		claimed, err := i.idempotencyRepo.ClaimKey(caller, key, fingerprint, i.cfg.TTL, idempotencyLockTimeout)
+		if err != nil || claimed {
+			return claimed, nil, err
+		}
+		record, err := i.idempotencyRepo.GetKey(caller, key)
+		// the request holding the key has just failed, claim it again
+		if err == repository.ErrNoIdempotencyKey {
+			continue
+		}
+		return false, record, err
+	}
+	// the key keeps changing hands, the caller has to retry later
+	return false, &model.IdempotencyRecord{Fingerprint: fingerprint}, nil
+}
+
+func (i *Idempotency) sweep() {
+	i.mu.Lock()
+	if time.Since(i.lastSweep) < time.Minute {
+		i.mu.Unlock()
+		return
+	}
+	i.lastSweep = time.Now()
+	i.mu.Unlock()
+
+	go func() {
+		if err := i.idempotencyRepo.DeleteExpiredKeys(i.cfg.TTL); err != nil {
+			log.Println("idempotency:", err)
+		}
+	}()
+}
+
+// requestFingerprint tells the retries from a different request with the same key.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	// the encoded query has its parameters sorted
+	fmt.Fprintf(hash, "%s %s?%s\n", r.Method, r.URL.Path, r.URL.Query().Encode())
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, response *model.IdempotentResponse) {
+	if response.ContentType != "" {
+		w.Header().Set("Content-Type", response.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(response.StatusCode)
+	w.Write(response.Body)
+}
+
+// responseRecorder keeps a copy of the response it writes through.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
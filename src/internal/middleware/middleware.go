@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// maxPeekedBody is the largest JSON body the middlewares look into,
+// the API requests naming their caller in the body are far smaller.
+const maxPeekedBody = 1 << 20
+
+// Chain wraps the handler so that the first middleware runs first.
+func Chain(h http.Handler, middlewares ...func(http.Handler) http.Handler) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// peekJSONBody returns the body of a request with a JSON body, nil for
+// the other requests or if the body is larger than maxPeekedBody.
+// The body is left for the handler to read again.
+func peekJSONBody(r *http.Request) []byte {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.Body == nil || r.Body == http.NoBody || contentType != "application/json" {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekedBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil || len(body) > maxPeekedBody {
+		return nil
+	}
+	return body
+}
+
+// errorResponse writes an error in the format of the API handlers.
+func errorResponse(w http.ResponseWriter, reason string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"reason": reason})
+}
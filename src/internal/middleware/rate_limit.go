@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"avito-back-test/internal/config"
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"encoding/json"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RateLimitStore keeps the token buckets of the clients.
+type RateLimitStore interface {
+	// Take removes a token from each of the buckets of the keys if all of them
+	// have one left, otherwise it takes none.
+	Take(keys []string, budget config.RateBudget) (RateLimitResult, error)
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token, zero if allowed
+	RetryAfter time.Duration
+}
+
+// refill adds the tokens of the time elapsed since the last update to the bucket.
+func refill(tokens float64, elapsed time.Duration, budget config.RateBudget) float64 {
+	capacity := float64(budget.Requests)
+	return math.Min(capacity, tokens+elapsed.Seconds()*capacity/budget.Period.Seconds())
+}
+
+// takeTokens takes a token from each of the refilled buckets if all of them
+// have one and reports the most restrictive of the buckets.
+func takeTokens(buckets []float64, budget config.RateBudget) RateLimitResult {
+	capacity := float64(budget.Requests)
+	rate := capacity / budget.Period.Seconds()
+
+	result := RateLimitResult{Allowed: true, Remaining: budget.Requests}
+	for _, tokens := range buckets {
+		if tokens < 1 {
+			result.Allowed = false
+			result.RetryAfter = max(result.RetryAfter, secondsDuration((1-tokens)/rate))
+		}
+	}
+	for i := range buckets {
+		if result.Allowed {
+			buckets[i]--
+		}
+		result.Remaining = min(result.Remaining, int(buckets[i]))
+		result.Reset = max(result.Reset, secondsDuration((capacity-buckets[i])/rate))
+	}
+	return result
+}
+
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+type RateLimiter struct {
+	cfg            config.RateLimitConfig
+	store          RateLimitStore
+	trustedProxies []netip.Prefix
+	auth           *service.Authorizer
+}
+
+func NewRateLimiter(cfg config.RateLimitConfig, store RateLimitStore) *RateLimiter {
+	l := &RateLimiter{
+		cfg:   cfg,
+		store: store,
+		auth:  service.NewAuthorizer(),
+	}
+	for _, proxy := range cfg.TrustedProxies {
+		// validated with the configuration
+		prefix, _ := config.ParseProxy(proxy)
+		l.trustedProxies = append(l.trustedProxies, prefix)
+	}
+	return l
+}
+
+// Middleware limits the requests of every client IP and, if the request
+// acts for an employee or an organization, of every one of them, see actorKey.
+// Each route has its own budget, a request takes a token from the budgets
+// of both only if neither is spent.
+// It has to run after the routing, so that the route template is known.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, budget := l.routeBudget(r)
+
+		keys := []string{"ip:" + l.clientIP(r) + ":" + route}
+		if actor := l.actorKey(r); actor != "" {
+			keys = append(keys, actor+":"+route)
+		}
+		result, err := l.store.Take(keys, budget)
+		if err != nil {
+			// an unavailable store must not take the API down
+			log.Println("rate limit:", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Policy", strconv.Itoa(budget.Requests)+";w="+strconv.Itoa(int(budget.Period.Seconds())))
+		h.Set("RateLimit-Limit", strconv.Itoa(budget.Requests))
+		h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			errorResponse(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func (l *RateLimiter) routeBudget(r *http.Request) (string, config.RateBudget) {
+	route := r.URL.Path
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			route = template
+		}
+	}
+	route = r.Method + " " + route
+	if budget, ok := l.cfg.Routes[route]; ok {
+		return route, budget
+	}
+	return route, l.cfg.Default
+}
+
+// actorKey names the employee or the organization the request acts for,
+// read the way the endpoints read it: the username parameters, the creator
+// of a new tender or the author of a new bid. The employees are resolved
+// like the endpoints resolve them, so that one budget follows an employee
+// whatever parameter names them, the caller stays cached for the endpoint.
+func (l *RateLimiter) actorKey(r *http.Request) string {
+	usernames, _ := requestUsernames(r)
+	usernames = append(usernames, r.URL.Query()["requesterUsername"]...)
+	var author struct {
+		CreatorUsername string `json:"creatorUsername"`
+		AuthorType      string `json:"authorType"`
+		AuthorID        string `json:"authorId"`
+	}
+	if body := peekJSONBody(r); body != nil {
+		json.Unmarshal(body, &author)
+	}
+	if author.CreatorUsername != "" {
+		usernames = append(usernames, author.CreatorUsername)
+	}
+
+	if len(usernames) > 0 {
+		caller, err := l.auth.Caller(r.Context(), usernames[0])
+		if err != nil {
+			// the endpoint reports the unknown employee
+			return "username:" + usernames[0]
+		}
+		return "employee:" + caller.EmployeeID.String()
+	}
+	switch author.AuthorType {
+	case model.AuthorTypeUser:
+		return "employee:" + author.AuthorID
+	case model.AuthorTypeOrganization:
+		return "organization:" + author.AuthorID
+	}
+	return ""
+}
+
+// clientIP is the address of the peer or, behind a proxy, the rightmost
+// address of X-Forwarded-For that no trusted proxy added. The addresses
+// to the left of it come from the client and can be anything.
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+	if !l.cfg.TrustProxy {
+		return client
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	// without a list the peer is the one proxy
+	trusted := len(l.trustedProxies) == 0
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !trusted && !l.isTrustedProxy(client) {
+			break
+		}
+		trusted = false
+		if hop := strings.TrimSpace(hops[i]); hop != "" {
+			client = hop
+		}
+	}
+	return client
+}
+
+func (l *RateLimiter) isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range l.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"avito-back-test/internal/config"
+	"avito-back-test/internal/repository"
+	"database/sql"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryRateLimitStore keeps the buckets in the process, budgets are per replica.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*memoryBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(keys []string, budget config.RateBudget) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	buckets := make([]*memoryBucket, len(keys))
+	tokens := make([]float64, len(keys))
+	for i, key := range keys {
+		b, ok := s.buckets[key]
+		if !ok {
+			b = &memoryBucket{tokens: float64(budget.Requests), updated: now}
+			s.buckets[key] = b
+		}
+		buckets[i] = b
+		tokens[i] = refill(b.tokens, now.Sub(b.updated), budget)
+	}
+	result := takeTokens(tokens, budget)
+	for i, b := range buckets {
+		b.tokens = tokens[i]
+		b.updated = now
+		b.period = budget.Period
+	}
+	return result, nil
+}
+
+// sweep drops the buckets that have refilled completely once a minute.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) > b.period {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// PostgresRateLimitStore keeps the buckets in the database,
+// so that all replicas share the budgets.
+type PostgresRateLimitStore struct {
+	rateLimitRepo *repository.RateLimitRepository
+	// buckets idle for longer than maxPeriod are full and can be dropped
+	maxPeriod time.Duration
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresRateLimitStore(cfg config.RateLimitConfig) *PostgresRateLimitStore {
+	maxPeriod := cfg.Default.Period
+	for _, budget := range cfg.Routes {
+		maxPeriod = max(maxPeriod, budget.Period)
+	}
+	return &PostgresRateLimitStore{
+		rateLimitRepo: repository.NewRateLimitRepository(),
+		maxPeriod:     maxPeriod,
+		lastSweep:     time.Now(),
+	}
+}
+
+func (s *PostgresRateLimitStore) Take(keys []string, budget config.RateBudget) (RateLimitResult, error) {
+	var result RateLimitResult
+	// the buckets are locked in one order, so that the requests sharing
+	// a bucket don't deadlock
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+
+	err := s.rateLimitRepo.WithTransaction(func(tx *sql.Tx) error {
+		tokens := make([]float64, len(keys))
+		for i, key := range keys {
+			bucket, elapsed, err := s.rateLimitRepo.TxGetBucket(tx, key, float64(budget.Requests))
+			if err != nil {
+				return err
+			}
+			tokens[i] = refill(bucket, elapsed, budget)
+		}
+		result = takeTokens(tokens, budget)
+		for i, key := range keys {
+			if err := s.rateLimitRepo.TxSetBucket(tx, key, tokens[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	s.sweep()
+	return result, nil
+}
+
+func (s *PostgresRateLimitStore) sweep() {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < time.Minute {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	go func() {
+		if err := s.rateLimitRepo.DeleteIdleBuckets(s.maxPeriod); err != nil {
+			log.Println("rate limit:", err)
+		}
+	}()
+}
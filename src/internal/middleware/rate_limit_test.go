@@ -0,0 +1,140 @@
+package middleware_test
+
+import (
+	"avito-back-test/fixtures"
+	"avito-back-test/internal/config"
+	"avito-back-test/internal/db/dbtest"
+	"avito-back-test/internal/middleware"
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/service"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRateLimitClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustProxy     bool
+		trustedProxies []string
+		// the peer and the X-Forwarded-For of the two requests
+		remoteAddr string
+		forwarded  [2]string
+		wantSame   bool
+	}{
+		{
+			name:       "the client rotates the addresses it writes itself",
+			trustProxy: true,
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  [2]string{"9.9.9.9, 1.1.1.1", "8.8.8.8, 1.1.1.1"},
+			wantSame:   true,
+		},
+		{
+			name:       "different clients behind the proxy",
+			trustProxy: true,
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  [2]string{"1.1.1.1", "2.2.2.2"},
+		},
+		{
+			name:           "a chain of trusted proxies",
+			trustProxy:     true,
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:1234",
+			forwarded:      [2]string{"9.9.9.9, 1.1.1.1, 10.0.0.2", "8.8.8.8, 1.1.1.1, 10.0.0.2"},
+			wantSame:       true,
+		},
+		{
+			name:           "an untrusted peer",
+			trustProxy:     true,
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "1.1.1.1:1234",
+			forwarded:      [2]string{"9.9.9.9", "8.8.8.8"},
+			wantSame:       true,
+		},
+		{
+			name:       "no proxy",
+			remoteAddr: "1.1.1.1:1234",
+			forwarded:  [2]string{"9.9.9.9", "8.8.8.8"},
+			wantSame:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := middleware.NewRateLimiter(config.RateLimitConfig{
+				TrustProxy:     tt.trustProxy,
+				TrustedProxies: tt.trustedProxies,
+				Default:        config.RateBudget{Requests: 1, Period: time.Minute},
+			}, middleware.NewMemoryRateLimitStore())
+			handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			var codes [2]int
+			for i, forwarded := range tt.forwarded {
+				req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+				req.RemoteAddr = tt.remoteAddr
+				req.Header.Set("X-Forwarded-For", forwarded)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				codes[i] = rec.Code
+			}
+			// the second request of the same client is over the budget
+			want := [2]int{http.StatusOK, http.StatusOK}
+			if tt.wantSame {
+				want[1] = http.StatusTooManyRequests
+			}
+			if codes != want {
+				t.Fatalf("got %v, want %v", codes, want)
+			}
+		})
+	}
+}
+
+func TestRateLimitActor(t *testing.T) {
+	dbtest.OpenSQLite(t)
+	var fixture model.Fixture
+	if err := yaml.Unmarshal(fixtures.Demo, &fixture); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.NewSeedService().Seed(&fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	limiter := middleware.NewRateLimiter(config.RateLimitConfig{
+		Default: config.RateBudget{Requests: 1, Period: time.Minute},
+	}, middleware.NewMemoryRateLimitStore())
+	// the handler answers with the body it reads
+	handler := middleware.CallerCache(limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		query      string
+		body       string
+		wantStatus int
+	}{
+		{name: "the creator in the body", remoteAddr: "1.1.1.1:1234", body: `{"creatorUsername":"ivanov"}`, wantStatus: 200},
+		{name: "the same employee in the query", remoteAddr: "2.2.2.2:1234", query: "username=ivanov", wantStatus: 429},
+		{name: "the denied request spends no IP token", remoteAddr: "2.2.2.2:1234", wantStatus: 200},
+		{name: "the author of a bid", remoteAddr: "3.3.3.3:1234", body: `{"authorType":"Organization","authorId":"8a2b6b3e-1c4f-4f0e-9a57-0d2f1c9e0a02"}`, wantStatus: 200},
+		{name: "the same organization again", remoteAddr: "4.4.4.4:1234", body: `{"authorType":"Organization","authorId":"8a2b6b3e-1c4f-4f0e-9a57-0d2f1c9e0a02"}`, wantStatus: 429},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/tenders/new?"+tt.query, strings.NewReader(tt.body))
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus {
+			t.Fatalf("%s: got %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+		if rec.Code == http.StatusOK && rec.Body.String() != tt.body {
+			t.Fatalf("%s: the handler read %q, want %q", tt.name, rec.Body.String(), tt.body)
+		}
+	}
+}
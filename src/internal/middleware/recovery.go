@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// RecoveryMiddleware turns a panic in a handler into a 500 response
+// instead of a dropped connection. A handler that has started its response
+// already can't get another one, its connection is aborted instead.
+func RecoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tracker := &headerTracker{ResponseWriter: w}
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			// the server aborts the response on purpose
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			log.Printf("panic serving %s %s: %v\n%s", r.Method, r.RequestURI, err, debug.Stack())
+			if tracker.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			errorResponse(w, "internal server error", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(tracker, r)
+	})
+}
+
+// headerTracker notes whether the response has started.
+type headerTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (t *headerTracker) WriteHeader(status int) {
+	t.wroteHeader = true
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *headerTracker) Write(b []byte) (int, error) {
+	t.wroteHeader = true
+	return t.ResponseWriter.Write(b)
+}
+
+func (t *headerTracker) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
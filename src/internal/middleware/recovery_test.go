@@ -0,0 +1,54 @@
+package middleware_test
+
+import (
+	"avito-back-test/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		wantStatus  int
+		wantAborted bool
+	}{
+		{
+			name:       "a panic before the response",
+			handler:    func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "a panic in the middle of the response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"id":`))
+				panic("boom")
+			},
+			wantStatus:  http.StatusOK,
+			wantAborted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			aborted := func() (aborted bool) {
+				defer func() {
+					aborted = recover() == http.ErrAbortHandler
+				}()
+				middleware.RecoveryMiddleware(tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+				return false
+			}()
+			if aborted != tt.wantAborted {
+				t.Fatalf("aborted %v, want %v", aborted, tt.wantAborted)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantAborted && rec.Body.String() != `{"id":` {
+				t.Fatalf("the response was written over: %q", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/service"
+	"net/http"
+)
+
+// ReadYourWrites sends the listings of a user changing data to the primary
+// database for a while, see db.Query. The users are the ones the services
+// resolved the request to act as, see service.TrackWriters, so the creates
+// carrying their author in the body count too. The username parameter is
+// marked before the handler as well, for a listing racing the response,
+// and the writes are marked after it, so that the window counts from the commit.
+func ReadYourWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		username := r.URL.Query().Get("username")
+		db.MarkWritten(username)
+		r = r.WithContext(service.TrackWriters(r.Context()))
+		next.ServeHTTP(w, r)
+		db.MarkWritten(username)
+		for _, writer := range service.WrittenBy(r.Context()) {
+			db.MarkWritten(writer)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"avito-back-test/internal/config"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SecurityHeadersMiddleware sets the headers recommended for JSON APIs.
+func SecurityHeadersMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+		h.Set("Cache-Control", "no-store")
+		if r.TLS != nil {
+			h.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type CORS struct {
+	cfg config.CORSConfig
+}
+
+func NewCORS(cfg config.CORSConfig) *CORS {
+	return &CORS{
+		cfg: cfg,
+	}
+}
+
+// Middleware answers the preflight requests of the allowed origins and
+// adds the CORS headers to their actual requests. It has to wrap the router,
+// as the routes don't accept OPTIONS.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || len(c.cfg.AllowedOrigins) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if !c.originAllowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if c.cfg.AllowCredentials || !slices.Contains(c.cfg.AllowedOrigins, "*") {
+			h.Set("Access-Control-Allow-Origin", origin)
+		} else {
+			h.Set("Access-Control-Allow-Origin", "*")
+		}
+		if c.cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		if r.Method != http.MethodOptions || requestMethod == "" {
+			if len(c.cfg.ExposedHeaders) > 0 {
+				h.Set("Access-Control-Expose-Headers", strings.Join(c.cfg.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// preflight
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		if !slices.Contains(c.cfg.AllowedMethods, requestMethod) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+			header = strings.TrimSpace(header)
+			if header != "" && !containsFold(c.cfg.AllowedHeaders, header) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		h.Set("Access-Control-Allow-Methods", strings.Join(c.cfg.AllowedMethods, ", "))
+		if len(c.cfg.AllowedHeaders) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(c.cfg.AllowedHeaders, ", "))
+		}
+		if c.cfg.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.cfg.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (c *CORS) originAllowed(origin string) bool {
+	for _, allowed := range c.cfg.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
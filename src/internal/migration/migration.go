@@ -0,0 +1,229 @@
+// Package migration applies the embedded SQL migrations.
+package migration
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/migrate/migrations"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// lockQuery serializes the migrate commands of the replicas starting at once.
+// golang-migrate takes its own lock per operation, so the key must differ from it.
+const (
+	lockQuery   = `SELECT pg_advisory_lock(hashtext('avito-back-test:migrate'))`
+	unlockQuery = `SELECT pg_advisory_unlock(hashtext('avito-back-test:migrate'))`
+)
+
+var fileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.up\.sql$`)
+
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+type Status struct {
+	Version    uint
+	Dirty      bool
+	Migrations []Migration
+}
+
+// source is the migrations directory of db.Driver.
+func source() fs.FS {
+	if db.Driver == db.SQLite {
+		return migrations.SQLite
+	}
+	return migrations.FS
+}
+
+// List returns the embedded migrations ordered by version.
+func List() ([]Migration, error) {
+	entries, err := fs.ReadDir(source(), ".")
+	if err != nil {
+		return nil, err
+	}
+	var list []Migration
+	for _, entry := range entries {
+		match := fileRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, Migration{Version: uint(version), Name: match[2]})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// LatestVersion is the schema version the code expects.
+func LatestVersion() uint {
+	list, err := List()
+	if err != nil || len(list) == 0 {
+		return 0
+	}
+	return list[len(list)-1].Version
+}
+
+type Migrator struct {
+	m    *migrate.Migrate
+	lock *sql.Conn
+}
+
+// New waits for the migration lock and prepares the embedded migrations.
+// The lock is held until Close. SQLite needs no lock, its writers are
+// serialized by the database file.
+func New(ctx context.Context, database *sql.DB) (*Migrator, error) {
+	var lock *sql.Conn
+	if db.Driver == db.Postgres {
+		var err error
+		if lock, err = database.Conn(ctx); err != nil {
+			return nil, err
+		}
+		log.Println("waiting for the migration lock")
+		if _, err := lock.ExecContext(ctx, lockQuery); err != nil {
+			lock.Close()
+			return nil, err
+		}
+	}
+
+	src, err := iofs.New(source(), ".")
+	if err != nil {
+		unlock(lock)
+		return nil, err
+	}
+	var driver migratedb.Driver
+	if db.Driver == db.SQLite {
+		driver, err = sqlite.WithInstance(database, &sqlite.Config{})
+	} else {
+		driver, err = postgres.WithInstance(database, &postgres.Config{})
+	}
+	if err != nil {
+		unlock(lock)
+		return nil, err
+	}
+	m, err := migrate.NewWithInstance("iofs", src, db.Driver, driver)
+	if err != nil {
+		driver.Close()
+		unlock(lock)
+		return nil, err
+	}
+	return &Migrator{
+		m:    m,
+		lock: lock,
+	}, nil
+}
+
+func unlock(lock *sql.Conn) {
+	if lock == nil {
+		return
+	}
+	if _, err := lock.ExecContext(context.Background(), unlockQuery); err != nil {
+		log.Println(err)
+	}
+	lock.Close()
+}
+
+// Close releases the lock and closes the database, the driver owns it.
+func (mg *Migrator) Close() {
+	unlock(mg.lock)
+	mg.m.Close()
+}
+
+// Up applies n migrations, or all of them if n is 0.
+func (mg *Migrator) Up(n int) error {
+	if n == 0 {
+		return ignoreNoChange(mg.m.Up())
+	}
+	return ignoreNoChange(mg.m.Steps(n))
+}
+
+// Down reverts n migrations.
+func (mg *Migrator) Down(n int) error {
+	if n <= 0 {
+		return errors.New("the number of migrations to revert has to be positive")
+	}
+	return ignoreNoChange(mg.m.Steps(-n))
+}
+
+// Goto migrates up or down to the version.
+func (mg *Migrator) Goto(version uint) error {
+	return ignoreNoChange(mg.m.Migrate(version))
+}
+
+// Force sets the version without running migrations, it is used to
+// recover from a dirty state after fixing the schema by hand.
+// The version -1 means no migrations are applied.
+func (mg *Migrator) Force(version int) error {
+	return mg.m.Force(version)
+}
+
+func (mg *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := mg.m.Version()
+	if err == migrate.ErrNilVersion {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func (mg *Migrator) Status() (*Status, error) {
+	version, dirty, err := mg.Version()
+	if err != nil {
+		return nil, err
+	}
+	list, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Applied = list[i].Version <= version
+	}
+	return &Status{
+		Version:    version,
+		Dirty:      dirty,
+		Migrations: list,
+	}, nil
+}
+
+func ignoreNoChange(err error) error {
+	if err == migrate.ErrNoChange {
+		log.Println("no change")
+		return nil
+	}
+	return err
+}
+
+func (s *Status) String() string {
+	out := fmt.Sprintf("version %d", s.Version)
+	if s.Dirty {
+		out += " (dirty)"
+	}
+	out += "\n"
+	for _, m := range s.Migrations {
+		state := "pending"
+		if m.Applied {
+			state = "applied"
+		}
+		if s.Dirty && m.Version == s.Version {
+			state = "dirty"
+		}
+		out += fmt.Sprintf("%06d %-30s %s\n", m.Version, m.Name, state)
+	}
+	return out
+}
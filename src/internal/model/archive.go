@@ -0,0 +1,99 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ArchiveFormatVersion is bumped when the archive layout changes,
+// the import refuses the versions it doesn't know.
+const ArchiveFormatVersion = 1
+
+// OrganizationArchive is the data of an organization moved between
+// instances. Employees are matched by username, since their ids differ
+// between instances. Lots, auctions, criteria, questions and
+// qualifications are not part of the archive.
+type OrganizationArchive struct {
+	FormatVersion int                 `json:"formatVersion"`
+	ExportedAt    time.Time           `json:"exportedAt"`
+	Organization  ArchiveOrganization `json:"organization"`
+	Responsibles  []ArchiveEmployee   `json:"responsibles"`
+	// Employees are the other employees the archive refers to, bid authors
+	// and the decision makers
+	Employees []ArchiveEmployee `json:"employees"`
+	Tenders   []ArchiveTender   `json:"tenders"`
+}
+
+type ArchiveOrganization struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Type        string    `json:"type"`
+}
+
+type ArchiveEmployee struct {
+	Username  string `json:"username"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+}
+
+type ArchiveTender struct {
+	ID               uuid.UUID              `json:"id"`
+	Status           string                 `json:"status"`
+	Visibility       string                 `json:"visibility"`
+	QuestionDeadline *time.Time             `json:"questionDeadline,omitempty"`
+	CreatedAt        time.Time              `json:"createdAt"`
+	Versions         []ArchiveTenderVersion `json:"versions"`
+	Bids             []ArchiveBid           `json:"bids"`
+}
+
+type ArchiveTenderVersion struct {
+	Version     int    `json:"version"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ServiceType string `json:"serviceType"`
+}
+
+type ArchiveBid struct {
+	ID         uuid.UUID `json:"id"`
+	Status     string    `json:"status"`
+	AuthorType string    `json:"authorType"`
+	// AuthorUsername is set for User bids, AuthorOrganizationID for Organization bids
+	AuthorUsername       string               `json:"authorUsername,omitempty"`
+	AuthorOrganizationID *uuid.UUID           `json:"authorOrganizationId,omitempty"`
+	CreatedAt            time.Time            `json:"createdAt"`
+	Versions             []ArchiveBidVersion  `json:"versions"`
+	Reviews              []ArchiveBidReview   `json:"reviews"`
+	Decisions            []ArchiveBidDecision `json:"decisions"`
+}
+
+type ArchiveBidVersion struct {
+	Version     int    `json:"version"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ArchiveBidReview struct {
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type ArchiveBidDecision struct {
+	ResponsibleUsername string `json:"responsibleUsername"`
+	Decision            string `json:"decision"`
+}
+
+// ImportConflict is a reason the archive can't be imported as it is.
+type ImportConflict struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// ImportResult maps the archive ids to the ids created by the import.
+type ImportResult struct {
+	OrganizationID uuid.UUID               `json:"organizationId"`
+	Tenders        map[uuid.UUID]uuid.UUID `json:"tenders"`
+	Bids           map[uuid.UUID]uuid.UUID `json:"bids"`
+	Employees      int                     `json:"createdEmployees"`
+}
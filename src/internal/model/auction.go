@@ -0,0 +1,27 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Auction struct {
+	TenderID         uuid.UUID  `json:"tenderId"`
+	StartPrice       float64    `json:"startPrice"`
+	MinStep          float64    `json:"minStep"`
+	DurationSeconds  int        `json:"durationSeconds"`
+	ExtensionSeconds int        `json:"extensionSeconds"`
+	EndsAt           *time.Time `json:"endsAt"`
+	BestPrice        *float64   `json:"bestPrice"`
+	BestBidID        *uuid.UUID `json:"bestBidId"`
+	WinnerBidID      *uuid.UUID `json:"winnerBidId"`
+	Finished         bool       `json:"finished"`
+}
+
+type AuctionOffer struct {
+	ID        uuid.UUID `json:"id"`
+	TenderID  uuid.UUID `json:"tenderId"`
+	BidID     uuid.UUID `json:"bidId"`
+	Price     float64   `json:"price"`
+	CreatedAt time.Time `json:"createdAt"`
+}
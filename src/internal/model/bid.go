@@ -6,15 +6,16 @@ import (
 )
 
 type Bid struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	TenderID    uuid.UUID `json:"tenderId"`
-	AuthorType  string    `json:"authorType"`
-	AuthorID    uuid.UUID `json:"authorId"`
-	Version     int       `json:"version"`
-	CreatedAt   time.Time `json:"createdAt"`
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	TenderID    uuid.UUID  `json:"tenderId"`
+	LotID       *uuid.UUID `json:"lotId,omitempty"`
+	AuthorType  string     `json:"authorType"`
+	AuthorID    uuid.UUID  `json:"authorId"`
+	Version     int        `json:"version"`
+	CreatedAt   time.Time  `json:"createdAt"`
 }
 
 type BidUpdate struct {
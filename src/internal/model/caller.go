@@ -0,0 +1,38 @@
+package model
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Caller is the employee a request acts for, with the organizations
+// the employee is responsible for.
+type Caller struct {
+	EmployeeID    uuid.UUID
+	Username      string
+	Organizations []uuid.UUID
+}
+
+func (c *Caller) IsResponsible(organizationID uuid.UUID) bool {
+	return slices.Contains(c.Organizations, organizationID)
+}
+
+// IsBidAuthor tells if the caller placed a User bid or is responsible
+// for the organization of an Organization bid.
+func (c *Caller) IsBidAuthor(bid *Bid) bool {
+	switch bid.AuthorType {
+	case AuthorTypeUser:
+		return bid.AuthorID == c.EmployeeID
+	case AuthorTypeOrganization:
+		return c.IsResponsible(bid.AuthorID)
+	}
+	return false
+}
+
+// ClientIdentity is who the client certificate of a request was issued to,
+// an employee or an organization.
+type ClientIdentity struct {
+	Username       string
+	OrganizationID *uuid.UUID
+}
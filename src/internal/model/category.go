@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// ServiceCategory is a node of the service type catalog. Tenders and lots
+// reference categories by code, e.g. a CPV-style "45000000" or "Construction".
+type ServiceCategory struct {
+	Code       string            `json:"code"`
+	ParentCode *string           `json:"parentCode"`
+	Name       string            `json:"name"`
+	Names      map[string]string `json:"names"`
+	CreatedAt  time.Time         `json:"createdAt"`
+}
+
+type ServiceCategoryUpdate struct {
+	ParentCode *string           `json:"parentCode,omitempty"`
+	Names      map[string]string `json:"names,omitempty"`
+}
+
+// DefaultLocale is used for display names missing in the requested locale.
+const DefaultLocale = "en"
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Clarification is a question of a bidder on a tender and the answer of
+// the tender responsibles. The asker is never disclosed to other bidders.
+type Clarification struct {
+	ID         uuid.UUID  `json:"id"`
+	TenderID   uuid.UUID  `json:"tenderId"`
+	Question   string     `json:"question"`
+	Answer     *string    `json:"answer"`
+	AskerID    uuid.UUID  `json:"-"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	AnsweredAt *time.Time `json:"answeredAt"`
+}
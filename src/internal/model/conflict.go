@@ -0,0 +1,34 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ConflictRecord is a blocked attempt to act on a tender
+// the actor has a conflict of interest with.
+type ConflictRecord struct {
+	ID             uuid.UUID  `json:"id"`
+	TenderID       uuid.UUID  `json:"tenderId"`
+	BidID          *uuid.UUID `json:"bidId"`
+	EmployeeID     *uuid.UUID `json:"employeeId"`
+	OrganizationID *uuid.UUID `json:"organizationId"`
+	Action         string     `json:"action"`
+	Reason         string     `json:"reason"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type Recusal struct {
+	TenderID   uuid.UUID `json:"tenderId"`
+	EmployeeID uuid.UUID `json:"employeeId"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type ConflictAction = string
+
+const (
+	ConflictBidCreation ConflictAction = "BidCreation"
+	ConflictDecision    ConflictAction = "Decision"
+	ConflictScoring     ConflictAction = "Scoring"
+)
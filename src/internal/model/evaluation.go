@@ -0,0 +1,38 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+type Criterion struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Weight float64   `json:"weight"`
+}
+
+type BidScore struct {
+	CriterionID uuid.UUID `json:"criterionId"`
+	Score       float64   `json:"score"`
+}
+
+type CriterionScore struct {
+	CriterionID  uuid.UUID `json:"criterionId"`
+	Name         string    `json:"name"`
+	Weight       float64   `json:"weight"`
+	AverageScore *float64  `json:"averageScore"`
+	Evaluators   int       `json:"evaluators"`
+}
+
+type BidEvaluation struct {
+	BidID         uuid.UUID        `json:"bidId"`
+	BidName       string           `json:"bidName"`
+	WeightedScore float64          `json:"weightedScore"`
+	Rank          int              `json:"rank"`
+	Criteria      []CriterionScore `json:"criteria"`
+}
+
+const (
+	// weights of the criteria of a tender add up to this value
+	CriteriaTotalWeight = 100
+	MaxBidScore         = 10
+)
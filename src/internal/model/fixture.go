@@ -0,0 +1,57 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Fixture is the demo data loaded by the seed command. Tenders are
+// referenced by name and employees by username, so the file stays readable.
+type Fixture struct {
+	Employees     []FixtureEmployee     `yaml:"employees"`
+	Organizations []FixtureOrganization `yaml:"organizations"`
+	Tenders       []FixtureTender       `yaml:"tenders"`
+	Bids          []FixtureBid          `yaml:"bids"`
+}
+
+type FixtureEmployee struct {
+	Username  string `yaml:"username"`
+	FirstName string `yaml:"firstName"`
+	LastName  string `yaml:"lastName"`
+}
+
+type FixtureOrganization struct {
+	ID           uuid.UUID `yaml:"id"`
+	Name         string    `yaml:"name"`
+	Description  string    `yaml:"description"`
+	Type         string    `yaml:"type"`
+	Responsibles []string  `yaml:"responsibles"`
+}
+
+type FixtureTender struct {
+	Name           string    `yaml:"name"`
+	Description    string    `yaml:"description"`
+	ServiceType    string    `yaml:"serviceType"`
+	OrganizationID uuid.UUID `yaml:"organizationId"`
+	Status         string    `yaml:"status"`
+	Visibility     string    `yaml:"visibility"`
+}
+
+type FixtureBid struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Tender is the name of a tender of the fixture
+	Tender     string `yaml:"tender"`
+	AuthorType string `yaml:"authorType"`
+	// Author is a username for User bids and an organization id for Organization bids
+	Author string `yaml:"author"`
+	Status string `yaml:"status"`
+}
+
+// SeedResult counts the records created by the seed, the records
+// found from an earlier run are left as they are.
+type SeedResult struct {
+	Employees     int
+	Organizations int
+	Tenders       int
+	Bids          int
+}
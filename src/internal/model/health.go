@@ -0,0 +1,48 @@
+package model
+
+const (
+	HealthStatusOk   = "ok"
+	HealthStatusFail = "fail"
+)
+
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type SchemaCheck struct {
+	Status          string `json:"status"`
+	Version         uint   `json:"version"`
+	ExpectedVersion uint   `json:"expectedVersion"`
+	Dirty           bool   `json:"dirty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// PoolStats describes the saturation of the database connection pool.
+type PoolStats struct {
+	MaxOpenConnections int    `json:"maxOpenConnections"`
+	OpenConnections    int    `json:"openConnections"`
+	InUse              int    `json:"inUse"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"waitCount"`
+	WaitDuration       string `json:"waitDuration"`
+	// Saturation is the share of the pool in use, it is 0 for unlimited pools
+	Saturation float64 `json:"saturation"`
+}
+
+// ReplicaCheck is the last health check of a read replica.
+type ReplicaCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Readiness struct {
+	Status   string      `json:"status"`
+	Draining bool        `json:"draining"`
+	Database HealthCheck `json:"database"`
+	Schema   SchemaCheck `json:"schema"`
+	Pool     PoolStats   `json:"pool"`
+	// Replicas never fail the check, the listings read from the primary instead
+	Replicas []ReplicaCheck `json:"replicas,omitempty"`
+}
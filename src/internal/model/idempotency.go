@@ -0,0 +1,16 @@
+package model
+
+// IdempotentResponse is the response kept for the retries of a request
+// with an Idempotency-Key.
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyRecord is a key in use: the fingerprint of the request
+// and its response, nil while the request is in progress.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Response    *IdempotentResponse
+}
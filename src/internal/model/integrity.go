@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IntegrityIssueKind = string
+
+const (
+	// IssueTenderWithoutInformation is a tender with no tender_information row,
+	// it is repaired by deleting the tender.
+	IssueTenderWithoutInformation IntegrityIssueKind = "TenderWithoutInformation"
+	// IssueBidWithoutInformation is a bid with no bid_information row,
+	// it is repaired by deleting the bid.
+	IssueBidWithoutInformation IntegrityIssueKind = "BidWithoutInformation"
+	// IssueBidWithoutTender is a bid on a tender that doesn't exist,
+	// it is repaired by deleting the bid.
+	IssueBidWithoutTender IntegrityIssueKind = "BidWithoutTender"
+	// IssueTenderVersionGap and IssueBidVersionGap are versions not numbered 1..n,
+	// they are repaired by renumbering the versions in their order.
+	IssueTenderVersionGap IntegrityIssueKind = "TenderVersionGap"
+	IssueBidVersionGap    IntegrityIssueKind = "BidVersionGap"
+	// IssueTenderCurrentVersion and IssueBidCurrentVersion are current versions
+	// other than the latest one, they are repaired by pointing them at the latest.
+	IssueTenderCurrentVersion IntegrityIssueKind = "TenderCurrentVersion"
+	IssueBidCurrentVersion    IntegrityIssueKind = "BidCurrentVersion"
+	// IssuePublishedBidOnClosedTender is a published bid that didn't win
+	// the closed tender, it is repaired by canceling the bid.
+	IssuePublishedBidOnClosedTender IntegrityIssueKind = "PublishedBidOnClosedTender"
+)
+
+type IntegrityIssue struct {
+	Kind   IntegrityIssueKind `json:"kind"`
+	ID     uuid.UUID          `json:"id"`
+	Detail string             `json:"detail"`
+}
+
+type IntegrityReport struct {
+	CheckedAt time.Time        `json:"checkedAt"`
+	Issues    []IntegrityIssue `json:"issues"`
+	// Repaired is set when the repairs are committed, a dry run reports
+	// the issues it would repair and rolls back
+	Repaired bool `json:"repaired"`
+	DryRun   bool `json:"dryRun"`
+}
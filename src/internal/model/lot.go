@@ -0,0 +1,24 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Lot struct {
+	ID          uuid.UUID  `json:"id"`
+	TenderID    uuid.UUID  `json:"tenderId"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	ServiceType string     `json:"serviceType"`
+	Status      string     `json:"status"`
+	WinnerBidID *uuid.UUID `json:"winnerBidId"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+type LotStatus = string
+
+const (
+	LotOpen    LotStatus = "Open"
+	LotDecided LotStatus = "Decided"
+)
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type QuestionnaireItem struct {
+	ID               uuid.UUID `json:"id"`
+	Question         string    `json:"question"`
+	Required         bool      `json:"required"`
+	RequiresDocument bool      `json:"requiresDocument"`
+}
+
+type QualificationDocument struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Content     []byte `json:"content"`
+}
+
+type QualificationAnswer struct {
+	ItemID   uuid.UUID              `json:"itemId"`
+	Answer   string                 `json:"answer"`
+	Document *QualificationDocument `json:"document,omitempty"`
+}
+
+type Qualification struct {
+	ID             uuid.UUID             `json:"id"`
+	TenderID       uuid.UUID             `json:"tenderId"`
+	OrganizationID uuid.UUID             `json:"organizationId"`
+	Status         string                `json:"status"`
+	ReviewComment  *string               `json:"reviewComment"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	ReviewedAt     *time.Time            `json:"reviewedAt"`
+	Answers        []QualificationAnswer `json:"answers,omitempty"`
+}
+
+type QualificationStatus = string
+
+const (
+	QualificationPending  QualificationStatus = "Pending"
+	QualificationApproved QualificationStatus = "Approved"
+	QualificationRejected QualificationStatus = "Rejected"
+)
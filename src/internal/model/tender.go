@@ -1,19 +1,36 @@
 package model
 
 import (
-	"github.com/google/uuid"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Tender struct {
-	ID             uuid.UUID `json:"id"`
-	Name           string    `json:"name"`
-	Description    string    `json:"description"`
-	Status         string    `json:"status"`
-	ServiceType    string    `json:"serviceType"`
-	Version        int       `json:"version"`
-	OrganizationID uuid.UUID `json:"-"`
-	CreatedAt      time.Time `json:"createdAt"`
+	ID             uuid.UUID       `json:"id"`
+	Name           string          `json:"name"`
+	Description    string          `json:"description"`
+	Status         string          `json:"status"`
+	ServiceType    string          `json:"serviceType"`
+	Visibility     string          `json:"visibility"`
+	Version        int             `json:"version"`
+	OrganizationID uuid.UUID       `json:"-"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	Questions      TenderQuestions `json:"questions"`
+}
+
+// TenderQuestions points to the clarifications of the tender, the answered
+// ones are public and don't name the askers.
+type TenderQuestions struct {
+	Answered int    `json:"answered"`
+	URL      string `json:"url"`
+}
+
+func (t Tender) MarshalJSON() ([]byte, error) {
+	type tender Tender
+	t.Questions.URL = "/api/tenders/" + t.ID.String() + "/questions"
+	return json.Marshal(tender(t))
 }
 
 type TenderUpdate struct {
@@ -29,3 +46,16 @@ const (
 	TenderPublished TenderStatus = "Published"
 	TenderClosed    TenderStatus = "Closed"
 )
+
+type TenderVisibility = string
+
+const (
+	TenderPublic     TenderVisibility = "Public"
+	TenderInviteOnly TenderVisibility = "InviteOnly"
+)
+
+type TenderInvitation struct {
+	TenderID       uuid.UUID `json:"tenderId"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// TransitionActor is the party allowed to move an entity to the next status.
+type TransitionActor = string
+
+const (
+	// ActorResponsible is an employee responsible for the tender organization.
+	ActorResponsible TransitionActor = "Responsible"
+	// ActorAuthor is the bid author or a responsible of the author organization.
+	ActorAuthor TransitionActor = "Author"
+	// ActorSystem covers transitions made by decisions and auctions.
+	ActorSystem TransitionActor = "System"
+)
+
+type StatusTransitions struct {
+	Status            string   `json:"status"`
+	AvailableStatuses []string `json:"availableStatuses"`
+}
@@ -0,0 +1,481 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ArchiveRepository reads and writes the organization archives,
+// the history and the timestamps are kept as they are.
+type ArchiveRepository struct {
+	db *sql.DB
+}
+
+func NewArchiveRepository() *ArchiveRepository {
+	db := db.DB
+	return &ArchiveRepository{
+		db: db,
+	}
+}
+
+// TxSetSnapshot makes the export see one snapshot of the database,
+// it has to be the first statement of the transaction. The SQLite
+// transactions always see one.
+func (r *ArchiveRepository) TxSetSnapshot(tx *sql.Tx) error {
+	if db.Driver == db.SQLite {
+		return nil
+	}
+	_, err := tx.Exec(`SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY`)
+	return err
+}
+
+func (r *ArchiveRepository) TxGetOrganization(tx *sql.Tx, organizationID uuid.UUID) (*model.ArchiveOrganization, error) {
+	query := `
+SELECT
+	id,
+	name,
+	COALESCE(description, ''),
+	COALESCE(CAST(type AS TEXT), '')
+FROM organization
+WHERE id = $1
+`
+	var o model.ArchiveOrganization
+	err := tx.QueryRow(query, organizationID).Scan(&o.ID, &o.Name, &o.Description, &o.Type)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoOrganization
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *ArchiveRepository) TxGetResponsibles(tx *sql.Tx, organizationID uuid.UUID) ([]model.ArchiveEmployee, error) {
+	query := `
+SELECT
+	e.username,
+	COALESCE(e.first_name, ''),
+	COALESCE(e.last_name, '')
+FROM organization_responsible ores
+	JOIN employee e
+		ON e.id = ores.user_id
+WHERE ores.organization_id = $1
+ORDER BY e.username
+`
+	return txQueryEmployees(tx, query, organizationID)
+}
+
+func (r *ArchiveRepository) TxGetEmployees(tx *sql.Tx, usernames []string) ([]model.ArchiveEmployee, error) {
+	query := `
+SELECT
+	username,
+	COALESCE(first_name, ''),
+	COALESCE(last_name, '')
+FROM employee
+WHERE ` + db.InArray("username", "$1") + `
+ORDER BY username
+`
+	return txQueryEmployees(tx, query, db.Array(usernames))
+}
+
+func txQueryEmployees(tx *sql.Tx, query string, args ...any) ([]model.ArchiveEmployee, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	employees := []model.ArchiveEmployee{}
+	for rows.Next() {
+		var e model.ArchiveEmployee
+		if err := rows.Scan(&e.Username, &e.FirstName, &e.LastName); err != nil {
+			return nil, err
+		}
+		employees = append(employees, e)
+	}
+	return employees, rows.Err()
+}
+
+// TxGetTenders returns the tenders of the organization with their
+// versions, bids, reviews and decisions.
+func (r *ArchiveRepository) TxGetTenders(tx *sql.Tx, organizationID uuid.UUID) ([]model.ArchiveTender, error) {
+	tenderQuery := `
+SELECT
+	id,
+	status,
+	visibility,
+	question_deadline,
+	created_at
+FROM tender
+WHERE organization_id = $1
+ORDER BY created_at, id
+`
+	versionQuery := `
+SELECT
+	ti.id,
+	ti.version,
+	ti.name,
+	COALESCE(ti.description, ''),
+	COALESCE(ti.service_type, '')
+FROM tender_information ti
+	JOIN tender t
+		ON t.id = ti.id
+WHERE t.organization_id = $1
+ORDER BY ti.id, ti.version
+`
+	rows, err := tx.Query(tenderQuery, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	tenders := []model.ArchiveTender{}
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		t := model.ArchiveTender{
+			Versions: []model.ArchiveTenderVersion{},
+			Bids:     []model.ArchiveBid{},
+		}
+		var deadline sql.NullTime
+		if err := rows.Scan(&t.ID, &t.Status, &t.Visibility, &deadline, &t.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if deadline.Valid {
+			t.QuestionDeadline = &deadline.Time
+		}
+		index[t.ID] = len(tenders)
+		tenders = append(tenders, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(versionQuery, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var (
+			id uuid.UUID
+			v  model.ArchiveTenderVersion
+		)
+		if err := rows.Scan(&id, &v.Version, &v.Name, &v.Description, &v.ServiceType); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tenders[index[id]].Versions = append(tenders[index[id]].Versions, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	bids, err := r.txGetBids(tx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for tenderID, tenderBids := range bids {
+		tenders[index[tenderID]].Bids = tenderBids
+	}
+	return tenders, nil
+}
+
+// txGetBids returns the bids received by the organization by tender.
+func (r *ArchiveRepository) txGetBids(tx *sql.Tx, organizationID uuid.UUID) (map[uuid.UUID][]model.ArchiveBid, error) {
+	bidQuery := `
+SELECT
+	b.id,
+	b.tender_id,
+	b.status,
+	b.author_type,
+	COALESCE(e.username, ''),
+	b.author_id,
+	b.created_at
+FROM bid b
+	JOIN tender t
+		ON t.id = b.tender_id
+	LEFT JOIN employee e
+		ON b.author_type = 'User'
+		AND e.id = b.author_id
+WHERE t.organization_id = $1
+ORDER BY b.created_at, b.id
+`
+	versionQuery := `
+SELECT
+	bi.id,
+	bi.version,
+	bi.name,
+	COALESCE(bi.description, '')
+FROM bid_information bi
+	JOIN bid b
+		ON b.id = bi.id
+	JOIN tender t
+		ON t.id = b.tender_id
+WHERE t.organization_id = $1
+ORDER BY bi.id, bi.version
+`
+	reviewQuery := `
+SELECT
+	br.bid_id,
+	COALESCE(br.description, ''),
+	br.created_at
+FROM bid_review br
+	JOIN bid b
+		ON b.id = br.bid_id
+	JOIN tender t
+		ON t.id = b.tender_id
+WHERE t.organization_id = $1
+ORDER BY br.created_at
+`
+	decisionQuery := `
+SELECT
+	d.bid_id,
+	e.username,
+	d.decision
+FROM bid_decision d
+	JOIN employee e
+		ON e.id = d.responsible_id
+	JOIN bid b
+		ON b.id = d.bid_id
+	JOIN tender t
+		ON t.id = b.tender_id
+WHERE t.organization_id = $1
+ORDER BY e.username
+`
+	rows, err := tx.Query(bidQuery, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	var bids []model.ArchiveBid
+	tenderIDs := []uuid.UUID{}
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		b := model.ArchiveBid{
+			Versions:  []model.ArchiveBidVersion{},
+			Reviews:   []model.ArchiveBidReview{},
+			Decisions: []model.ArchiveBidDecision{},
+		}
+		var tenderID, authorID uuid.UUID
+		err := rows.Scan(&b.ID, &tenderID, &b.Status, &b.AuthorType, &b.AuthorUsername, &authorID, &b.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if b.AuthorType == model.AuthorTypeOrganization {
+			b.AuthorOrganizationID = &authorID
+		}
+		index[b.ID] = len(bids)
+		bids = append(bids, b)
+		tenderIDs = append(tenderIDs, tenderID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(versionQuery, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var (
+			id uuid.UUID
+			v  model.ArchiveBidVersion
+		)
+		if err := rows.Scan(&id, &v.Version, &v.Name, &v.Description); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		bids[index[id]].Versions = append(bids[index[id]].Versions, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(reviewQuery, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var (
+			id     uuid.UUID
+			review model.ArchiveBidReview
+		)
+		if err := rows.Scan(&id, &review.Description, &review.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		bids[index[id]].Reviews = append(bids[index[id]].Reviews, review)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(decisionQuery, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var (
+			id       uuid.UUID
+			decision model.ArchiveBidDecision
+		)
+		if err := rows.Scan(&id, &decision.ResponsibleUsername, &decision.Decision); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		bids[index[id]].Decisions = append(bids[index[id]].Decisions, decision)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	byTender := make(map[uuid.UUID][]model.ArchiveBid)
+	for i, b := range bids {
+		byTender[tenderIDs[i]] = append(byTender[tenderIDs[i]], b)
+	}
+	return byTender, nil
+}
+
+// TxEnsureEmployee returns the id of the employee with the username,
+// creating the employee if there is none.
+func (r *ArchiveRepository) TxEnsureEmployee(tx *sql.Tx, e *model.ArchiveEmployee) (uuid.UUID, bool, error) {
+	insertQuery := `
+INSERT INTO employee
+	(username, first_name, last_name)
+VALUES ($1, $2, $3)
+ON CONFLICT (username) DO NOTHING
+RETURNING id
+`
+	selectQuery := `
+SELECT id
+FROM employee
+WHERE username = $1
+`
+	var id uuid.UUID
+	err := tx.QueryRow(insertQuery, e.Username, e.FirstName, e.LastName).Scan(&id)
+	if err == nil {
+		return id, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return uuid.Nil, false, err
+	}
+	err = tx.QueryRow(selectQuery, e.Username).Scan(&id)
+	return id, false, err
+}
+
+func (r *ArchiveRepository) TxInsertOrganization(tx *sql.Tx, o *model.ArchiveOrganization) error {
+	query := `
+INSERT INTO organization
+	(id, name, description, type)
+VALUES ($1, $2, $3, $4)
+`
+	organizationType := sql.NullString{String: o.Type, Valid: o.Type != ""}
+	_, err := tx.Exec(query, o.ID, o.Name, o.Description, organizationType)
+	return err
+}
+
+// TxInsertTender inserts the tender with a new id and all of its versions.
+func (r *ArchiveRepository) TxInsertTender(tx *sql.Tx, organizationID uuid.UUID, t *model.ArchiveTender) (uuid.UUID, error) {
+	tenderQuery := `
+INSERT INTO tender
+	(organization_id, status, visibility, question_deadline, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id
+`
+	versionQuery := `
+INSERT INTO tender_information
+	(id, version, name, description, service_type)
+VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+`
+	var id uuid.UUID
+	err := tx.QueryRow(tenderQuery, organizationID, t.Status, t.Visibility,
+		t.QuestionDeadline, t.CreatedAt).Scan(&id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, v := range t.Versions {
+		if _, err := tx.Exec(versionQuery, id, v.Version, v.Name, v.Description, v.ServiceType); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	return id, nil
+}
+
+// TxInsertBid inserts the bid with a new id, its versions and reviews.
+func (r *ArchiveRepository) TxInsertBid(tx *sql.Tx, tenderID, authorID uuid.UUID, b *model.ArchiveBid) (uuid.UUID, error) {
+	bidQuery := `
+INSERT INTO bid
+	(tender_id, status, author_type, author_id, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id
+`
+	versionQuery := `
+INSERT INTO bid_information
+	(id, version, name, description)
+VALUES ($1, $2, $3, $4)
+`
+	reviewQuery := `
+INSERT INTO bid_review
+	(bid_id, description, created_at)
+VALUES ($1, $2, $3)
+`
+	var id uuid.UUID
+	err := tx.QueryRow(bidQuery, tenderID, b.Status, b.AuthorType, authorID, b.CreatedAt).Scan(&id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, v := range b.Versions {
+		if _, err := tx.Exec(versionQuery, id, v.Version, v.Name, v.Description); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	for _, review := range b.Reviews {
+		if _, err := tx.Exec(reviewQuery, id, review.Description, review.CreatedAt); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	return id, nil
+}
+
+func (r *ArchiveRepository) TxInsertDecision(tx *sql.Tx, bidID, responsibleID uuid.UUID, decision string) error {
+	query := `
+INSERT INTO bid_decision
+	(bid_id, responsible_id, decision)
+VALUES ($1, $2, $3)
+`
+	_, err := tx.Exec(query, bidID, responsibleID, decision)
+	return err
+}
+
+// TxGetTenderPresent looks for a tender of the organization with the same
+// first name and creation time, that is a tender imported before.
+func (r *ArchiveRepository) TxGetTenderPresent(tx *sql.Tx, organizationID uuid.UUID, name string, createdAt time.Time) (bool, error) {
+	query := `
+SELECT 1
+FROM tender t
+	JOIN tender_information ti
+		ON ti.id = t.id
+		AND ti.version = 1
+WHERE
+	t.organization_id = $1
+	AND ti.name = $2
+	AND t.created_at = $3
+`
+	var one int
+	err := tx.QueryRow(query, organizationID, name, createdAt).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *ArchiveRepository) WithTransaction(fn func(tx *sql.Tx) error) error {
+	return db.RunInTx(r.db, fn)
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoAuction = errors.New("auction for the tender not found")
+)
+
+type AuctionRepository struct {
+	db *sql.DB
+}
+
+func NewAuctionRepository() *AuctionRepository {
+	db := db.DB
+	return &AuctionRepository{
+		db: db,
+	}
+}
+
+func (r *AuctionRepository) TxInsertAuction(tx *sql.Tx, a *model.Auction) error {
+	query := `
+INSERT INTO tender_auction
+	(tender_id, start_price, min_step, duration_seconds, extension_seconds)
+VALUES ($1, $2, $3, $4, $5)
+`
+	_, err := tx.Exec(query, a.TenderID, a.StartPrice, a.MinStep, a.DurationSeconds, a.ExtensionSeconds)
+	return err
+}
+
+func (r *AuctionRepository) GetAuctionByTenderID(tenderID uuid.UUID) (*model.Auction, error) {
+	query := `
+SELECT
+	tender_id,
+	start_price,
+	min_step,
+	duration_seconds,
+	extension_seconds,
+	ends_at,
+	best_price,
+	best_bid_id,
+	winner_bid_id,
+	finished
+FROM tender_auction
+WHERE tender_id = $1
+`
+	return scanAuction(r.db.QueryRow(query, tenderID))
+}
+
+// TxGetAuctionForUpdate locks the auction row, so concurrent offers
+// on the same tender are applied one after another.
+// The second value reports whether the auction is accepting offers right now.
+func (r *AuctionRepository) TxGetAuctionForUpdate(tx *sql.Tx, tenderID uuid.UUID) (*model.Auction, bool, error) {
+	query := `
+SELECT
+	a.tender_id,
+	a.start_price,
+	a.min_step,
+	a.duration_seconds,
+	a.extension_seconds,
+	a.ends_at,
+	a.best_price,
+	a.best_bid_id,
+	a.winner_bid_id,
+	a.finished,
+	(
+		t.status = 'Published'
+		AND NOT a.finished
+		AND a.ends_at IS NOT NULL
+		AND a.ends_at > CURRENT_TIMESTAMP
+	) AS running
+FROM tender_auction a
+	JOIN tender t
+		ON t.id = a.tender_id
+WHERE a.tender_id = $1
+`
+	// the SQLite transactions hold the write lock from the start
+	if db.Driver == db.Postgres {
+		query += "FOR UPDATE OF a\n"
+	}
+	var (
+		a       model.Auction
+		running bool
+	)
+	row := tx.QueryRow(query, tenderID)
+	err := row.Scan(&a.TenderID, &a.StartPrice, &a.MinStep, &a.DurationSeconds,
+		&a.ExtensionSeconds, &a.EndsAt, &a.BestPrice, &a.BestBidID,
+		&a.WinnerBidID, &a.Finished, &running)
+	if err == sql.ErrNoRows {
+		return nil, false, ErrNoAuction
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &a, running, nil
+}
+
+// TxPlaceOffer records the offer as the new best one and pushes the end of
+// the auction so that at least extension_seconds remain after the offer.
+func (r *AuctionRepository) TxPlaceOffer(tx *sql.Tx, o *model.AuctionOffer) error {
+	offerQuery := `
+INSERT INTO auction_offer
+	(tender_id, bid_id, price)
+VALUES ($1, $2, $3)
+RETURNING
+	id,
+	created_at
+`
+	auctionQuery := db.Pick(`
+UPDATE tender_auction
+SET
+	best_price = $2,
+	best_bid_id = $3,
+	ends_at = GREATEST(ends_at, CURRENT_TIMESTAMP + extension_seconds * INTERVAL '1 second')
+WHERE tender_id = $1
+`, `
+UPDATE tender_auction
+SET
+	best_price = $2,
+	best_bid_id = $3,
+	ends_at = MAX(ends_at, datetime(CURRENT_TIMESTAMP, '+' || extension_seconds || ' seconds'))
+WHERE tender_id = $1
+`)
+	row := tx.QueryRow(offerQuery, o.TenderID, o.BidID, o.Price)
+	err := row.Scan(&o.ID, &o.CreatedAt)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(auctionQuery, o.TenderID, o.Price, o.BidID)
+	return err
+}
+
+func (r *AuctionRepository) StartAuction(tenderID uuid.UUID) error {
+	query := db.Pick(`
+UPDATE tender_auction
+SET ends_at = CURRENT_TIMESTAMP + duration_seconds * INTERVAL '1 second'
+WHERE
+	tender_id = $1
+	AND ends_at IS NULL
+`, `
+UPDATE tender_auction
+SET ends_at = datetime(CURRENT_TIMESTAMP, '+' || duration_seconds || ' seconds')
+WHERE
+	tender_id = $1
+	AND ends_at IS NULL
+`)
+	_, err := r.db.Exec(query, tenderID)
+	return err
+}
+
+// CloseExpiredAuctions declares the best offer the winner of every auction
+// whose time has run out and runs the status change closing the corresponding
+// tenders, or only the auction of the tender if tenderID isn't nil. A tender
+// the change doesn't apply to keeps its status.
+func (r *AuctionRepository) CloseExpiredAuctions(tenderID *uuid.UUID, change StatusChange) (int64, error) {
+	auctionQuery := `
+UPDATE tender_auction
+SET
+	finished = TRUE,
+	winner_bid_id = best_bid_id
+WHERE
+	NOT finished
+	AND ends_at <= CURRENT_TIMESTAMP
+`
+	args := []any{}
+	if tenderID != nil {
+		auctionQuery += "\tAND tender_id = $1\n"
+		args = append(args, *tenderID)
+	}
+	auctionQuery += "RETURNING tender_id\n"
+	tenderQuery := `
+UPDATE tender
+SET status = $2
+WHERE
+	` + db.InArray("id", "$1") + `
+	AND status = $3
+`
+	if change.Guard != "" {
+		tenderQuery += "\tAND " + string(change.Guard) + "\n"
+	}
+	var closed int64
+	err := db.RunInTx(r.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(auctionQuery, args...)
+		if err != nil {
+			return err
+		}
+		tenderIDs := []uuid.UUID{}
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			tenderIDs = append(tenderIDs, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(tenderIDs) == 0 {
+			closed = 0
+			return nil
+		}
+
+		res, err := tx.Exec(tenderQuery, db.Array(tenderIDs), change.To, change.From)
+		if err != nil {
+			return err
+		}
+		closed, err = res.RowsAffected()
+		return err
+	})
+	return closed, err
+}
+
+func (r *AuctionRepository) GetAuctionOffers(tenderID uuid.UUID, limit, offset int) ([]model.AuctionOffer, error) {
+	query := `
+SELECT
+	id,
+	tender_id,
+	bid_id,
+	price,
+	created_at
+FROM auction_offer
+WHERE tender_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3
+`
+	rows, err := r.db.Query(query, tenderID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var offers []model.AuctionOffer
+	for rows.Next() {
+		var o model.AuctionOffer
+		err := rows.Scan(&o.ID, &o.TenderID, &o.BidID, &o.Price, &o.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, o)
+	}
+	return offers, nil
+}
+
+func (r *AuctionRepository) WithTransaction(fn func(tx *sql.Tx) error) error {
+	return db.RunInTx(r.db, fn)
+}
+
+func scanAuction(row *sql.Row) (*model.Auction, error) {
+	var a model.Auction
+	err := row.Scan(&a.TenderID, &a.StartPrice, &a.MinStep, &a.DurationSeconds,
+		&a.ExtensionSeconds, &a.EndsAt, &a.BestPrice, &a.BestBidID,
+		&a.WinnerBidID, &a.Finished)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoAuction
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// AuthorizationRepository reads everything an authorization check needs
+// in one query, the bid or tender the check is about along with the caller.
+type AuthorizationRepository struct {
+	db *sql.DB
+}
+
+func NewAuthorizationRepository() *AuthorizationRepository {
+	db := db.DB
+	return &AuthorizationRepository{
+		db: db,
+	}
+}
+
+// GetCaller resolves the employee with the organizations the employee is responsible for.
+func (r *AuthorizationRepository) GetCaller(username string) (*model.Caller, error) {
+	query := `
+SELECT
+	e.id,
+	orr.organization_id
+FROM employee e
+	LEFT JOIN organization_responsible orr
+		ON orr.user_id = e.id
+WHERE e.username = $1
+`
+	rows, err := r.db.Query(query, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var caller *model.Caller
+	for rows.Next() {
+		var employeeID, organizationID uuid.NullUUID
+		if err := rows.Scan(&employeeID, &organizationID); err != nil {
+			return nil, err
+		}
+		caller = addCallerRow(caller, username, employeeID, organizationID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if caller == nil {
+		return nil, ErrNoEmployee
+	}
+	return caller, nil
+}
+
+// callerJoins add the caller with the username $2 to an access query, a row
+// for each of the organizations the caller is responsible for. The employee
+// columns are NULL if there is no such employee or the username is empty.
+const callerJoins = `
+	LEFT JOIN employee e
+		ON e.username = $2
+	LEFT JOIN organization_responsible orr
+		ON orr.user_id = e.id
+`
+
+// addCallerRow adds a row of the caller columns to the caller,
+// which is nil until the first row of an employee.
+func addCallerRow(caller *model.Caller, username string, employeeID, organizationID uuid.NullUUID) *model.Caller {
+	if !employeeID.Valid {
+		return caller
+	}
+	if caller == nil {
+		caller = &model.Caller{EmployeeID: employeeID.UUID, Username: username}
+	}
+	if organizationID.Valid {
+		caller.Organizations = append(caller.Organizations, organizationID.UUID)
+	}
+	return caller
+}
+
+// GetBidAccess reads the current version of the bid with the organization
+// of its tender and, unless username is empty, the caller with the username
+// in the same query. The caller is nil if there is no such employee.
+func (r *AuthorizationRepository) GetBidAccess(username string, bidID uuid.UUID) (*model.Bid, uuid.UUID, *model.Caller, error) {
+	query := `
+SELECT
+	b.id,
+	bi.name,
+	bi.description,
+	b.tender_id,
+	b.lot_id,
+	b.status,
+	b.author_id,
+	b.author_type,
+	bi.version,
+	b.created_at,
+	t.organization_id,
+	e.id,
+	orr.organization_id
+FROM bid b
+	JOIN bid_information bi
+		ON bi.id = b.id
+		AND bi.version = b.current_version
+	JOIN tender t
+		ON t.id = b.tender_id
+` + callerJoins + `
+WHERE b.id = $1
+`
+	rows, err := r.db.Query(query, bidID, username)
+	if err != nil {
+		return nil, uuid.Nil, nil, err
+	}
+	defer rows.Close()
+
+	var (
+		bid                  *model.Bid
+		tenderOrganizationID uuid.UUID
+		caller               *model.Caller
+	)
+	for rows.Next() {
+		var (
+			b                          model.Bid
+			employeeID, organizationID uuid.NullUUID
+		)
+		err := rows.Scan(&b.ID, &b.Name, &b.Description,
+			&b.TenderID, &b.LotID, &b.Status, &b.AuthorID,
+			&b.AuthorType, &b.Version, &b.CreatedAt, &tenderOrganizationID,
+			&employeeID, &organizationID)
+		if err != nil {
+			return nil, uuid.Nil, nil, err
+		}
+		if bid == nil {
+			bid = &b
+		}
+		caller = addCallerRow(caller, username, employeeID, organizationID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, uuid.Nil, nil, err
+	}
+	if bid == nil {
+		return nil, uuid.Nil, nil, ErrNoBid
+	}
+	return bid, tenderOrganizationID, caller, nil
+}
+
+// GetTenderAccess reads the current version of the tender and, unless
+// username is empty, the caller with the username in the same query.
+// The caller is nil if there is no such employee.
+func (r *AuthorizationRepository) GetTenderAccess(username string, tenderID uuid.UUID) (*model.Tender, *model.Caller, error) {
+	query := `
+SELECT
+	t.id,
+	ti.name,
+	ti.description,
+	ti.service_type,
+	t.status,
+	t.visibility,
+	t.organization_id,
+	ti.version,
+	t.created_at,
+	(SELECT COUNT(*) FROM tender_question q WHERE q.tender_id = t.id AND q.answer IS NOT NULL),
+	e.id,
+	orr.organization_id
+FROM tender t
+	JOIN tender_information ti
+		ON ti.id = t.id
+		AND ti.version = t.current_version
+` + callerJoins + `
+WHERE t.id = $1
+`
+	rows, err := r.db.Query(query, tenderID, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var (
+		tender *model.Tender
+		caller *model.Caller
+	)
+	for rows.Next() {
+		var (
+			t                          model.Tender
+			employeeID, organizationID uuid.NullUUID
+		)
+		err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.ServiceType, &t.Status,
+			&t.Visibility, &t.OrganizationID, &t.Version, &t.CreatedAt, &t.Questions.Answered,
+			&employeeID, &organizationID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tender == nil {
+			tender = &t
+		}
+		caller = addCallerRow(caller, username, employeeID, organizationID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if tender == nil {
+		return nil, nil, ErrNoTender
+	}
+	return tender, caller, nil
+}
+
+// GetAuthorUsernames resolves the author of a bid to the employees writing
+// as the author: the employee of a User bid or the responsibles
+// of the organization of an Organization bid.
+func (r *AuthorizationRepository) GetAuthorUsernames(authorID uuid.UUID) ([]string, error) {
+	query := `
+SELECT e.username
+FROM employee e
+WHERE
+	e.id = $1
+	OR e.id IN (
+		SELECT user_id
+		FROM organization_responsible
+		WHERE organization_id = $1
+	)
+`
+	rows, err := r.db.Query(query, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usernames := []string{}
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		usernames = append(usernames, username)
+	}
+	return usernames, rows.Err()
+}
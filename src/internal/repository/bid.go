@@ -25,10 +25,16 @@ func NewBidRepository() *BidRepository {
 }
 
 func (r *BidRepository) InsertNewBid(b *model.Bid) error {
+	return db.RunInTx(r.db, func(tx *sql.Tx) error {
+		return r.TxInsertNewBid(tx, b)
+	})
+}
+
+func (r *BidRepository) TxInsertNewBid(tx *sql.Tx, b *model.Bid) error {
 	bidQuery := `
 INSERT INTO bid
-	(tender_id, author_type, author_id)
-VALUES ($1, $2, $3)
+	(tender_id, lot_id, author_type, author_id)
+VALUES ($1, $2, $3, $4)
 RETURNING 
 	id,
 	status,
@@ -41,38 +47,53 @@ VALUES ($1, $2, $3)
 RETURNING
 	version;
 `
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-
-	row := tx.QueryRow(bidQuery, b.TenderID, b.AuthorType, b.AuthorID)
-	err = row.Scan(&b.ID, &b.Status, &b.CreatedAt)
-
-	if err != nil {
-		tx.Rollback()
+	row := tx.QueryRow(bidQuery, b.TenderID, b.LotID, b.AuthorType, b.AuthorID)
+	if err := row.Scan(&b.ID, &b.Status, &b.CreatedAt); err != nil {
 		return err
 	}
-
 	row = tx.QueryRow(bidInfoQuery, b.ID, b.Name, b.Description)
-	err = row.Scan(&b.Version)
+	return row.Scan(&b.Version)
+}
 
+// TxGetBidIDByName finds a bid of the author on the tender by its first name.
+func (r *BidRepository) TxGetBidIDByName(tx *sql.Tx, tenderID, authorID uuid.UUID, name string) (*uuid.UUID, error) {
+	query := `
+SELECT b.id
+FROM bid b
+	JOIN bid_information bi
+		ON bi.id = b.id
+		AND bi.version = 1
+WHERE
+	b.tender_id = $1
+	AND b.author_id = $2
+	AND bi.name = $3
+LIMIT 1
+`
+	var id uuid.UUID
+	err := tx.QueryRow(query, tenderID, authorID, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoBid
+	}
 	if err != nil {
-		tx.Rollback()
-	} else {
-		tx.Commit()
+		return nil, err
 	}
-
-	return err
+	return &id, nil
 }
 
-func (r *BidRepository) GetUserBids(userID uuid.UUID, limit, offset int) ([]model.Bid, error) {
+// GetUserBids lists the bids of the user and of the organizations the user is
+// responsible for, in their current versions or, with includeHistory, in all of them.
+func (r *BidRepository) GetUserBids(userID uuid.UUID, includeHistory bool, limit, offset int) ([]model.Bid, error) {
+	versions := "AND bi.version = b.current_version"
+	if includeHistory {
+		versions = ""
+	}
 	query := `
 SELECT
 	b.id,
 	bi.name,
 	bi.description,
 	b.tender_id,
+	b.lot_id,
 	b.status,
 	b.author_id,
 	b.author_type,
@@ -81,6 +102,7 @@ SELECT
 FROM bid b
 	JOIN bid_information bi
 		ON bi.id = b.id
+		` + versions + `
 WHERE
 	author_type = 'Organization'
 	AND author_id IN (
@@ -105,7 +127,7 @@ OFFSET $3
 	for rows.Next() {
 		var bid model.Bid
 		err := rows.Scan(&bid.ID, &bid.Name, &bid.Description,
-			&bid.TenderID, &bid.Status, &bid.AuthorID,
+			&bid.TenderID, &bid.LotID, &bid.Status, &bid.AuthorID,
 			&bid.AuthorType, &bid.Version, &bid.CreatedAt)
 		if err != nil {
 			return nil, err
@@ -115,13 +137,17 @@ OFFSET $3
 	return bids, nil
 }
 
-func (r *BidRepository) GetPublicBidsByTender(tenderID uuid.UUID, limit, offset int) ([]model.Bid, error) {
+// GetPublicBidsByTender lists the published bids of the tender.
+// If lotID is not nil, only the bids placed against that lot are returned.
+// The listing may read from a replica, reader is the username it reads for, see db.Query.
+func (r *BidRepository) GetPublicBidsByTender(reader string, tenderID uuid.UUID, lotID *uuid.UUID, limit, offset int) ([]model.Bid, error) {
 	query := `
 SELECT
 	b.id,
 	bi.name,
 	bi.description,
 	b.tender_id,
+	b.lot_id,
 	b.status,
 	b.author_id,
 	b.author_type,
@@ -130,20 +156,16 @@ SELECT
 FROM bid b
 	JOIN bid_information bi
 		ON bi.id = b.id
-	JOIN (
-		SELECT id, MAX(version) as mv
-		FROM bid_information
-		GROUP BY id
-	) latest_bi
-		ON latest_bi.id = bi.id AND bi.version = latest_bi.mv
+		AND bi.version = b.current_version
 WHERE
 	b.tender_id = $1
 	AND b.status = 'Published'
+	AND (CAST($2 AS UUID) IS NULL OR b.lot_id = $2)
 ORDER BY name ASC, version DESC
-LIMIT $2
-OFFSET $3
+LIMIT $3
+OFFSET $4
 `
-	rows, err := r.db.Query(query, tenderID, limit, offset)
+	rows, err := db.Query(r.db, reader, query, tenderID, lotID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +175,7 @@ OFFSET $3
 	for rows.Next() {
 		var bid model.Bid
 		err := rows.Scan(&bid.ID, &bid.Name, &bid.Description,
-			&bid.TenderID, &bid.Status, &bid.AuthorID,
+			&bid.TenderID, &bid.LotID, &bid.Status, &bid.AuthorID,
 			&bid.AuthorType, &bid.Version, &bid.CreatedAt)
 		if err != nil {
 			return nil, err
@@ -163,21 +185,20 @@ OFFSET $3
 	return bids, nil
 }
 
-func (r *BidRepository) UpdateBidStatus(b *model.Bid) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	err = r.TxSetBidStatus(tx, b.ID, b.Status)
-	if err != nil && err != ErrNoBid {
-		tx.Rollback()
-		return err
-	} else {
-		tx.Commit()
-	}
-	tmp, err := r.GetLastBidByID(b.ID)
-	*b = *tmp
-	return err
+// ChangeBidStatus moves the bid to the new status unless its status
+// has changed or the guard of the change doesn't hold anymore.
+func (r *BidRepository) ChangeBidStatus(bidID uuid.UUID, change StatusChange) error {
+	return changeStatus(r.db, "bid", bidID, change)
+}
+
+func (r *BidRepository) TxChangeBidStatus(tx *sql.Tx, bidID uuid.UUID, change StatusChange) error {
+	return changeStatus(tx, "bid", bidID, change)
+}
+
+// BidGuardHolds reports whether a status change of the bid guarded
+// by the guard would be allowed now.
+func (r *BidRepository) BidGuardHolds(bidID uuid.UUID, guard StatusGuard) (bool, error) {
+	return guardHolds(r.db, "bid", bidID, guard)
 }
 
 func (r *BidRepository) TxSetBidStatus(tx *sql.Tx, bidID uuid.UUID, status string) error {
@@ -205,6 +226,7 @@ SELECT
 	bi.name,
 	bi.description,
 	b.tender_id,
+	b.lot_id,
 	b.status,
 	b.author_id,
 	b.author_type,
@@ -213,15 +235,14 @@ SELECT
 FROM bid b
 	JOIN bid_information bi
 		ON bi.id = b.id
+		AND bi.version = b.current_version
 WHERE b.id = $1
-ORDER BY version DESC
-LIMIT 1
 `
 	var bid model.Bid
 
 	row := r.db.QueryRow(query, bidID)
 	err := row.Scan(&bid.ID, &bid.Name, &bid.Description,
-		&bid.TenderID, &bid.Status, &bid.AuthorID,
+		&bid.TenderID, &bid.LotID, &bid.Status, &bid.AuthorID,
 		&bid.AuthorType, &bid.Version, &bid.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNoBid
@@ -232,7 +253,8 @@ LIMIT 1
 	return &bid, nil
 }
 
-func (r *BidRepository) PatchBid(bidID uuid.UUID, patch *model.BidUpdate) (*model.Bid, error) {
+// PatchBid adds a version of the bid made of the current one, b, and the patch.
+func (r *BidRepository) PatchBid(b *model.Bid, patch *model.BidUpdate) (*model.Bid, error) {
 	bidInfoQuery := `
 INSERT INTO bid_information
 	(id, name, description, version)
@@ -240,66 +262,66 @@ SELECT
 	$1,
 	$2,
 	$3,
-	(SELECT MAX(version) + 1 FROM bid_information WHERE id = $1)
+	(SELECT current_version + 1 FROM bid WHERE id = $1)
 RETURNING
 	version;
 `
-	b, err := r.GetLastBidByID(bidID)
-	if err != nil {
-		return nil, err
-	}
+	patched := *b
 	if patch.Name != nil {
-		b.Name = *patch.Name
+		patched.Name = *patch.Name
 	}
 	if patch.Description != nil {
-		b.Description = *patch.Description
+		patched.Description = *patch.Description
 	}
 
-	row := r.db.QueryRow(bidInfoQuery, b.ID, b.Name, b.Description)
-	err = row.Scan(&b.Version)
+	row := r.db.QueryRow(bidInfoQuery, patched.ID, patched.Name, patched.Description)
+	err := row.Scan(&patched.Version)
 	if err != nil {
 		return nil, err
 	}
-	return r.GetLastBidByID(bidID)
+	return &patched, nil
 }
 
-func (r *BidRepository) RollbackBid(bidID uuid.UUID, version int) (*model.Bid, error) {
+// RollbackBid adds a version of the bid, b is the current one,
+// with the name and the description of the given version.
+func (r *BidRepository) RollbackBid(b *model.Bid, version int) (*model.Bid, error) {
 	bidInfoQuery := `
 INSERT INTO bid_information
 	(id, version, name, description)
 SELECT
 	id,
-	(SELECT MAX(version) + 1 FROM bid_information WHERE id = $1),
+	(SELECT current_version + 1 FROM bid WHERE id = $1),
 	name,
 	description
 FROM bid_information
 WHERE id = $1 AND version = $2
-RETURNING version
+RETURNING version, name, description
 `
-	q, err := r.db.Query(bidInfoQuery, bidID, version)
+	rolledBack := *b
+	err := r.db.QueryRow(bidInfoQuery, b.ID, version).Scan(&rolledBack.Version,
+		&rolledBack.Name, &rolledBack.Description)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoBid
+	}
 	if err != nil {
 		return nil, err
 	}
-	if !q.Next() {
-		return nil, ErrNoBid
-	}
-	return r.GetLastBidByID(bidID)
+	return &rolledBack, nil
 }
 
-func (r *BidRepository) LeaveReview(bidID uuid.UUID, review string) (*model.Bid, error) {
+func (r *BidRepository) LeaveReview(bidID uuid.UUID, review string) error {
 	bidReviewQuery := `
 INSERT INTO bid_review
 	(bid_id, description)
 VALUES ($1, $2)
 `
 	_, err := r.db.Exec(bidReviewQuery, bidID, review)
-	if err != nil {
-		return nil, err
-	}
-	return r.GetLastBidByID(bidID)
+	return err
 }
 
-func (r *BidRepository) GetTenderReviewsOnUser(tenderID, bidUserID uuid.UUID,
+// GetTenderReviewsOnUser lists the reviews on the bids of the user in the tender,
+// it may read from a replica like GetPublicBidsByTender.
+func (r *BidRepository) GetTenderReviewsOnUser(reader string, tenderID, bidUserID uuid.UUID,
 	limit, offset int) ([]model.BidReview, error) {
 
 	bidReviewQuery := `
@@ -321,7 +343,7 @@ ORDER BY br.created_at DESC
 LIMIT $3
 OFFSET $4
 `
-	rows, err := r.db.Query(bidReviewQuery, tenderID, bidUserID, limit, offset)
+	rows, err := db.Query(r.db, reader, bidReviewQuery, tenderID, bidUserID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
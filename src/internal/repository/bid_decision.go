@@ -40,20 +40,18 @@ WHERE bid_id = $1 AND responsible_id = $2
 }
 
 func (r *BidDecisionRepository) TxCountDecisions(tx *sql.Tx, bidID uuid.UUID) (int, int, error) {
+	// the recused responsibles don't count towards the quorum,
+	// so their decisions made before the recusal don't count either
 	query := `
 SELECT
-	approve.approve_count, reject.reject_count
-FROM
-(
-	SELECT COUNT(1) as approve_count
-	FROM bid_decision
-	WHERE bid_id = $1 AND decision = 'Approved'
-) as approve,
-(
-	SELECT COUNT(1) as reject_count
-	FROM bid_decision
-	WHERE bid_id = $1 AND decision = 'Rejected'
-) as reject
+	COUNT(CASE WHEN bd.decision = 'Approved' THEN 1 END) as approve_count,
+	COUNT(CASE WHEN bd.decision = 'Rejected' THEN 1 END) as reject_count
+FROM bid_decision bd
+JOIN bid b ON b.id = bd.bid_id
+LEFT JOIN tender_recusal tr ON tr.tender_id = b.tender_id AND tr.employee_id = bd.responsible_id
+WHERE
+	bd.bid_id = $1
+	AND tr.employee_id IS NULL
 `
 	var (
 		approveCount int
@@ -66,22 +64,5 @@ FROM
 }
 
 func (r *BidDecisionRepository) WithTransaction(fn func(tx *sql.Tx) error) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			panic(r)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
-		}
-	}()
-
-	err = fn(tx)
-
-	return err
+	return db.RunInTx(r.db, fn)
 }
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrNoCategory = errors.New("service category not found")
+)
+
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRepository() *CategoryRepository {
+	db := db.DB
+	return &CategoryRepository{
+		db: db,
+	}
+}
+
+func (r *CategoryRepository) GetCategories() ([]model.ServiceCategory, error) {
+	query := `
+SELECT
+	code,
+	parent_code,
+	created_at
+FROM service_category
+ORDER BY code ASC
+`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []model.ServiceCategory
+	for rows.Next() {
+		var c model.ServiceCategory
+		err := rows.Scan(&c.Code, &c.ParentCode, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	names, err := r.getNames(nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categories {
+		categories[i].Names = names[categories[i].Code]
+	}
+	return categories, nil
+}
+
+func (r *CategoryRepository) GetCategoryByCode(code string) (*model.ServiceCategory, error) {
+	query := `
+SELECT
+	code,
+	parent_code,
+	created_at
+FROM service_category
+WHERE code = $1
+`
+	var c model.ServiceCategory
+
+	err := r.db.QueryRow(query, code).Scan(&c.Code, &c.ParentCode, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoCategory
+	}
+	if err != nil {
+		return nil, err
+	}
+	names, err := r.getNames(&code)
+	if err != nil {
+		return nil, err
+	}
+	c.Names = names[code]
+	return &c, nil
+}
+
+// getNames returns the display names by category code and locale.
+// If code is nil, the names of all categories are returned.
+func (r *CategoryRepository) getNames(code *string) (map[string]map[string]string, error) {
+	query := `
+SELECT
+	code,
+	locale,
+	name
+FROM service_category_name
+WHERE CAST($1 AS VARCHAR) IS NULL OR code = $1
+`
+	rows, err := r.db.Query(query, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]map[string]string)
+	for rows.Next() {
+		var categoryCode, locale, name string
+		if err := rows.Scan(&categoryCode, &locale, &name); err != nil {
+			return nil, err
+		}
+		if names[categoryCode] == nil {
+			names[categoryCode] = make(map[string]string)
+		}
+		names[categoryCode][locale] = name
+	}
+	return names, rows.Err()
+}
+
+func (r *CategoryRepository) GetCategoryPresent(code string) (bool, error) {
+	query := `
+SELECT 1
+FROM service_category
+WHERE code = $1
+`
+	var one int
+	err := r.db.QueryRow(query, code).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetIfCategoryIsDescendant reports whether code lies in the subtree of ancestorCode,
+// a category counts as its own descendant.
+func (r *CategoryRepository) GetIfCategoryIsDescendant(code, ancestorCode string) (bool, error) {
+	query := `
+WITH RECURSIVE subtree AS (
+	SELECT code
+	FROM service_category
+	WHERE code = $2
+	UNION
+	SELECT c.code
+	FROM service_category c
+		JOIN subtree s
+			ON c.parent_code = s.code
+)
+SELECT 1
+FROM subtree
+WHERE code = $1
+`
+	var one int
+	err := r.db.QueryRow(query, code, ancestorCode).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetCategoryInUse reports whether the category has subcategories
+// or is referenced by a tender version or a lot.
+func (r *CategoryRepository) GetCategoryInUse(code string) (bool, error) {
+	query := `
+SELECT
+	EXISTS (SELECT 1 FROM service_category WHERE parent_code = $1)
+	OR EXISTS (SELECT 1 FROM tender_information WHERE service_type = $1)
+	OR EXISTS (SELECT 1 FROM tender_lot WHERE service_type = $1)
+`
+	var inUse bool
+	err := r.db.QueryRow(query, code).Scan(&inUse)
+	return inUse, err
+}
+
+func (r *CategoryRepository) TxInsertCategory(tx *sql.Tx, c *model.ServiceCategory) error {
+	query := `
+INSERT INTO service_category
+	(code, parent_code)
+VALUES ($1, $2)
+RETURNING
+	created_at
+`
+	return tx.QueryRow(query, c.Code, c.ParentCode).Scan(&c.CreatedAt)
+}
+
+func (r *CategoryRepository) TxUpdateParent(tx *sql.Tx, code string, parentCode *string) error {
+	query := `
+UPDATE service_category
+SET parent_code = $2
+WHERE code = $1
+`
+	res, err := tx.Exec(query, code, parentCode)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return ErrNoCategory
+	}
+	return nil
+}
+
+// TxReplaceNames replaces all display names of the category.
+func (r *CategoryRepository) TxReplaceNames(tx *sql.Tx, code string, names map[string]string) error {
+	deleteQuery := `
+DELETE FROM service_category_name
+WHERE code = $1
+`
+	insertQuery := `
+INSERT INTO service_category_name
+	(code, locale, name)
+VALUES ($1, $2, $3)
+`
+	if _, err := tx.Exec(deleteQuery, code); err != nil {
+		return err
+	}
+	for locale, name := range names {
+		if _, err := tx.Exec(insertQuery, code, locale, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *CategoryRepository) DeleteCategory(code string) error {
+	query := `
+DELETE FROM service_category
+WHERE code = $1
+`
+	res, err := r.db.Exec(query, code)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return ErrNoCategory
+	}
+	return nil
+}
+
+func (r *CategoryRepository) WithTransaction(fn func(tx *sql.Tx) error) error {
+	return db.RunInTx(r.db, fn)
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoQuestion = errors.New("question not found")
+)
+
+type ClarificationRepository struct {
+	db *sql.DB
+}
+
+func NewClarificationRepository() *ClarificationRepository {
+	db := db.DB
+	return &ClarificationRepository{
+		db: db,
+	}
+}
+
+func (r *ClarificationRepository) InsertNewQuestion(c *model.Clarification) error {
+	query := `
+INSERT INTO tender_question
+	(tender_id, asker_id, question)
+VALUES ($1, $2, $3)
+RETURNING
+	id,
+	created_at
+`
+	row := r.db.QueryRow(query, c.TenderID, c.AskerID, c.Question)
+	return row.Scan(&c.ID, &c.CreatedAt)
+}
+
+func (r *ClarificationRepository) GetQuestionByID(questionID uuid.UUID) (*model.Clarification, error) {
+	query := `
+SELECT
+	id,
+	tender_id,
+	question,
+	answer,
+	asker_id,
+	created_at,
+	answered_at
+FROM tender_question
+WHERE id = $1
+`
+	var c model.Clarification
+
+	row := r.db.QueryRow(query, questionID)
+	err := row.Scan(&c.ID, &c.TenderID, &c.Question, &c.Answer,
+		&c.AskerID, &c.CreatedAt, &c.AnsweredAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoQuestion
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *ClarificationRepository) AnswerQuestion(questionID, responsibleID uuid.UUID, answer string) (*model.Clarification, error) {
+	query := `
+UPDATE tender_question
+SET
+	answer = $2,
+	answered_by = $3,
+	answered_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+	res, err := r.db.Exec(query, questionID, answer, responsibleID)
+	if err != nil {
+		return nil, err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return nil, ErrNoQuestion
+	}
+	return r.GetQuestionByID(questionID)
+}
+
+// GetTenderQuestions lists the answered questions of the tender.
+// With allQuestions set, unanswered questions are listed too;
+// otherwise unanswered questions of askerID, if not nil, are included.
+func (r *ClarificationRepository) GetTenderQuestions(tenderID uuid.UUID, askerID *uuid.UUID, allQuestions bool,
+	limit, offset int) ([]model.Clarification, error) {
+
+	query := `
+SELECT
+	id,
+	tender_id,
+	question,
+	answer,
+	asker_id,
+	created_at,
+	answered_at
+FROM tender_question
+WHERE
+	tender_id = $1
+	AND (
+		answer IS NOT NULL
+		OR $2
+		OR asker_id = $3
+	)
+ORDER BY created_at ASC
+LIMIT $4
+OFFSET $5
+`
+	rows, err := r.db.Query(query, tenderID, allQuestions, askerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []model.Clarification
+	for rows.Next() {
+		var c model.Clarification
+		err := rows.Scan(&c.ID, &c.TenderID, &c.Question, &c.Answer,
+			&c.AskerID, &c.CreatedAt, &c.AnsweredAt)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, c)
+	}
+	return questions, nil
+}
+
+func (r *ClarificationRepository) GetQuestionDeadline(tenderID uuid.UUID) (*time.Time, error) {
+	query := `
+SELECT question_deadline
+FROM tender
+WHERE id = $1
+`
+	var deadline *time.Time
+	err := r.db.QueryRow(query, tenderID).Scan(&deadline)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoTender
+	}
+	if err != nil {
+		return nil, err
+	}
+	return deadline, nil
+}
+
+func (r *ClarificationRepository) SetQuestionDeadline(tenderID uuid.UUID, deadline *time.Time) error {
+	query := `
+UPDATE tender
+SET question_deadline = $2
+WHERE id = $1
+`
+	res, err := r.db.Exec(query, tenderID, deadline)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return ErrNoTender
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type ConflictRepository struct {
+	db *sql.DB
+}
+
+func NewConflictRepository() *ConflictRepository {
+	db := db.DB
+	return &ConflictRepository{
+		db: db,
+	}
+}
+
+func (r *ConflictRepository) InsertConflict(c *model.ConflictRecord) error {
+	query := `
+INSERT INTO conflict_of_interest
+	(tender_id, bid_id, employee_id, organization_id, action, reason)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING
+	id,
+	created_at
+`
+	row := r.db.QueryRow(query, c.TenderID, c.BidID, c.EmployeeID, c.OrganizationID, c.Action, c.Reason)
+	return row.Scan(&c.ID, &c.CreatedAt)
+}
+
+func (r *ConflictRepository) GetTenderConflicts(tenderID uuid.UUID, limit, offset int) ([]model.ConflictRecord, error) {
+	query := `
+SELECT
+	id,
+	tender_id,
+	bid_id,
+	employee_id,
+	organization_id,
+	action,
+	reason,
+	created_at
+FROM conflict_of_interest
+WHERE tender_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3
+`
+	rows, err := r.db.Query(query, tenderID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []model.ConflictRecord
+	for rows.Next() {
+		var c model.ConflictRecord
+		err := rows.Scan(&c.ID, &c.TenderID, &c.BidID, &c.EmployeeID,
+			&c.OrganizationID, &c.Action, &c.Reason, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, nil
+}
+
+func (r *ConflictRepository) InsertRecusal(rec *model.Recusal) error {
+	query := `
+INSERT INTO tender_recusal
+	(tender_id, employee_id, reason)
+VALUES ($1, $2, $3)
+ON CONFLICT (tender_id, employee_id) DO UPDATE
+SET reason = EXCLUDED.reason
+RETURNING
+	created_at
+`
+	row := r.db.QueryRow(query, rec.TenderID, rec.EmployeeID, rec.Reason)
+	return row.Scan(&rec.CreatedAt)
+}
+
+func (r *ConflictRepository) GetIfEmployeeRecused(tenderID, employeeID uuid.UUID) (bool, error) {
+	query := `
+SELECT 1
+FROM tender_recusal
+WHERE
+	tender_id = $1
+	AND employee_id = $2
+`
+	var one int
+	err := r.db.QueryRow(query, tenderID, employeeID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *ConflictRepository) GetTenderRecusals(tenderID uuid.UUID) ([]model.Recusal, error) {
+	query := `
+SELECT
+	tender_id,
+	employee_id,
+	COALESCE(reason, ''),
+	created_at
+FROM tender_recusal
+WHERE tender_id = $1
+ORDER BY created_at ASC
+`
+	rows, err := r.db.Query(query, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recusals []model.Recusal
+	for rows.Next() {
+		var rec model.Recusal
+		err := rows.Scan(&rec.TenderID, &rec.EmployeeID, &rec.Reason, &rec.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		recusals = append(recusals, rec)
+	}
+	return recusals, nil
+}
+
+func (r *ConflictRepository) TxCountRecusals(tx *sql.Tx, tenderID uuid.UUID) (int, error) {
+	query := `
+SELECT COUNT(1)
+FROM tender_recusal
+WHERE tender_id = $1
+`
+	var count int
+	err := tx.QueryRow(query, tenderID).Scan(&count)
+	return count, err
+}
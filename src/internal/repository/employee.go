@@ -67,6 +67,7 @@ WHERE id = $1
 	if err != nil {
 		return false, err
 	}
+	defer x.Close()
 	return x.Next(), nil
 }
 
@@ -90,3 +91,75 @@ WHERE user_id = $1
 	}
 	return &organizationID, nil
 }
+
+func (r *EmployeeRepository) GetEmployees() ([]model.Employee, error) {
+	query := `
+SELECT
+	id,
+	username,
+	COALESCE(first_name, ''),
+	COALESCE(last_name, ''),
+	created_at,
+	updated_at
+FROM employee
+ORDER BY username`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var employees []model.Employee
+	for rows.Next() {
+		var employee model.Employee
+		err := rows.Scan(&employee.ID, &employee.Username, &employee.FirstName,
+			&employee.LastName, &employee.CreatedAt, &employee.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		employees = append(employees, employee)
+	}
+	return employees, rows.Err()
+}
+
+// TxUpsertEmployee inserts the employee or updates the names of the one
+// with the same username.
+func (r *EmployeeRepository) TxUpsertEmployee(tx *sql.Tx, e *model.Employee) error {
+	query := `
+INSERT INTO employee
+	(username, first_name, last_name)
+VALUES ($1, $2, $3)
+ON CONFLICT (username) DO UPDATE
+SET
+	first_name = EXCLUDED.first_name,
+	last_name = EXCLUDED.last_name,
+	updated_at = CURRENT_TIMESTAMP
+RETURNING
+	id,
+	created_at,
+	updated_at
+`
+	row := tx.QueryRow(query, e.Username, e.FirstName, e.LastName)
+	return row.Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+}
+
+func (r *EmployeeRepository) DeleteEmployee(username string) error {
+	query := `
+DELETE FROM employee
+WHERE username = $1
+`
+	res, err := r.db.Exec(query, username)
+	if err != nil {
+		return err
+	}
+	if aff, err := res.RowsAffected(); err != nil {
+		return err
+	} else if aff == 0 {
+		return ErrNoEmployee
+	}
+	return nil
+}
+
+func (r *EmployeeRepository) WithTransaction(fn func(tx *sql.Tx) error) error {
+	return db.RunInTx(r.db, fn)
+}
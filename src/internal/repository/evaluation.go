@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type EvaluationRepository struct {
+	db *sql.DB
+}
+
+func NewEvaluationRepository() *EvaluationRepository {
+	db := db.DB
+	return &EvaluationRepository{
+		db: db,
+	}
+}
+
+func (r *EvaluationRepository) TxInsertCriteria(tx *sql.Tx, tenderID uuid.UUID, criteria []model.Criterion) error {
+	query := `
+INSERT INTO tender_criterion
+	(tender_id, name, weight)
+VALUES ($1, $2, $3)
+RETURNING
+	id
+`
+	for i := range criteria {
+		row := tx.QueryRow(query, tenderID, criteria[i].Name, criteria[i].Weight)
+		if err := row.Scan(&criteria[i].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *EvaluationRepository) GetCriteriaByTender(tenderID uuid.UUID) ([]model.Criterion, error) {
+	query := `
+SELECT
+	id,
+	name,
+	weight
+FROM tender_criterion
+WHERE tender_id = $1
+ORDER BY weight DESC, name ASC
+`
+	rows, err := r.db.Query(query, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var criteria []model.Criterion
+	for rows.Next() {
+		var c model.Criterion
+		if err := rows.Scan(&c.ID, &c.Name, &c.Weight); err != nil {
+			return nil, err
+		}
+		criteria = append(criteria, c)
+	}
+	return criteria, nil
+}
+
+func (r *EvaluationRepository) TxUpsertScore(tx *sql.Tx, bidID, evaluatorID uuid.UUID, score *model.BidScore) error {
+	query := `
+INSERT INTO bid_score
+	(bid_id, criterion_id, evaluator_id, score)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (bid_id, criterion_id, evaluator_id) DO UPDATE
+SET
+	score = EXCLUDED.score,
+	updated_at = CURRENT_TIMESTAMP
+`
+	_, err := tx.Exec(query, bidID, score.CriterionID, evaluatorID, score.Score)
+	return err
+}
+
+// GetTenderEvaluation returns a row per published bid of the tender and
+// criterion, with the score averaged over all evaluators.
+// Criteria nobody has scored yet come with a nil average.
+func (r *EvaluationRepository) GetTenderEvaluation(tenderID uuid.UUID) ([]model.BidEvaluation, error) {
+	query := `
+WITH avg_score AS (
+	SELECT
+		s.bid_id,
+		s.criterion_id,
+		AVG(s.score) AS score,
+		COUNT(1) AS evaluators
+	FROM bid_score s
+		JOIN bid b
+			ON b.id = s.bid_id
+	WHERE b.tender_id = $1
+	GROUP BY s.bid_id, s.criterion_id
+)
+SELECT
+	b.id,
+	bi.name,
+	c.id,
+	c.name,
+	c.weight,
+	a.score,
+	COALESCE(a.evaluators, 0)
+FROM bid b
+	JOIN bid_information bi
+		ON bi.id = b.id
+		AND bi.version = b.current_version
+	JOIN tender_criterion c
+		ON c.tender_id = b.tender_id
+	LEFT JOIN avg_score a
+		ON a.bid_id = b.id AND a.criterion_id = c.id
+WHERE
+	b.tender_id = $1
+	AND b.status = 'Published'
+ORDER BY b.id, c.weight DESC, c.name ASC
+`
+	rows, err := r.db.Query(query, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var evaluations []model.BidEvaluation
+	for rows.Next() {
+		var (
+			bidID   uuid.UUID
+			bidName string
+			cs      model.CriterionScore
+		)
+		err := rows.Scan(&bidID, &bidName, &cs.CriterionID, &cs.Name,
+			&cs.Weight, &cs.AverageScore, &cs.Evaluators)
+		if err != nil {
+			return nil, err
+		}
+		if len(evaluations) == 0 || evaluations[len(evaluations)-1].BidID != bidID {
+			evaluations = append(evaluations, model.BidEvaluation{
+				BidID:   bidID,
+				BidName: bidName,
+			})
+		}
+		last := &evaluations[len(evaluations)-1]
+		last.Criteria = append(last.Criteria, cs)
+	}
+	return evaluations, nil
+}
+
+func (r *EvaluationRepository) WithTransaction(fn func(tx *sql.Tx) error) error {
+	return db.RunInTx(r.db, fn)
+}
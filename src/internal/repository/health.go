@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"context"
+	"database/sql"
+)
+
+type HealthRepository struct {
+	db *sql.DB
+}
+
+func NewHealthRepository() *HealthRepository {
+	db := db.DB
+	return &HealthRepository{
+		db: db,
+	}
+}
+
+func (r *HealthRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// GetSchemaVersion reads the version recorded by golang-migrate.
+func (r *HealthRepository) GetSchemaVersion(ctx context.Context) (uint, bool, error) {
+	query := `
+SELECT version, dirty
+FROM schema_migrations
+LIMIT 1
+`
+	var (
+		version uint
+		dirty   bool
+	)
+	err := r.db.QueryRowContext(ctx, query).Scan(&version, &dirty)
+	if err != nil {
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+func (r *HealthRepository) PoolStats() sql.DBStats {
+	return r.db.Stats()
+}
+
+// CheckReplicas reports the last health checks of the read replicas,
+// they run in the background, see db.CheckReplicas.
+func (r *HealthRepository) CheckReplicas() []model.ReplicaCheck {
+	var checks []model.ReplicaCheck
+	for _, state := range db.ReplicaStates() {
+		check := model.ReplicaCheck{Name: state.Name, Status: model.HealthStatusOk}
+		if !state.Healthy {
+			check.Status = model.HealthStatusFail
+		}
+		if state.Err != nil {
+			check.Error = state.Err.Error()
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrNoIdempotencyKey = errors.New("idempotency key not found")
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository() *IdempotencyRepository {
+	db := db.DB
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+// ClaimKey takes the key of the caller for the request with the fingerprint,
+// it returns false if the key is already taken. An expired key, or a key of
+// a request in progress for longer than lockTimeout, is taken over.
+func (r *IdempotencyRepository) ClaimKey(caller, key, fingerprint string, ttl, lockTimeout time.Duration) (bool, error) {
+	deleteQuery := db.Pick(`
+DELETE FROM idempotency_key
+WHERE
+	caller = $1
+	AND request_key = $2
+	AND (created_at < CURRENT_TIMESTAMP - make_interval(secs => $3)
+		OR (status_code IS NULL AND created_at < CURRENT_TIMESTAMP - make_interval(secs => $4)))
+`, `
+DELETE FROM idempotency_key
+WHERE
+	caller = $1
+	AND request_key = $2
+	AND (julianday(created_at) < julianday('now') - $3 / 86400.0
+		OR (status_code IS NULL AND julianday(created_at) < julianday('now') - $4 / 86400.0))
+`)
+	insertQuery := `
+INSERT INTO idempotency_key
+	(caller, request_key, fingerprint)
+VALUES ($1, $2, $3)
+ON CONFLICT (caller, request_key) DO NOTHING
+`
+	_, err := r.db.Exec(deleteQuery, caller, key, ttl.Seconds(), lockTimeout.Seconds())
+	if err != nil {
+		return false, err
+	}
+	res, err := r.db.Exec(insertQuery, caller, key, fingerprint)
+	if err != nil {
+		return false, err
+	}
+	aff, err := res.RowsAffected()
+	return aff == 1, err
+}
+
+func (r *IdempotencyRepository) GetKey(caller, key string) (*model.IdempotencyRecord, error) {
+	query := `
+SELECT
+	fingerprint,
+	status_code,
+	content_type,
+	body
+FROM idempotency_key
+WHERE caller = $1 AND request_key = $2
+`
+	var (
+		record      model.IdempotencyRecord
+		statusCode  sql.NullInt64
+		contentType string
+		body        []byte
+	)
+	err := r.db.QueryRow(query, caller, key).Scan(&record.Fingerprint, &statusCode, &contentType, &body)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoIdempotencyKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if statusCode.Valid {
+		record.Response = &model.IdempotentResponse{
+			StatusCode:  int(statusCode.Int64),
+			ContentType: contentType,
+			Body:        body,
+		}
+	}
+	return &record, nil
+}
+
+// CompleteKey keeps the response of the request holding the key.
+func (r *IdempotencyRepository) CompleteKey(caller, key string, response *model.IdempotentResponse) error {
+	query := `
+UPDATE idempotency_key
+SET
+	status_code = $3,
+	content_type = $4,
+	body = $5
+WHERE caller = $1 AND request_key = $2
+`
+	_, err := r.db.Exec(query, caller, key, response.StatusCode, response.ContentType, response.Body)
+	return err
+}
+
+// ReleaseKey frees the key of a request that failed, so that it can be retried.
+func (r *IdempotencyRepository) ReleaseKey(caller, key string) error {
+	query := `
+DELETE FROM idempotency_key
+WHERE caller = $1 AND request_key = $2
+`
+	_, err := r.db.Exec(query, caller, key)
+	return err
+}
+
+// DeleteExpiredKeys drops the keys older than the TTL.
+func (r *IdempotencyRepository) DeleteExpiredKeys(ttl time.Duration) error {
+	query := db.Pick(`
+DELETE FROM idempotency_key
+WHERE created_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+`, `
+DELETE FROM idempotency_key
+WHERE julianday(created_at) < julianday('now') - $1 / 86400.0
+`)
+	_, err := r.db.Exec(query, ttl.Seconds())
+	return err
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type IntegrityRepository struct {
+	db *sql.DB
+}
+
+func NewIntegrityRepository() *IntegrityRepository {
+	db := db.DB
+	return &IntegrityRepository{
+		db: db,
+	}
+}
+
+// integrityQueries select the id and a readable detail of every anomaly.
+var integrityQueries = []struct {
+	kind  model.IntegrityIssueKind
+	query string
+}{
+	{model.IssueTenderWithoutInformation, `
+SELECT t.id, 'tender has no information rows'
+FROM tender t
+WHERE NOT EXISTS (
+	SELECT 1
+	FROM tender_information ti
+	WHERE ti.id = t.id
+)
+ORDER BY t.id
+`},
+	{model.IssueBidWithoutTender, `
+SELECT b.id, 'tender ' || COALESCE(CAST(b.tender_id AS TEXT), 'NULL') || ' does not exist'
+FROM bid b
+	LEFT JOIN tender t
+		ON t.id = b.tender_id
+WHERE t.id IS NULL
+ORDER BY b.id
+`},
+	{model.IssueBidWithoutInformation, `
+SELECT b.id, 'bid has no information rows'
+FROM bid b
+WHERE NOT EXISTS (
+	SELECT 1
+	FROM bid_information bi
+	WHERE bi.id = b.id
+)
+ORDER BY b.id
+`},
+	{model.IssueTenderVersionGap, `
+SELECT id, 'versions ' || string_agg(CAST(version AS TEXT), ',' ORDER BY version)
+FROM tender_information
+GROUP BY id
+HAVING MIN(version) <> 1 OR MAX(version) <> COUNT(1)
+ORDER BY id
+`},
+	{model.IssueBidVersionGap, `
+SELECT id, 'versions ' || string_agg(CAST(version AS TEXT), ',' ORDER BY version)
+FROM bid_information
+GROUP BY id
+HAVING MIN(version) <> 1 OR MAX(version) <> COUNT(1)
+ORDER BY id
+`},
+	{model.IssueTenderCurrentVersion, `
+SELECT t.id, 'current version ' || COALESCE(CAST(t.current_version AS TEXT), 'NULL') || ', latest ' || CAST(MAX(ti.version) AS TEXT)
+FROM tender t
+	JOIN tender_information ti
+		ON ti.id = t.id
+GROUP BY t.id, t.current_version
+HAVING t.current_version IS NULL OR t.current_version <> MAX(ti.version)
+ORDER BY t.id
+`},
+	{model.IssueBidCurrentVersion, `
+SELECT b.id, 'current version ' || COALESCE(CAST(b.current_version AS TEXT), 'NULL') || ', latest ' || CAST(MAX(bi.version) AS TEXT)
+FROM bid b
+	JOIN bid_information bi
+		ON bi.id = b.id
+GROUP BY b.id, b.current_version
+HAVING b.current_version IS NULL OR b.current_version <> MAX(bi.version)
+ORDER BY b.id
+`},
+	// the winner of a decision, a lot or an auction stays published
+	{model.IssuePublishedBidOnClosedTender, `
+SELECT b.id, 'tender ' || CAST(t.id AS TEXT) || ' is closed'
+FROM bid b
+	JOIN tender t
+		ON t.id = b.tender_id
+WHERE
+	b.status = 'Published'
+	AND t.status = 'Closed'
+	AND NOT EXISTS (
+		SELECT 1
+		FROM tender_lot l
+		WHERE l.winner_bid_id = b.id
+	)
+	AND NOT EXISTS (
+		SELECT 1
+		FROM tender_auction a
+		WHERE a.winner_bid_id = b.id
+	)
+	AND NOT (
+		EXISTS (
+			SELECT 1
+			FROM bid_decision d
+			WHERE d.bid_id = b.id AND d.decision = 'Approved'
+		)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM bid_decision d
+			WHERE d.bid_id = b.id AND d.decision = 'Rejected'
+		)
+	)
+ORDER BY b.id
+`},
+}
+
+func (r *IntegrityRepository) TxGetIssues(tx *sql.Tx) ([]model.IntegrityIssue, error) {
+	issues := []model.IntegrityIssue{}
+	for _, q := range integrityQueries {
+		rows, err := tx.Query(q.query)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			issue := model.IntegrityIssue{Kind: q.kind}
+			if err := rows.Scan(&issue.ID, &issue.Detail); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			issues = append(issues, issue)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return issues, nil
+}
+
+// TxDeleteTenders deletes the tenders along with their bids, lots and auctions.
+func (r *IntegrityRepository) TxDeleteTenders(tx *sql.Tx, ids []uuid.UUID) error {
+	query := `
+DELETE FROM tender
+WHERE ` + db.InArray("id", "$1") + `
+`
+	_, err := tx.Exec(query, db.Array(ids))
+	return err
+}
+
+func (r *IntegrityRepository) TxDeleteBids(tx *sql.Tx, ids []uuid.UUID) error {
+	query := `
+DELETE FROM bid
+WHERE ` + db.InArray("id", "$1") + `
+`
+	_, err := tx.Exec(query, db.Array(ids))
+	return err
+}
+
+func (r *IntegrityRepository) TxCancelBids(tx *sql.Tx, ids []uuid.UUID) error {
+	query := `
+UPDATE bid
+SET status = 'Canceled'
+WHERE ` + db.InArray("id", "$1") + `
+`
+	_, err := tx.Exec(query, db.Array(ids))
+	return err
+}
+
+// TxRenumberTenderVersions numbers the versions of the tenders 1..n in their order.
+func (r *IntegrityRepository) TxRenumberTenderVersions(tx *sql.Tx, ids []uuid.UUID) error {
+	return txRenumberVersions(tx, "tender", ids)
+}
+
+func (r *IntegrityRepository) TxRenumberBidVersions(tx *sql.Tx, ids []uuid.UUID) error {
+	return txRenumberVersions(tx, "bid", ids)
+}
+
+// txRenumberVersions moves the versions out of the way first,
+// since the primary key is checked row by row. The current versions
+// of the tenders or the bids, the table, follow the renumbering.
+func txRenumberVersions(tx *sql.Tx, table string, ids []uuid.UUID) error {
+	infoTable := table + "_information"
+	shiftQuery := `
+UPDATE ` + infoTable + `
+SET version = version + 1000000
+WHERE ` + db.InArray("id", "$1") + `
+`
+	renumberQuery := `
+UPDATE ` + infoTable + ` info
+SET version = numbered.version
+FROM (
+	SELECT
+		id,
+		version AS old_version,
+		ROW_NUMBER() OVER (PARTITION BY id ORDER BY version) AS version
+	FROM ` + infoTable + `
+	WHERE ` + db.InArray("id", "$1") + `
+) numbered
+WHERE
+	info.id = numbered.id
+	AND info.version = numbered.old_version
+`
+	if _, err := tx.Exec(shiftQuery, db.Array(ids)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(renumberQuery, db.Array(ids)); err != nil {
+		return err
+	}
+	return txSyncCurrentVersions(tx, table, ids)
+}
+
+// TxSyncTenderCurrentVersions points the tenders at their latest versions.
+func (r *IntegrityRepository) TxSyncTenderCurrentVersions(tx *sql.Tx, ids []uuid.UUID) error {
+	return txSyncCurrentVersions(tx, "tender", ids)
+}
+
+func (r *IntegrityRepository) TxSyncBidCurrentVersions(tx *sql.Tx, ids []uuid.UUID) error {
+	return txSyncCurrentVersions(tx, "bid", ids)
+}
+
+func txSyncCurrentVersions(tx *sql.Tx, table string, ids []uuid.UUID) error {
+	query := `
+UPDATE ` + table + `
+SET current_version = (
+	SELECT MAX(version)
+	FROM ` + table + `_information info
+	WHERE info.id = ` + table + `.id
+)
+WHERE ` + db.InArray("id", "$1") + `
+`
+	_, err := tx.Exec(query, db.Array(ids))
+	return err
+}
+
+func (r *IntegrityRepository) WithTransaction(fn func(tx *sql.Tx) error) error {
+	return db.RunInTx(r.db, fn)
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoInvitation = errors.New("invitation not found")
+)
+
+type InvitationRepository struct {
+	db *sql.DB
+}
+
+func NewInvitationRepository() *InvitationRepository {
+	db := db.DB
+	return &InvitationRepository{
+		db: db,
+	}
+}
+
+func (r *InvitationRepository) InsertInvitation(inv *model.TenderInvitation) error {
+	query := `
+INSERT INTO tender_invitation
+	(tender_id, organization_id)
+VALUES ($1, $2)
+ON CONFLICT (tender_id, organization_id) DO UPDATE
+SET tender_id = EXCLUDED.tender_id
+RETURNING
+	created_at
+`
+	row := r.db.QueryRow(query, inv.TenderID, inv.OrganizationID)
+	return row.Scan(&inv.CreatedAt)
+}
+
+func (r *InvitationRepository) DeleteInvitation(tenderID, organizationID uuid.UUID) error {
+	query := `
+DELETE FROM tender_invitation
+WHERE
+	tender_id = $1
+	AND organization_id = $2
+`
+	res, err := r.db.Exec(query, tenderID, organizationID)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return ErrNoInvitation
+	}
+	return nil
+}
+
+func (r *InvitationRepository) GetTenderInvitations(tenderID uuid.UUID, limit, offset int) ([]model.TenderInvitation, error) {
+	query := `
+SELECT
+	tender_id,
+	organization_id,
+	created_at
+FROM tender_invitation
+WHERE tender_id = $1
+ORDER BY created_at ASC
+LIMIT $2
+OFFSET $3
+`
+	rows, err := r.db.Query(query, tenderID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []model.TenderInvitation
+	for rows.Next() {
+		var inv model.TenderInvitation
+		if err := rows.Scan(&inv.TenderID, &inv.OrganizationID, &inv.CreatedAt); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, nil
+}
+
+func (r *InvitationRepository) GetIfOrganizationIsInvited(tenderID, organizationID uuid.UUID) (bool, error) {
+	query := `
+SELECT 1
+FROM tender_invitation
+WHERE
+	tender_id = $1
+	AND organization_id = $2
+`
+	var one int
+	err := r.db.QueryRow(query, tenderID, organizationID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetIfEmployeeIsInvited reports whether any organization the employee
+// is responsible for is invited to the tender.
+func (r *InvitationRepository) GetIfEmployeeIsInvited(tenderID, employeeID uuid.UUID) (bool, error) {
+	query := `
+SELECT 1
+FROM tender_invitation inv
+	JOIN organization_responsible orr
+		ON orr.organization_id = inv.organization_id
+WHERE
+	inv.tender_id = $1
+	AND orr.user_id = $2
+LIMIT 1
+`
+	var one int
+	err := r.db.QueryRow(query, tenderID, employeeID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoLot = errors.New("lot not found")
+)
+
+type LotRepository struct {
+	db *sql.DB
+}
+
+func NewLotRepository() *LotRepository {
+	db := db.DB
+	return &LotRepository{
+		db: db,
+	}
+}
+
+func (r *LotRepository) InsertNewLot(l *model.Lot) error {
+	query := `
+INSERT INTO tender_lot
+	(tender_id, name, description, service_type)
+VALUES ($1, $2, $3, $4)
+RETURNING
+	id,
+	status,
+	created_at
+`
+	row := r.db.QueryRow(query, l.TenderID, l.Name, l.Description, l.ServiceType)
+	return row.Scan(&l.ID, &l.Status, &l.CreatedAt)
+}
+
+func (r *LotRepository) GetLotByID(lotID uuid.UUID) (*model.Lot, error) {
+	query := `
+SELECT
+	id,
+	tender_id,
+	name,
+	description,
+	service_type,
+	status,
+	winner_bid_id,
+	created_at
+FROM tender_lot
+WHERE id = $1
+`
+	var l model.Lot
+
+	row := r.db.QueryRow(query, lotID)
+	err := row.Scan(&l.ID, &l.TenderID, &l.Name, &l.Description, &l.ServiceType,
+		&l.Status, &l.WinnerBidID, &l.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoLot
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *LotRepository) GetLotsByTender(tenderID uuid.UUID) ([]model.Lot, error) {
+	query := `
+SELECT
+	id,
+	tender_id,
+	name,
+	description,
+	service_type,
+	status,
+	winner_bid_id,
+	created_at
+FROM tender_lot
+WHERE tender_id = $1
+ORDER BY created_at ASC
+`
+	rows, err := r.db.Query(query, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lots []model.Lot
+	for rows.Next() {
+		var l model.Lot
+		err := rows.Scan(&l.ID, &l.TenderID, &l.Name, &l.Description, &l.ServiceType,
+			&l.Status, &l.WinnerBidID, &l.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	return lots, nil
+}
+
+func (r *LotRepository) GetTenderHasLots(tenderID uuid.UUID) (bool, error) {
+	query := `
+SELECT 1
+FROM tender_lot
+WHERE tender_id = $1
+LIMIT 1
+`
+	var one int
+	err := r.db.QueryRow(query, tenderID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetTenderHasLotlessBids reports whether the tender has bids on the whole tender
+// rather than on one of its lots.
+func (r *LotRepository) GetTenderHasLotlessBids(tenderID uuid.UUID) (bool, error) {
+	query := `
+SELECT 1
+FROM bid
+WHERE
+	tender_id = $1
+	AND lot_id IS NULL
+LIMIT 1
+`
+	var one int
+	err := r.db.QueryRow(query, tenderID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *LotRepository) TxDecideLot(tx *sql.Tx, lotID, winnerBidID uuid.UUID) error {
+	query := `
+UPDATE tender_lot
+SET
+	status = 'Decided',
+	winner_bid_id = $2
+WHERE
+	id = $1
+	AND status = 'Open'
+`
+	res, err := tx.Exec(query, lotID, winnerBidID)
+	if err != nil {
+		return err
+	}
+	var aff int64
+	if aff, err = res.RowsAffected(); aff == 0 {
+		return ErrNoLot
+	}
+	return err
+}
+
+func (r *LotRepository) TxCountOpenLots(tx *sql.Tx, tenderID uuid.UUID) (int, error) {
+	query := `
+SELECT COUNT(1)
+FROM tender_lot
+WHERE
+	tender_id = $1
+	AND status = 'Open'
+`
+	var count int
+	err := tx.QueryRow(query, tenderID).Scan(&count)
+	return count, err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
 	"database/sql"
 	"errors"
 	"github.com/google/uuid"
@@ -32,8 +33,30 @@ WHERE id = $1
 	if err != nil {
 		return false, err
 	}
+	defer x.Close()
 	if !x.Next() {
 		return false, nil
 	}
 	return true, nil
 }
+
+// TxUpsertOrganization inserts the organization with its id or updates
+// the existing one, so the fixtures can be loaded again.
+func (r *OrganizationRepository) TxUpsertOrganization(tx *sql.Tx, o *model.Organization) error {
+	query := `
+INSERT INTO organization
+	(id, name, description, type)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (id) DO UPDATE
+SET
+	name = EXCLUDED.name,
+	description = EXCLUDED.description,
+	type = EXCLUDED.type,
+	updated_at = CURRENT_TIMESTAMP
+RETURNING
+	created_at,
+	updated_at
+`
+	row := tx.QueryRow(query, o.ID, o.Name, o.Description, o.Type)
+	return row.Scan(&o.CreatedAt, &o.UpdatedAt)
+}
@@ -60,3 +60,41 @@ FROM organization_responsible ores
 	}
 	return count, nil
 }
+
+// TxInsertResponsible makes the employee responsible for the organization,
+// doing nothing if it already is.
+func (r *OrganizationResponsibleRepository) TxInsertResponsible(tx *sql.Tx, organizationID, employeeID uuid.UUID) error {
+	query := `
+INSERT INTO organization_responsible
+	(organization_id, user_id)
+SELECT $1, $2
+WHERE NOT EXISTS (
+	SELECT 1
+	FROM organization_responsible
+	WHERE
+		organization_id = $1
+		AND user_id = $2
+)
+`
+	_, err := tx.Exec(query, organizationID, employeeID)
+	return err
+}
+
+func (r *OrganizationResponsibleRepository) DeleteResponsible(organizationID, employeeID uuid.UUID) (bool, error) {
+	query := `
+DELETE FROM organization_responsible
+WHERE
+	organization_id = $1
+	AND user_id = $2
+`
+	res, err := r.db.Exec(query, organizationID, employeeID)
+	if err != nil {
+		return false, err
+	}
+	aff, err := res.RowsAffected()
+	return aff > 0, err
+}
+
+func (r *OrganizationResponsibleRepository) WithTransaction(fn func(tx *sql.Tx) error) error {
+	return db.RunInTx(r.db, fn)
+}
@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/model"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoQualification = errors.New("qualification not found")
+)
+
+type QualificationRepository struct {
+	db *sql.DB
+}
+
+func NewQualificationRepository() *QualificationRepository {
+	db := db.DB
+	return &QualificationRepository{
+		db: db,
+	}
+}
+
+// TxReplaceQuestionnaire drops the current questionnaire of the tender
+// and stores the items in the given order.
+func (r *QualificationRepository) TxReplaceQuestionnaire(tx *sql.Tx, tenderID uuid.UUID, items []model.QuestionnaireItem) error {
+	deleteQuery := `
+DELETE FROM questionnaire_item
+WHERE tender_id = $1
+`
+	insertQuery := `
+INSERT INTO questionnaire_item
+	(tender_id, question, required, requires_document, position)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING
+	id
+`
+	if _, err := tx.Exec(deleteQuery, tenderID); err != nil {
+		return err
+	}
+	for i := range items {
+		row := tx.QueryRow(insertQuery, tenderID, items[i].Question, items[i].Required,
+			items[i].RequiresDocument, i+1)
+		if err := row.Scan(&items[i].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *QualificationRepository) GetQuestionnaire(tenderID uuid.UUID) ([]model.QuestionnaireItem, error) {
+	query := `
+SELECT
+	id,
+	question,
+	required,
+	requires_document
+FROM questionnaire_item
+WHERE tender_id = $1
+ORDER BY position ASC
+`
+	rows, err := r.db.Query(query, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.QuestionnaireItem
+	for rows.Next() {
+		var item model.QuestionnaireItem
+		err := rows.Scan(&item.ID, &item.Question, &item.Required, &item.RequiresDocument)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *QualificationRepository) GetTenderHasQuestionnaire(tenderID uuid.UUID) (bool, error) {
+	query := `
+SELECT 1
+FROM questionnaire_item
+WHERE tender_id = $1
+LIMIT 1
+`
+	var one int
+	err := r.db.QueryRow(query, tenderID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// TxGetQuestionnaireInUse reports whether the organizations already answered
+// the questionnaire of the tender or bid on it under its terms.
+func (r *QualificationRepository) TxGetQuestionnaireInUse(tx *sql.Tx, tenderID uuid.UUID) (bool, error) {
+	query := `
+SELECT
+	EXISTS (SELECT 1 FROM qualification WHERE tender_id = $1)
+	OR EXISTS (SELECT 1 FROM bid WHERE tender_id = $1)
+`
+	var inUse bool
+	err := tx.QueryRow(query, tenderID).Scan(&inUse)
+	return inUse, err
+}
+
+// TxUpsertQualification stores the submission of the organization,
+// a resubmission replaces the previous answers and resets the review.
+func (r *QualificationRepository) TxUpsertQualification(tx *sql.Tx, q *model.Qualification, submittedBy uuid.UUID) error {
+	qualificationQuery := `
+INSERT INTO qualification
+	(tender_id, organization_id, submitted_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (tender_id, organization_id) DO UPDATE
+SET
+	status = 'Pending',
+	submitted_by = EXCLUDED.submitted_by,
+	reviewed_by = NULL,
+	review_comment = NULL,
+	created_at = CURRENT_TIMESTAMP,
+	reviewed_at = NULL
+RETURNING
+	id,
+	status,
+	review_comment,
+	created_at,
+	reviewed_at
+`
+	deleteAnswersQuery := `
+DELETE FROM qualification_answer
+WHERE qualification_id = $1
+`
+	answerQuery := `
+INSERT INTO qualification_answer
+	(qualification_id, item_id, answer, document_name, document_content_type, document_content)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+	row := tx.QueryRow(qualificationQuery, q.TenderID, q.OrganizationID, submittedBy)
+	err := row.Scan(&q.ID, &q.Status, &q.ReviewComment, &q.CreatedAt, &q.ReviewedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(deleteAnswersQuery, q.ID); err != nil {
+		return err
+	}
+	for _, a := range q.Answers {
+		var (
+			name, contentType *string
+			content           []byte
+		)
+		if a.Document != nil {
+			name, contentType, content = &a.Document.Name, &a.Document.ContentType, a.Document.Content
+		}
+		_, err := tx.Exec(answerQuery, q.ID, a.ItemID, a.Answer, name, contentType, content)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *QualificationRepository) GetQualificationByID(qualificationID uuid.UUID) (*model.Qualification, error) {
+	query := `
+SELECT
+	id,
+	tender_id,
+	organization_id,
+	status,
+	review_comment,
+	created_at,
+	reviewed_at
+FROM qualification
+WHERE id = $1
+`
+	answersQuery := `
+SELECT
+	item_id,
+	COALESCE(answer, ''),
+	document_name,
+	document_content_type,
+	document_content
+FROM qualification_answer a
+	JOIN questionnaire_item i
+		ON i.id = a.item_id
+WHERE a.qualification_id = $1
+ORDER BY i.position ASC
+`
+	var q model.Qualification
+
+	row := r.db.QueryRow(query, qualificationID)
+	err := row.Scan(&q.ID, &q.TenderID, &q.OrganizationID, &q.Status,
+		&q.ReviewComment, &q.CreatedAt, &q.ReviewedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoQualification
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(answersQuery, qualificationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			a                 model.QualificationAnswer
+			name, contentType *string
+			content           []byte
+		)
+		err := rows.Scan(&a.ItemID, &a.Answer, &name, &contentType, &content)
+		if err != nil {
+			return nil, err
+		}
+		if name != nil {
+			a.Document = &model.QualificationDocument{
+				Name:    *name,
+				Content: content,
+			}
+			if contentType != nil {
+				a.Document.ContentType = *contentType
+			}
+		}
+		q.Answers = append(q.Answers, a)
+	}
+	return &q, nil
+}
+
+// GetTenderQualifications lists the submissions of the tender without their answers.
+// If status is not nil, only the submissions with that status are returned.
+func (r *QualificationRepository) GetTenderQualifications(tenderID uuid.UUID, status *string,
+	limit, offset int) ([]model.Qualification, error) {
+
+	query := `
+SELECT
+	id,
+	tender_id,
+	organization_id,
+	status,
+	review_comment,
+	created_at,
+	reviewed_at
+FROM qualification
+WHERE
+	tender_id = $1
+	AND (CAST($2 AS qualification_status) IS NULL OR status = $2)
+ORDER BY created_at ASC
+LIMIT $3
+OFFSET $4
+`
+	rows, err := r.db.Query(query, tenderID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var qualifications []model.Qualification
+	for rows.Next() {
+		var q model.Qualification
+		err := rows.Scan(&q.ID, &q.TenderID, &q.OrganizationID, &q.Status,
+			&q.ReviewComment, &q.CreatedAt, &q.ReviewedAt)
+		if err != nil {
+			return nil, err
+		}
+		qualifications = append(qualifications, q)
+	}
+	return qualifications, nil
+}
+
+func (r *QualificationRepository) ReviewQualification(qualificationID, reviewerID uuid.UUID,
+	status string, comment *string) (*model.Qualification, error) {
+
+	query := `
+UPDATE qualification
+SET
+	status = $2,
+	reviewed_by = $3,
+	review_comment = $4,
+	reviewed_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+	res, err := r.db.Exec(query, qualificationID, status, reviewerID, comment)
+	if err != nil {
+		return nil, err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return nil, ErrNoQualification
+	}
+	return r.GetQualificationByID(qualificationID)
+}
+
+func (r *QualificationRepository) GetIfOrganizationIsQualified(tenderID, organizationID uuid.UUID) (bool, error) {
+	query := `
+SELECT 1
+FROM qualification
+WHERE
+	tender_id = $1
+	AND organization_id = $2
+	AND status = 'Approved'
+`
+	var one int
+	err := r.db.QueryRow(query, tenderID, organizationID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *QualificationRepository) WithTransaction(fn func(tx *sql.Tx) error) error {
+	return db.RunInTx(r.db, fn)
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"avito-back-test/internal/db"
+	"database/sql"
+	"time"
+)
+
+type RateLimitRepository struct {
+	db *sql.DB
+}
+
+func NewRateLimitRepository() *RateLimitRepository {
+	db := db.DB
+	return &RateLimitRepository{
+		db: db,
+	}
+}
+
+// TxGetBucket locks the token bucket of the key, creating a full one if needed,
+// and returns its tokens with the time passed since its last update.
+func (r *RateLimitRepository) TxGetBucket(tx *sql.Tx, key string, capacity float64) (float64, time.Duration, error) {
+	insertQuery := `
+INSERT INTO rate_limit_bucket
+	(key, tokens)
+VALUES ($1, $2)
+ON CONFLICT (key) DO NOTHING
+`
+	// the SQLite transactions hold the write lock from the start
+	selectQuery := db.Pick(`
+SELECT
+	tokens,
+	EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - updated_at))
+FROM rate_limit_bucket
+WHERE key = $1
+FOR UPDATE
+`, `
+SELECT
+	tokens,
+	(julianday('now') - julianday(updated_at)) * 86400
+FROM rate_limit_bucket
+WHERE key = $1
+`)
+	if _, err := tx.Exec(insertQuery, key, capacity); err != nil {
+		return 0, 0, err
+	}
+	var tokens, elapsed float64
+	err := tx.QueryRow(selectQuery, key).Scan(&tokens, &elapsed)
+	if err != nil {
+		return 0, 0, err
+	}
+	return tokens, time.Duration(elapsed * float64(time.Second)), nil
+}
+
+func (r *RateLimitRepository) TxSetBucket(tx *sql.Tx, key string, tokens float64) error {
+	// CURRENT_TIMESTAMP of SQLite has no fractions of a second
+	query := db.Pick(`
+UPDATE rate_limit_bucket
+SET
+	tokens = $2,
+	updated_at = CURRENT_TIMESTAMP
+WHERE key = $1
+`, `
+UPDATE rate_limit_bucket
+SET
+	tokens = $2,
+	updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+WHERE key = $1
+`)
+	_, err := tx.Exec(query, key, tokens)
+	return err
+}
+
+// DeleteIdleBuckets drops the buckets untouched for longer than idle,
+// they would have been full again anyway.
+func (r *RateLimitRepository) DeleteIdleBuckets(idle time.Duration) error {
+	query := db.Pick(`
+DELETE FROM rate_limit_bucket
+WHERE updated_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+`, `
+DELETE FROM rate_limit_bucket
+WHERE julianday(updated_at) < julianday('now') - $1 / 86400.0
+`)
+	_, err := r.db.Exec(query, idle.Seconds())
+	return err
+}
+
+func (r *RateLimitRepository) WithTransaction(fn func(tx *sql.Tx) error) error {
+	return db.RunInTx(r.db, fn)
+}
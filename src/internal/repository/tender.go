@@ -15,6 +15,9 @@ type TenderRepository struct {
 
 var ErrNoTender = errors.New("tender with set id not found")
 
+// PrimaryReader makes a listing read from the primary, see db.PrimaryReader.
+const PrimaryReader = db.PrimaryReader
+
 func NewTenderRepository() *TenderRepository {
 	db := db.DB
 	return &TenderRepository{
@@ -22,7 +25,10 @@ func NewTenderRepository() *TenderRepository {
 	}
 }
 
-func (r *TenderRepository) GetAllPublicTenders(limit, offset int) ([]model.Tender, error) {
+// GetAllPublicTenders lists the published tenders visible to the viewer:
+// public ones and, if viewerID is not nil, those the viewer's organizations are invited to.
+// The listing may read from a replica, reader is the username it reads for, see db.Query.
+func (r *TenderRepository) GetAllPublicTenders(reader string, viewerID *uuid.UUID, limit, offset int) ([]model.Tender, error) {
 	query := `
 SELECT
 	t.id,
@@ -30,24 +36,32 @@ SELECT
 	ti.description,
 	ti.service_type,
 	t.status,
+	t.visibility,
 	t.organization_id,
 	ti.version,
-	t.created_at
+	t.created_at,
+	(SELECT COUNT(*) FROM tender_question q WHERE q.tender_id = t.id AND q.answer IS NOT NULL)
 FROM tender t
 	JOIN tender_information ti
 		ON ti.id = t.id
-	JOIN (
-		SELECT id, MAX(version) as mv
-		FROM tender_information
-		GROUP BY id
-	) latest_ti
-		ON latest_ti.id = ti.id AND ti.version = latest_ti.mv
-WHERE status = 'Published'
+		AND ti.version = t.current_version
+WHERE
+	status = 'Published'
+	AND (
+		visibility = 'Public'
+		OR t.id IN (
+			SELECT inv.tender_id
+			FROM tender_invitation inv
+				JOIN organization_responsible orr
+					ON orr.organization_id = inv.organization_id
+			WHERE orr.user_id = $1
+		)
+	)
 ORDER BY name ASC, version DESC
-LIMIT $1
-OFFSET $2
+LIMIT $2
+OFFSET $3
 `
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := db.Query(r.db, reader, query, viewerID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -57,8 +71,8 @@ OFFSET $2
 	for rows.Next() {
 		var tender model.Tender
 		err := rows.Scan(&tender.ID, &tender.Name, &tender.Description,
-			&tender.ServiceType, &tender.Status, &tender.OrganizationID,
-			&tender.Version, &tender.CreatedAt)
+			&tender.ServiceType, &tender.Status, &tender.Visibility, &tender.OrganizationID,
+			&tender.Version, &tender.CreatedAt, &tender.Questions.Answered)
 		if err != nil {
 			return nil, err
 		}
@@ -67,7 +81,9 @@ OFFSET $2
 	return tenders, nil
 }
 
-func (r *TenderRepository) GetPublicTendersOfService(serviceType string, limit, offset int) ([]model.Tender, error) {
+// GetPublicTendersOfService is GetAllPublicTenders narrowed down to a service category
+// and its subcategories.
+func (r *TenderRepository) GetPublicTendersOfService(reader, serviceType string, viewerID *uuid.UUID, limit, offset int) ([]model.Tender, error) {
 	query := `
 SELECT
 	t.id,
@@ -75,26 +91,45 @@ SELECT
 	ti.description,
 	ti.service_type,
 	t.status,
+	t.visibility,
 	t.organization_id,
 	ti.version,
-	t.created_at
+	t.created_at,
+	(SELECT COUNT(*) FROM tender_question q WHERE q.tender_id = t.id AND q.answer IS NOT NULL)
 FROM tender t
 	JOIN tender_information ti
 		ON ti.id = t.id
-	JOIN (
-		SELECT id, MAX(version) as mv
-		FROM tender_information
-		GROUP BY id
-	) latest_ti
-		ON latest_ti.id = ti.id AND ti.version = latest_ti.mv
+		AND ti.version = t.current_version
 WHERE
 	status = 'Published'
-	AND service_type = $1
+	AND service_type IN (
+		WITH RECURSIVE subtree AS (
+			SELECT code
+			FROM service_category
+			WHERE code = $1
+			UNION
+			SELECT c.code
+			FROM service_category c
+				JOIN subtree s
+					ON c.parent_code = s.code
+		)
+		SELECT code FROM subtree
+	)
+	AND (
+		visibility = 'Public'
+		OR t.id IN (
+			SELECT inv.tender_id
+			FROM tender_invitation inv
+				JOIN organization_responsible orr
+					ON orr.organization_id = inv.organization_id
+			WHERE orr.user_id = $2
+		)
+	)
 ORDER BY name ASC, version DESC
-LIMIT $2
-OFFSET $3
+LIMIT $3
+OFFSET $4
 `
-	rows, err := r.db.Query(query, serviceType, limit, offset)
+	rows, err := db.Query(r.db, reader, query, serviceType, viewerID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -104,8 +139,8 @@ OFFSET $3
 	for rows.Next() {
 		var tender model.Tender
 		err := rows.Scan(&tender.ID, &tender.Name, &tender.Description,
-			&tender.ServiceType, &tender.Status, &tender.OrganizationID,
-			&tender.Version, &tender.CreatedAt)
+			&tender.ServiceType, &tender.Status, &tender.Visibility, &tender.OrganizationID,
+			&tender.Version, &tender.CreatedAt, &tender.Questions.Answered)
 		if err != nil {
 			return nil, err
 		}
@@ -115,13 +150,20 @@ OFFSET $3
 }
 
 func (r *TenderRepository) InsertNewTender(t *model.Tender) error {
+	return db.RunInTx(r.db, func(tx *sql.Tx) error {
+		return r.TxInsertNewTender(tx, t)
+	})
+}
+
+func (r *TenderRepository) TxInsertNewTender(tx *sql.Tx, t *model.Tender) error {
 	tenderQuery := `
 INSERT INTO tender
-	(organization_id)
-VALUES ($1)
+	(organization_id, visibility)
+VALUES ($1, $2)
 RETURNING 
 	id,
 	status,
+	visibility,
 	created_at
 `
 	tenderInfoQuery := `
@@ -133,32 +175,51 @@ RETURNING
 	version;
 `
 
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
+	visibility := t.Visibility
+	if visibility == "" {
+		visibility = model.TenderPublic
 	}
-
-	row := tx.QueryRow(tenderQuery, t.OrganizationID)
-	err = row.Scan(&t.ID, &t.Status, &t.CreatedAt)
-
+	row := tx.QueryRow(tenderQuery, t.OrganizationID, visibility)
+	err := row.Scan(&t.ID, &t.Status, &t.Visibility, &t.CreatedAt)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
 	row = tx.QueryRow(tenderInfoQuery, t.ID, t.Name, t.Description, t.ServiceType)
-	err = row.Scan(&t.Version)
+	return row.Scan(&t.Version)
+}
 
+// TxGetTenderIDByName finds a tender of the organization by its first name.
+func (r *TenderRepository) TxGetTenderIDByName(tx *sql.Tx, organizationID uuid.UUID, name string) (*uuid.UUID, error) {
+	query := `
+SELECT t.id
+FROM tender t
+	JOIN tender_information ti
+		ON ti.id = t.id
+		AND ti.version = 1
+WHERE
+	t.organization_id = $1
+	AND ti.name = $2
+LIMIT 1
+`
+	var id uuid.UUID
+	err := tx.QueryRow(query, organizationID, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoTender
+	}
 	if err != nil {
-		tx.Rollback()
-	} else {
-		tx.Commit()
+		return nil, err
 	}
-
-	return err
+	return &id, nil
 }
 
-func (r *TenderRepository) GetUserTenders(userID uuid.UUID, limit, offset int) ([]model.Tender, error) {
+// GetUserTenders lists the tenders of the organizations the user is responsible for,
+// in their current versions or, with includeHistory, in all of them.
+func (r *TenderRepository) GetUserTenders(userID uuid.UUID, includeHistory bool, limit, offset int) ([]model.Tender, error) {
+	versions := "AND ti.version = t.current_version"
+	if includeHistory {
+		versions = ""
+	}
 	query := `
 SELECT
 	t.id,
@@ -166,12 +227,15 @@ SELECT
 	ti.description,
 	ti.service_type,
 	t.status,
+	t.visibility,
 	t.organization_id,
 	ti.version,
-	t.created_at
+	t.created_at,
+	(SELECT COUNT(*) FROM tender_question q WHERE q.tender_id = t.id AND q.answer IS NOT NULL)
 FROM tender t
 	JOIN tender_information ti
 		ON ti.id = t.id
+		` + versions + `
 WHERE organization_id IN (
 	SELECT organization_id
 	FROM organization_responsible
@@ -191,8 +255,8 @@ OFFSET $3
 	for rows.Next() {
 		var tender model.Tender
 		err := rows.Scan(&tender.ID, &tender.Name, &tender.Description,
-			&tender.ServiceType, &tender.Status, &tender.OrganizationID,
-			&tender.Version, &tender.CreatedAt)
+			&tender.ServiceType, &tender.Status, &tender.Visibility, &tender.OrganizationID,
+			&tender.Version, &tender.CreatedAt, &tender.Questions.Answered)
 		if err != nil {
 			return nil, err
 		}
@@ -201,21 +265,40 @@ OFFSET $3
 	return tenders, nil
 }
 
-func (r *TenderRepository) UpdateTenderStatus(t *model.Tender) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
+// ChangeTenderStatus moves the tender to the new status unless its status
+// has changed or the guard of the change doesn't hold anymore.
+func (r *TenderRepository) ChangeTenderStatus(tenderID uuid.UUID, change StatusChange) error {
+	return changeStatus(r.db, "tender", tenderID, change)
+}
+
+func (r *TenderRepository) TxChangeTenderStatus(tx *sql.Tx, tenderID uuid.UUID, change StatusChange) error {
+	return changeStatus(tx, "tender", tenderID, change)
+}
+
+// TenderGuardHolds reports whether a status change of the tender guarded
+// by the guard would be allowed now.
+func (r *TenderRepository) TenderGuardHolds(tenderID uuid.UUID, guard StatusGuard) (bool, error) {
+	return guardHolds(r.db, "tender", tenderID, guard)
+}
+
+// TxGetTenderStatusForUpdate locks the tender until the end of the transaction,
+// so that its status can't change under the writes depending on it.
+func (r *TenderRepository) TxGetTenderStatusForUpdate(tx *sql.Tx, tenderID uuid.UUID) (string, error) {
+	query := `
+SELECT status
+FROM tender
+WHERE id = $1
+`
+	// the SQLite transactions hold the write lock from the start
+	if db.Driver == db.Postgres {
+		query += "FOR UPDATE\n"
 	}
-	err = r.TxUpdateTenderStatus(tx, t.ID, t.Status)
-	if err != nil && err != ErrNoBid {
-		tx.Rollback()
-		return err
-	} else {
-		tx.Commit()
+	var status string
+	err := tx.QueryRow(query, tenderID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", ErrNoTender
 	}
-	tmp, err := r.GetLastTenderByID(t.ID)
-	*t = *tmp
-	return err
+	return status, err
 }
 
 func (r *TenderRepository) TxUpdateTenderStatus(tx *sql.Tx, tenderID uuid.UUID, status string) error {
@@ -244,22 +327,23 @@ SELECT
 	ti.description,
 	ti.service_type,
 	t.status,
+	t.visibility,
 	t.organization_id,
 	ti.version,
-	t.created_at
+	t.created_at,
+	(SELECT COUNT(*) FROM tender_question q WHERE q.tender_id = t.id AND q.answer IS NOT NULL)
 FROM tender t
 	JOIN tender_information ti
 		ON ti.id = t.id
+		AND ti.version = t.current_version
 WHERE t.id = $1
-ORDER BY version DESC
-LIMIT 1
 `
 
 	var t model.Tender
 
 	row := r.db.QueryRow(query, tenderID)
 	err := row.Scan(&t.ID, &t.Name, &t.Description, &t.ServiceType, &t.Status,
-		&t.OrganizationID, &t.Version, &t.CreatedAt)
+		&t.Visibility, &t.OrganizationID, &t.Version, &t.CreatedAt, &t.Questions.Answered)
 	if err == sql.ErrNoRows {
 		return nil, ErrNoTender
 	}
@@ -269,7 +353,8 @@ LIMIT 1
 	return &t, nil
 }
 
-func (r *TenderRepository) PatchTender(tenderID uuid.UUID, patch *model.TenderUpdate) (*model.Tender, error) {
+// PatchTender adds a version of the tender made of the current one, t, and the patch.
+func (r *TenderRepository) PatchTender(t *model.Tender, patch *model.TenderUpdate) (*model.Tender, error) {
 	tenderInfoQuery := `
 INSERT INTO tender_information
 	(id, name, description, service_type, version)
@@ -278,52 +363,105 @@ SELECT
 	$2,
 	$3,
 	$4,
-	(SELECT MAX(version) + 1 FROM tender_information WHERE id = $1)
+	(SELECT current_version + 1 FROM tender WHERE id = $1)
 RETURNING
 	version;
 `
-	t, err := r.GetLastTenderByID(tenderID)
-	if err != nil {
-		return nil, err
-	}
+	patched := *t
 	if patch.Name != nil {
-		t.Name = *patch.Name
+		patched.Name = *patch.Name
 	}
 	if patch.Description != nil {
-		t.Description = *patch.Description
+		patched.Description = *patch.Description
 	}
 	if patch.ServiceType != nil {
-		t.ServiceType = *patch.ServiceType
+		patched.ServiceType = *patch.ServiceType
 	}
 
-	row := r.db.QueryRow(tenderInfoQuery, t.ID, t.Name, t.Description, t.ServiceType)
-	err = row.Scan(&t.Version)
+	row := r.db.QueryRow(tenderInfoQuery, patched.ID, patched.Name, patched.Description, patched.ServiceType)
+	err := row.Scan(&patched.Version)
 	if err != nil {
 		return nil, err
 	}
-	return r.GetLastTenderByID(tenderID)
+	return &patched, nil
 }
 
-func (r *TenderRepository) RollbackTender(tenderID uuid.UUID, version int) (*model.Tender, error) {
+// RollbackTender adds a version of the tender, t is the current one,
+// with the name, the description and the service type of the given version.
+func (r *TenderRepository) RollbackTender(t *model.Tender, version int) (*model.Tender, error) {
 	tenderInfoQuery := `
 INSERT INTO tender_information
 	(id, version, name, description, service_type)
 SELECT
 	id,
-	(SELECT MAX(version) + 1 FROM tender_information WHERE id = $1),
+	(SELECT current_version + 1 FROM tender WHERE id = $1),
 	name,
 	description,
 	service_type
 FROM tender_information
 WHERE id = $1 AND version = $2
-RETURNING version
+RETURNING version, name, description, service_type
+`
+	rolledBack := *t
+	err := r.db.QueryRow(tenderInfoQuery, t.ID, version).Scan(&rolledBack.Version,
+		&rolledBack.Name, &rolledBack.Description, &rolledBack.ServiceType)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoTender
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rolledBack, nil
+}
+
+// UpdateTenderVisibility sets the visibility of the tender, t is the current one.
+func (r *TenderRepository) UpdateTenderVisibility(t *model.Tender, visibility string) (*model.Tender, error) {
+	query := `
+UPDATE tender
+SET visibility = $2
+WHERE
+	id = $1
 `
-	q, err := r.db.Query(tenderInfoQuery, tenderID, version)
+	res, err := r.db.Exec(query, t.ID, visibility)
 	if err != nil {
 		return nil, err
 	}
-	if !q.Next() {
+	if aff, _ := res.RowsAffected(); aff == 0 {
 		return nil, ErrNoTender
 	}
-	return r.GetLastTenderByID(tenderID)
+	updated := *t
+	updated.Visibility = visibility
+	return &updated, nil
+}
+
+// tenderChangesChannel carries the id of a changed tender,
+// or an empty payload for any tender.
+const tenderChangesChannel = "tender_changes"
+
+// NotifyTenderChanged tells the other instances to drop the tender from their
+// caches, a nil tenderID stands for all the tenders. A SQLite database is used
+// by a single process, there is nobody to notify.
+func (r *TenderRepository) NotifyTenderChanged(tenderID *uuid.UUID) error {
+	if db.Driver != db.Postgres {
+		return nil
+	}
+	payload := ""
+	if tenderID != nil {
+		payload = tenderID.String()
+	}
+	_, err := r.db.Exec(`SELECT pg_notify($1, $2)`, tenderChangesChannel, payload)
+	return err
+}
+
+// ListenTenderChanges calls handle for every NotifyTenderChanged until stop is closed,
+// with a nil tenderID when the notifications may have been missed.
+func (r *TenderRepository) ListenTenderChanges(dsn string, stop <-chan struct{}, handle func(tenderID *uuid.UUID)) {
+	db.Listen(dsn, tenderChangesChannel, stop, func(payload string) {
+		tenderID, err := uuid.Parse(payload)
+		if err != nil {
+			handle(nil)
+			return
+		}
+		handle(&tenderID)
+	})
 }
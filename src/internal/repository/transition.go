@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrStatusChanged is returned by a status change whose entity has left
+// the source status, or whose guard no longer holds.
+var ErrStatusChanged = errors.New("the status has changed or the transition guard doesn't hold")
+
+// StatusGuard is an SQL condition on the tender or bid row a status change
+// checks in the same statement as the update.
+type StatusGuard string
+
+const (
+	// TenderHasNoBids holds until somebody bids on the tender.
+	TenderHasNoBids StatusGuard = "NOT EXISTS (SELECT 1 FROM bid WHERE bid.tender_id = tender.id)"
+	// BidHasNoDecisions holds until the first decision on the bid.
+	BidHasNoDecisions StatusGuard = "NOT EXISTS (SELECT 1 FROM bid_decision WHERE bid_decision.bid_id = bid.id)"
+	// BidTenderIsPublished holds while the tender of the bid is published.
+	BidTenderIsPublished StatusGuard = "EXISTS (SELECT 1 FROM tender WHERE tender.id = bid.tender_id AND tender.status = 'Published')"
+)
+
+// StatusChange moves a tender or a bid from one status to another,
+// an empty Guard always holds.
+type StatusChange struct {
+	From  string
+	To    string
+	Guard StatusGuard
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// changeStatus runs the conditional update of the status of the row
+// of the table, so that the transition and its guard are checked atomically.
+func changeStatus(e execer, table string, id any, change StatusChange) error {
+	query := `
+UPDATE ` + table + `
+SET status = $2
+WHERE
+	id = $1
+	AND status = $3
+`
+	if change.Guard != "" {
+		query += "\tAND " + string(change.Guard) + "\n"
+	}
+	res, err := e.Exec(query, id, change.To, change.From)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// guardHolds reports whether the guard holds for the row of the table now.
+func guardHolds(conn *sql.DB, table string, id any, guard StatusGuard) (bool, error) {
+	query := `
+SELECT EXISTS (
+	SELECT 1
+	FROM ` + table + `
+	WHERE
+		id = $1
+		AND ` + string(guard) + `
+)
+`
+	var holds bool
+	err := conn.QueryRow(query, id).Scan(&holds)
+	return holds, err
+}
@@ -0,0 +1,289 @@
+package server_test
+
+import (
+	"avito-back-test/fixtures"
+	"avito-back-test/internal/config"
+	"avito-back-test/internal/db"
+	"avito-back-test/internal/db/dbtest"
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/server"
+	"avito-back-test/internal/service"
+	"avito-back-test/internal/tlstest"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// the organizations of the demo fixture, ivanov is responsible for the first
+// one and sidorov for the second one
+const (
+	organization1 = "8a2b6b3e-1c4f-4f0e-9a57-0d2f1c9e0a01"
+	organization2 = "8a2b6b3e-1c4f-4f0e-9a57-0d2f1c9e0a02"
+)
+
+type testAPI struct {
+	t      *testing.T
+	server *httptest.Server
+	client *http.Client
+}
+
+// newTestAPI serves the API on a migrated SQLite database
+// with the demo fixture loaded, over TLS if configured.
+func newTestAPI(t *testing.T, configure func(cfg *config.Config)) *testAPI {
+	dbtest.OpenSQLite(t)
+
+	var fixture model.Fixture
+	if err := yaml.Unmarshal(fixtures.Demo, &fixture); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.NewSeedService().Seed(&fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.Database.Driver = db.SQLite
+	cfg.RateLimit.Enabled = false
+	if configure != nil {
+		configure(cfg)
+	}
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewUnstartedServer(srv.Handler)
+	if srv.TLSConfig != nil {
+		ts.TLS = srv.TLSConfig
+		ts.StartTLS()
+	} else {
+		ts.Start()
+	}
+	t.Cleanup(func() {
+		ts.Close()
+		srv.Shutdown(context.Background())
+	})
+	return &testAPI{t: t, server: ts, client: ts.Client()}
+}
+
+// do sends the request with a JSON body, unless body is nil,
+// and decodes the JSON response into out, unless out is nil.
+func (a *testAPI) do(method, path string, body any, header http.Header, out any) *http.Response {
+	a.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			a.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, a.server.URL+path, reader)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.Unmarshal(data, out); err != nil {
+			a.t.Fatalf("%s %s: %v: %s", method, path, err, data)
+		}
+	}
+	return resp
+}
+
+// expect is do failing the test unless the response has the status.
+func (a *testAPI) expect(status int, method, path string, body any, out any) {
+	a.t.Helper()
+	if resp := a.do(method, path, body, nil, out); resp.StatusCode != status {
+		a.t.Fatalf("%s %s: got %d, want %d", method, path, resp.StatusCode, status)
+	}
+}
+
+func (a *testAPI) createTender(name string, auction *model.Auction) model.Tender {
+	a.t.Helper()
+	var tender model.Tender
+	a.expect(http.StatusOK, http.MethodPost, "/api/tenders/new", map[string]any{
+		"name":            name,
+		"description":     "test tender",
+		"serviceType":     "Delivery",
+		"organizationId":  organization1,
+		"creatorUsername": "ivanov",
+		"auction":         auction,
+	}, &tender)
+	return tender
+}
+
+func (a *testAPI) createBid(tender model.Tender) model.Bid {
+	a.t.Helper()
+	var bid model.Bid
+	a.expect(http.StatusOK, http.MethodPost, "/api/bids/new", map[string]any{
+		"name":        "test bid",
+		"description": "test bid",
+		"tenderId":    tender.ID,
+		"authorType":  model.AuthorTypeOrganization,
+		"authorId":    organization2,
+	}, &bid)
+	return bid
+}
+
+func TestTenderAndBidStatuses(t *testing.T) {
+	api := newTestAPI(t, nil)
+	tender := api.createTender("statuses", nil)
+	api.expect(http.StatusOK, http.MethodPut, "/api/tenders/"+tender.ID.String()+"/status?username=ivanov&status=Published", nil, nil)
+
+	var tenders []model.Tender
+	api.expect(http.StatusOK, http.MethodGet, "/api/tenders?service_type=Delivery&limit=50", nil, &tenders)
+	found := false
+	for _, listed := range tenders {
+		found = found || listed.ID == tender.ID
+	}
+	if !found {
+		t.Fatalf("the published tender is not listed")
+	}
+
+	bid := api.createBid(tender)
+	// a tender somebody has bid on can't be unpublished
+	api.expect(http.StatusConflict, http.MethodPut, "/api/tenders/"+tender.ID.String()+"/status?username=ivanov&status=Created", nil, nil)
+	api.expect(http.StatusOK, http.MethodPut, "/api/bids/"+bid.ID.String()+"/status?username=sidorov&status=Published", nil, nil)
+
+	// a rejection cancels the bid
+	var decided model.Bid
+	api.expect(http.StatusOK, http.MethodPut, "/api/bids/"+bid.ID.String()+"/submit_decision?username=ivanov&decision=Rejected", nil, &decided)
+	if decided.Status != model.BidCanceled {
+		t.Fatalf("got bid status %q, want %q", decided.Status, model.BidCanceled)
+	}
+}
+
+func TestExpiredAuctionClosesTender(t *testing.T) {
+	api := newTestAPI(t, nil)
+	tender := api.createTender("auction", &model.Auction{
+		StartPrice:       1000,
+		MinStep:          10,
+		DurationSeconds:  60,
+		ExtensionSeconds: 30,
+	})
+	tenderPath := "/api/tenders/" + tender.ID.String()
+	api.expect(http.StatusOK, http.MethodPut, tenderPath+"/status?username=ivanov&status=Published", nil, nil)
+	bid := api.createBid(tender)
+	api.expect(http.StatusOK, http.MethodPut, "/api/bids/"+bid.ID.String()+"/status?username=sidorov&status=Published", nil, nil)
+
+	var offer model.AuctionOffer
+	api.expect(http.StatusOK, http.MethodPut, "/api/bids/"+bid.ID.String()+"/offer?username=sidorov&price=900", nil, &offer)
+	var auction model.Auction
+	api.expect(http.StatusOK, http.MethodGet, tenderPath+"/auction?username=ivanov", nil, &auction)
+	if auction.Finished || auction.EndsAt == nil || auction.EndsAt.Before(time.Now()) {
+		t.Fatalf("the auction ending at %s has to be running", auction.EndsAt)
+	}
+
+	// run the clock out instead of waiting for it
+	_, err := db.DB.Exec(`UPDATE tender_auction SET ends_at = datetime('now', '-1 seconds') WHERE tender_id = $1`, tender.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.expect(http.StatusOK, http.MethodGet, tenderPath+"/auction?username=ivanov", nil, &auction)
+	if !auction.Finished || auction.WinnerBidID == nil || *auction.WinnerBidID != bid.ID {
+		t.Fatalf("got auction %+v, want it finished with the bid winning", auction)
+	}
+	var status string
+	api.expect(http.StatusOK, http.MethodGet, tenderPath+"/status?username=ivanov", nil, &status)
+	if status != model.TenderClosed {
+		t.Fatalf("got tender status %q, want %q", status, model.TenderClosed)
+	}
+}
+
+func TestIdempotencyKeyReplaysCreate(t *testing.T) {
+	api := newTestAPI(t, nil)
+	body := map[string]any{
+		"name":            "idempotent",
+		"description":     "test tender",
+		"serviceType":     "Delivery",
+		"organizationId":  organization1,
+		"creatorUsername": "ivanov",
+	}
+	header := http.Header{"Idempotency-Key": {"create-1"}}
+
+	var first, retried model.Tender
+	if resp := api.do(http.MethodPost, "/api/tenders/new", body, header, &first); resp.StatusCode != http.StatusOK {
+		t.Fatalf("got %d, want 200", resp.StatusCode)
+	}
+	resp := api.do(http.MethodPost, "/api/tenders/new", body, header, &retried)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("got %d replayed %q, want a replayed 200", resp.StatusCode, resp.Header.Get("Idempotent-Replayed"))
+	}
+	if retried.ID != first.ID {
+		t.Fatalf("the retry created tender %s besides %s", retried.ID, first.ID)
+	}
+
+	body["name"] = "different"
+	if resp := api.do(http.MethodPost, "/api/tenders/new", body, header, nil); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d for a different request with the key, want 422", resp.StatusCode)
+	}
+}
+
+func TestRateLimitSharedBackend(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) {
+		cfg.RateLimit.Enabled = true
+		// the shared backend keeps the budgets in the database
+		cfg.RateLimit.Backend = "postgres"
+		cfg.RateLimit.Default = config.RateBudget{Requests: 2, Period: time.Minute}
+	})
+	for range 2 {
+		api.expect(http.StatusOK, http.MethodGet, "/api/ping", nil, nil)
+	}
+	resp := api.do(http.MethodGet, "/api/ping", nil, nil, nil)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("got %d over the budget, want 429", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("the 429 response has no Retry-After")
+	}
+}
+
+func TestClientCertActsOnlyAsItsEmployee(t *testing.T) {
+	dir := t.TempDir()
+	ca := tlstest.NewCA(t, "test CA")
+	serverCert := ca.Issue(t, "localhost")
+	api := newTestAPI(t, func(cfg *config.Config) {
+		cfg.TLS.CertFile = tlstest.WriteFile(t, dir, "server.pem", serverCert.PEM)
+		cfg.TLS.KeyFile = tlstest.WriteFile(t, dir, "server-key.pem", serverCert.KeyPEM)
+		cfg.TLS.ClientCAFile = tlstest.WriteFile(t, dir, "ca.pem", ca.PEM)
+		cfg.TLS.ClientIdentities = map[string]config.ClientIdentity{
+			"ivanov":  {Username: "ivanov"},
+			"sidorov": {Username: "sidorov"},
+		}
+	})
+	clientOf := func(username string) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      ca.Pool(),
+			ServerName:   "localhost",
+			Certificates: []tls.Certificate{ca.Issue(t, username).TLS},
+		}}}
+	}
+
+	api.client = clientOf("ivanov")
+	tender := api.createTender("reviews", nil)
+	// the requester names the acting employee instead of username
+	reviewsPath := "/api/bids/" + tender.ID.String() + "/reviews?authorUsername=petrova&requesterUsername=ivanov"
+	api.expect(http.StatusOK, http.MethodGet, reviewsPath, nil, nil)
+
+	api.client = clientOf("sidorov")
+	api.expect(http.StatusForbidden, http.MethodGet, reviewsPath, nil, nil)
+}
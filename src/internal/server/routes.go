@@ -1,6 +1,7 @@
 package server
 
 import (
+	"avito-back-test/internal/config"
 	"avito-back-test/internal/handler"
 	"avito-back-test/internal/middleware"
 	"net/http"
@@ -8,32 +9,115 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func newRouter() *mux.Router {
+func newRouter(cfg *config.Config) *mux.Router {
+	handler.SetPagination(cfg.Pagination)
+
 	r := mux.NewRouter()
 	r.Use(middleware.LoggingMiddleware)
+	r.Use(middleware.CallerCache)
+	if cfg.TLS.ClientCAFile != "" {
+		r.Use(middleware.NewClientCert(cfg.TLS.ClientIdentities).Middleware)
+	}
+	if len(cfg.Database.ReplicaURLs) > 0 {
+		r.Use(middleware.ReadYourWrites)
+	}
+	if cfg.RateLimit.Enabled {
+		var store middleware.RateLimitStore
+		if cfg.RateLimit.Backend == "postgres" {
+			store = middleware.NewPostgresRateLimitStore(cfg.RateLimit)
+		} else {
+			store = middleware.NewMemoryRateLimitStore()
+		}
+		r.Use(middleware.NewRateLimiter(cfg.RateLimit, store).Middleware)
+	}
 
 	r.HandleFunc("/api/ping", handler.PingHandler).Methods(http.MethodGet)
 
+	// the retries of the creates, the status changes and the decisions
+	// with an Idempotency-Key get the first response again
+	idempotency := middleware.NewIdempotency(cfg.Idempotency)
+	byUsername := middleware.QueryCaller("username")
+
 	tenderHandler := handler.NewTenderHandler()
-	r.HandleFunc("/api/tenders/new", tenderHandler.InsertNewTender).Methods(http.MethodPost)
+	r.Handle("/api/tenders/new", idempotency.Wrap(middleware.BodyCaller("creatorUsername"),
+		tenderHandler.InsertNewTender)).Methods(http.MethodPost)
 	r.HandleFunc("/api/tenders/my", tenderHandler.GetMyTenders).Methods(http.MethodGet)
-	r.HandleFunc("/api/tenders/{tenderId}/status", tenderHandler.UpdateTenderStatus).Methods(http.MethodPut)
+	r.Handle("/api/tenders/{tenderId}/status", idempotency.Wrap(byUsername,
+		tenderHandler.UpdateTenderStatus)).Methods(http.MethodPut)
 	r.HandleFunc("/api/tenders/{tenderId}/status", tenderHandler.GetTenderStatus).Methods(http.MethodGet)
+	r.HandleFunc("/api/tenders/{tenderId}/transitions", tenderHandler.GetTenderTransitions).Methods(http.MethodGet)
 	r.HandleFunc("/api/tenders/{tenderId}/edit", tenderHandler.UpdateTender).Methods(http.MethodPatch)
 	r.HandleFunc("/api/tenders/{tenderId}/rollback/{version}", tenderHandler.RollbackTender).Methods(http.MethodPut)
+	r.HandleFunc("/api/tenders/{tenderId}/visibility", tenderHandler.UpdateTenderVisibility).Methods(http.MethodPut)
+	r.HandleFunc("/api/tenders/{tenderId}/invitations", tenderHandler.GetInvitations).Methods(http.MethodGet)
+	r.HandleFunc("/api/tenders/{tenderId}/invitations/{organizationId}", tenderHandler.InviteOrganization).Methods(http.MethodPut)
+	r.HandleFunc("/api/tenders/{tenderId}/invitations/{organizationId}", tenderHandler.RevokeInvitation).Methods(http.MethodDelete)
 	r.HandleFunc("/api/tenders", tenderHandler.GetTenders).Methods(http.MethodGet)
 
-	bidHandler := handler.NewBidHandler()
-	r.HandleFunc("/api/bids/new", bidHandler.InsertNewBid).Methods(http.MethodPost)
+	lotHandler := handler.NewLotHandler()
+	r.HandleFunc("/api/tenders/{tenderId}/lots/new", lotHandler.InsertNewLot).Methods(http.MethodPost)
+	r.HandleFunc("/api/tenders/{tenderId}/lots", lotHandler.GetLots).Methods(http.MethodGet)
+
+	evaluationHandler := handler.NewEvaluationHandler()
+	r.HandleFunc("/api/tenders/{tenderId}/criteria", evaluationHandler.GetCriteria).Methods(http.MethodGet)
+	r.HandleFunc("/api/tenders/{tenderId}/evaluation", evaluationHandler.GetTenderEvaluation).Methods(http.MethodGet)
+
+	clarificationHandler := handler.NewClarificationHandler()
+	r.HandleFunc("/api/tenders/{tenderId}/questions/new", clarificationHandler.AskQuestion).Methods(http.MethodPost)
+	r.HandleFunc("/api/tenders/{tenderId}/questions/deadline", clarificationHandler.GetQuestionDeadline).Methods(http.MethodGet)
+	r.HandleFunc("/api/tenders/{tenderId}/questions/deadline", clarificationHandler.SetQuestionDeadline).Methods(http.MethodPut)
+	r.HandleFunc("/api/tenders/{tenderId}/questions/{questionId}/answer", clarificationHandler.AnswerQuestion).Methods(http.MethodPut)
+	r.HandleFunc("/api/tenders/{tenderId}/questions", clarificationHandler.GetQuestions).Methods(http.MethodGet)
+
+	qualificationHandler := handler.NewQualificationHandler()
+	r.HandleFunc("/api/tenders/{tenderId}/questionnaire", qualificationHandler.GetQuestionnaire).Methods(http.MethodGet)
+	r.HandleFunc("/api/tenders/{tenderId}/questionnaire", qualificationHandler.SetQuestionnaire).Methods(http.MethodPut)
+	r.HandleFunc("/api/tenders/{tenderId}/qualifications/new", qualificationHandler.SubmitQualification).Methods(http.MethodPost)
+	r.HandleFunc("/api/tenders/{tenderId}/qualifications", qualificationHandler.GetQualifications).Methods(http.MethodGet)
+	r.HandleFunc("/api/tenders/{tenderId}/qualifications/{qualificationId}", qualificationHandler.GetQualification).Methods(http.MethodGet)
+	r.HandleFunc("/api/tenders/{tenderId}/qualifications/{qualificationId}/review", qualificationHandler.ReviewQualification).Methods(http.MethodPut)
+
+	conflictHandler := handler.NewConflictHandler(cfg.AdminUsernames)
+	r.HandleFunc("/api/tenders/{tenderId}/recusal", conflictHandler.DeclareRecusal).Methods(http.MethodPut)
+	r.HandleFunc("/api/tenders/{tenderId}/recusals", conflictHandler.GetRecusals).Methods(http.MethodGet)
+	r.HandleFunc("/api/tenders/{tenderId}/conflicts", conflictHandler.GetConflicts).Methods(http.MethodGet)
+
+	categoryHandler := handler.NewCategoryHandler(cfg.AdminUsernames)
+	r.HandleFunc("/api/categories/new", categoryHandler.InsertCategory).Methods(http.MethodPost)
+	r.HandleFunc("/api/categories/{code}/edit", categoryHandler.UpdateCategory).Methods(http.MethodPatch)
+	r.HandleFunc("/api/categories/{code}", categoryHandler.GetCategory).Methods(http.MethodGet)
+	r.HandleFunc("/api/categories/{code}", categoryHandler.DeleteCategory).Methods(http.MethodDelete)
+	r.HandleFunc("/api/categories", categoryHandler.GetCategories).Methods(http.MethodGet)
+
+	integrityHandler := handler.NewIntegrityHandler(cfg.AdminUsernames)
+	r.HandleFunc("/api/admin/integrity/repair", integrityHandler.RepairIntegrity).Methods(http.MethodPost)
+	r.HandleFunc("/api/admin/integrity", integrityHandler.GetIntegrityReport).Methods(http.MethodGet)
+
+	archiveHandler := handler.NewArchiveHandler(cfg.AdminUsernames)
+	r.HandleFunc("/api/admin/organizations/import", archiveHandler.ImportOrganization).Methods(http.MethodPost)
+	r.HandleFunc("/api/admin/organizations/{organizationId}/export", archiveHandler.ExportOrganization).Methods(http.MethodGet)
+
+	bidHandler := handler.NewBidHandler(cfg.Bids.DecisionQuorum)
+	r.Handle("/api/bids/new", idempotency.Wrap(middleware.BodyCaller("authorType", "authorId"),
+		bidHandler.InsertNewBid)).Methods(http.MethodPost)
 	r.HandleFunc("/api/bids/my", bidHandler.GetMyBids).Methods(http.MethodGet)
 	r.HandleFunc("/api/bids/{tenderId}/list", bidHandler.GetBidsByTender).Methods(http.MethodGet)
 	r.HandleFunc("/api/bids/{bidId}/status", bidHandler.GetBidStatus).Methods(http.MethodGet)
-	r.HandleFunc("/api/bids/{bidId}/status", bidHandler.UpdateBidStatus).Methods(http.MethodPut)
+	r.Handle("/api/bids/{bidId}/status", idempotency.Wrap(byUsername,
+		bidHandler.UpdateBidStatus)).Methods(http.MethodPut)
+	r.HandleFunc("/api/bids/{bidId}/transitions", bidHandler.GetBidTransitions).Methods(http.MethodGet)
 	r.HandleFunc("/api/bids/{bidId}/edit", bidHandler.UpdateBid).Methods(http.MethodPatch)
 	r.HandleFunc("/api/bids/{bidId}/rollback/{version}", bidHandler.RollbackBid).Methods(http.MethodPut)
 	r.HandleFunc("/api/bids/{bidId}/feedback", bidHandler.LeaveFeedback).Methods(http.MethodPut)
 	r.HandleFunc("/api/bids/{tenderId}/reviews", bidHandler.GetTenderReviewsOnUser).Methods(http.MethodGet)
-	r.HandleFunc("/api/bids/{bidId}/submit_decision", bidHandler.SubmitDecision).Methods(http.MethodPut)
+	r.Handle("/api/bids/{bidId}/submit_decision", idempotency.Wrap(byUsername,
+		bidHandler.SubmitDecision)).Methods(http.MethodPut)
+	r.HandleFunc("/api/bids/{bidId}/scores", evaluationHandler.SubmitScores).Methods(http.MethodPut)
+
+	auctionHandler := handler.NewAuctionHandler()
+	r.HandleFunc("/api/tenders/{tenderId}/auction", auctionHandler.GetAuction).Methods(http.MethodGet)
+	r.HandleFunc("/api/tenders/{tenderId}/auction/offers", auctionHandler.GetAuctionOffers).Methods(http.MethodGet)
+	r.HandleFunc("/api/bids/{bidId}/offer", auctionHandler.SubmitOffer).Methods(http.MethodPut)
 
 	// gorilla/mux:
 	// Routes are tested in the order they were added to the router
@@ -2,20 +2,76 @@ package server
 
 import (
 	"avito-back-test/internal/config"
+	"avito-back-test/internal/handler"
+	"avito-back-test/internal/middleware"
+	"expvar"
+	"fmt"
 	"net/http"
-	"time"
 )
 
-func NewServer(cfg *config.Config) *http.Server {
-	router := newRouter()
+// NewServer builds the API server. If TLS is configured, the server
+// has to be started with ListenAndServeTLS("", "").
+func NewServer(cfg *config.Config) (*http.Server, error) {
+	router := newRouter(cfg)
+	// CORS preflights never reach the routes, so the chain wraps the whole router
+	middlewares := make([]func(http.Handler) http.Handler, len(cfg.Server.Middlewares))
+	for i, name := range cfg.Server.Middlewares {
+		switch name {
+		case "recovery":
+			middlewares[i] = middleware.RecoveryMiddleware
+		case "securityHeaders":
+			middlewares[i] = middleware.SecurityHeadersMiddleware
+		case "cors":
+			middlewares[i] = middleware.NewCORS(cfg.CORS).Middleware
+		default:
+			return nil, fmt.Errorf("unknown middleware %q", name)
+		}
+	}
+	api := middleware.Chain(router, middlewares...)
+
+	// the probes skip the API middlewares, so rate limits and client
+	// certificates never take an instance out of rotation
+	healthHandler := handler.NewHealthHandler(cfg.Health.CheckTimeout)
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", healthHandler.Liveness)
+	root.HandleFunc("GET /readyz", healthHandler.Readiness)
+	root.Handle("/", api)
 
 	serv := &http.Server{
-		Addr:         cfg.ServerAddress,
-		Handler:      router,
-		WriteTimeout: time.Second * 10,
-		ReadTimeout:  time.Second * 10,
-		IdleTimeout:  time.Second * 20,
+		Addr:         cfg.Server.Address,
+		Handler:      root,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	if cfg.TLS.Enabled() {
+		reloader, err := newCertReloader(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		serv.TLSConfig = reloader.tlsConfig()
+		serv.RegisterOnShutdown(reloader.Stop)
 	}
 
-	return serv
+	return serv, nil
+}
+
+// NewMetricsServer builds the listener of the runtime, cache and database
+// counters for the monitoring, or returns nil if it is disabled. The counters
+// expose the command line and the internals of the service, so they are never
+// served on the API listener.
+func NewMetricsServer(cfg *config.Config) *http.Server {
+	if cfg.Server.MetricsAddress == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return &http.Server{
+		Addr:         cfg.Server.MetricsAddress,
+		Handler:      mux,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
 }
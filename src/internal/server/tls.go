@@ -0,0 +1,149 @@
+package server
+
+import (
+	"avito-back-test/internal/config"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloader serves the certificate and the client CAs from disk
+// and picks up their changes without a restart.
+type certReloader struct {
+	cfg config.TLSConfig
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTimes map[string]time.Time
+
+	stop chan struct{}
+}
+
+func newCertReloader(cfg config.TLSConfig) (*certReloader, error) {
+	r := &certReloader{
+		cfg:      cfg,
+		modTimes: make(map[string]time.Time),
+		stop:     make(chan struct{}),
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	go r.watch()
+	return r, nil
+}
+
+func (r *certReloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+func (r *certReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = info.ModTime()
+	}
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return err
+	}
+	var clientCA *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		clientCA = x509.NewCertPool()
+		if !clientCA.AppendCertsFromPEM(pem) {
+			return errors.New("no certificates found in " + r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.clientCA, r.modTimes = &cert, clientCA, modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+// watch polls the files and reloads them once any of them changes.
+// A broken update is logged and the previous certificates stay in use.
+func (r *certReloader) watch() {
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.load(); err != nil {
+				log.Println("tls reload:", err)
+				continue
+			}
+			log.Println("tls certificates reloaded")
+		}
+	}
+}
+
+func (r *certReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			// the file is being replaced, wait for it
+			return false
+		}
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *certReloader) Stop() {
+	close(r.stop)
+}
+
+func (r *certReloader) tlsConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: r.cfg.MinTLSVersion(),
+	}
+	base.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return r.cert, nil
+	}
+	// a fresh config per handshake carries the current client CAs
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		cfg := &tls.Config{
+			MinVersion:   r.cfg.MinTLSVersion(),
+			Certificates: []tls.Certificate{*r.cert},
+			NextProtos:   []string{"h2", "http/1.1"},
+		}
+		if r.clientCA != nil {
+			cfg.ClientCAs = r.clientCA
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			if r.cfg.ClientAuth == "request" {
+				cfg.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+		}
+		return cfg, nil
+	}
+	return base
+}
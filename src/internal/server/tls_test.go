@@ -0,0 +1,178 @@
+package server
+
+import (
+	"avito-back-test/internal/config"
+	"avito-back-test/internal/tlstest"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// tlsFiles are the certificate files of a test server.
+type tlsFiles struct {
+	dir      string
+	ca       *tlstest.CA
+	certFile string
+	keyFile  string
+	caFile   string
+}
+
+func newTLSFiles(t *testing.T) *tlsFiles {
+	f := &tlsFiles{dir: t.TempDir(), ca: tlstest.NewCA(t, "test CA")}
+	f.writeServerCert(t, f.ca.Issue(t, "localhost"))
+	f.caFile = tlstest.WriteFile(t, f.dir, "ca.pem", f.ca.PEM)
+	return f
+}
+
+func (f *tlsFiles) writeServerCert(t *testing.T, cert *tlstest.Cert) {
+	f.certFile = tlstest.WriteFile(t, f.dir, "server.pem", cert.PEM)
+	f.keyFile = tlstest.WriteFile(t, f.dir, "server-key.pem", cert.KeyPEM)
+}
+
+// serveTLS serves the reloader certificates the way NewServer does
+// and returns the address of the server.
+func serveTLS(t *testing.T, cfg config.TLSConfig) string {
+	reloader, err := newCertReloader(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serv := &http.Server{
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: reloader.tlsConfig(),
+	}
+	go serv.ServeTLS(listener, "", "")
+	t.Cleanup(func() {
+		serv.Close()
+		reloader.Stop()
+	})
+	return listener.Addr().String()
+}
+
+// get makes a request over a new connection and returns its TLS state.
+func get(addr string, clientCfg *tls.Config) (*tls.ConnectionState, error) {
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: clientCfg, DisableKeepAlives: true},
+		Timeout:   5 * time.Second,
+	}
+	resp, err := client.Get("https://" + addr + "/")
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp.TLS, nil
+}
+
+func TestCertReloaderPicksUpChangedFiles(t *testing.T) {
+	files := newTLSFiles(t)
+	addr := serveTLS(t, config.TLSConfig{
+		CertFile:       files.certFile,
+		KeyFile:        files.keyFile,
+		ReloadInterval: 10 * time.Millisecond,
+	})
+	clientCfg := &tls.Config{RootCAs: files.ca.Pool(), ServerName: "localhost"}
+	if _, err := get(addr, clientCfg); err != nil {
+		t.Fatal(err)
+	}
+
+	renewed := files.ca.Issue(t, "localhost")
+	files.writeServerCert(t, renewed)
+	// the file system may keep the modification time of a quick rewrite
+	later := time.Now().Add(time.Minute)
+	for _, file := range []string{files.certFile, files.keyFile} {
+		if err := os.Chtimes(file, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state, err := get(addr, clientCfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.PeerCertificates[0].SerialNumber.Cmp(renewed.X509.SerialNumber) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the renewed certificate is not served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMinVersionRejectsOlderHandshakes(t *testing.T) {
+	files := newTLSFiles(t)
+	addr := serveTLS(t, config.TLSConfig{
+		CertFile:       files.certFile,
+		KeyFile:        files.keyFile,
+		MinVersion:     "1.3",
+		ReloadInterval: time.Minute,
+	})
+
+	_, err := get(addr, &tls.Config{RootCAs: files.ca.Pool(), ServerName: "localhost", MaxVersion: tls.VersionTLS12})
+	if err == nil {
+		t.Fatal("a TLS 1.2 handshake is accepted below the minimum version")
+	}
+	state, err := get(addr, &tls.Config{RootCAs: files.ca.Pool(), ServerName: "localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != tls.VersionTLS13 {
+		t.Fatalf("got version %x, want TLS 1.3", state.Version)
+	}
+}
+
+func TestClientAuth(t *testing.T) {
+	files := newTLSFiles(t)
+	client := files.ca.Issue(t, "client")
+	stranger := tlstest.NewCA(t, "another CA").Issue(t, "stranger")
+
+	tests := []struct {
+		clientAuth string
+		cert       *tlstest.Cert
+		wantErr    bool
+	}{
+		{clientAuth: "require", cert: client},
+		{clientAuth: "require", cert: nil, wantErr: true},
+		{clientAuth: "require", cert: stranger, wantErr: true},
+		{clientAuth: "request", cert: client},
+		{clientAuth: "request", cert: nil},
+		{clientAuth: "request", cert: stranger, wantErr: true},
+	}
+	for _, tt := range tests {
+		name := tt.clientAuth + " without a certificate"
+		if tt.cert != nil {
+			name = tt.clientAuth + " with " + tt.cert.X509.Subject.CommonName
+		}
+		t.Run(name, func(t *testing.T) {
+			addr := serveTLS(t, config.TLSConfig{
+				CertFile:       files.certFile,
+				KeyFile:        files.keyFile,
+				ClientCAFile:   files.caFile,
+				ClientAuth:     tt.clientAuth,
+				ReloadInterval: time.Minute,
+			})
+			clientCfg := &tls.Config{RootCAs: files.ca.Pool(), ServerName: "localhost"}
+			if tt.cert != nil {
+				// sent even if the server doesn't list its CA
+				clientCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return &tt.cert.TLS, nil
+				}
+			}
+			_, err := get(addr, clientCfg)
+			if tt.wantErr && err == nil {
+				t.Fatal("the handshake is accepted")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
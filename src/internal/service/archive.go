@@ -0,0 +1,394 @@
+package service
+
+import (
+	"archive/zip"
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrArchiveFormat   = errors.New("unsupported archive format version")
+	ErrInvalidArchive  = errors.New("invalid archive")
+	ErrImportConflicts = errors.New("the archive conflicts with the data of this instance")
+)
+
+// archiveFileName is the name of the JSON document inside the ZIP archives.
+const archiveFileName = "organization.json"
+
+type ArchiveService struct {
+	archiveRepo                 *repository.ArchiveRepository
+	employeeRepo                *repository.EmployeeRepository
+	organizationRepo            *repository.OrganizationRepository
+	organizationResponsibleRepo *repository.OrganizationResponsibleRepository
+	categoryRepo                *repository.CategoryRepository
+	tenderRepo                  *repository.TenderRepository
+	admins                      map[string]bool
+	auth                        *Authorizer
+}
+
+// NewArchiveService creates the export and import service, only the employees
+// listed in adminUsernames may use it through the API.
+func NewArchiveService(adminUsernames []string) *ArchiveService {
+	archiveRepo := repository.NewArchiveRepository()
+	emploRepo := repository.NewEmployeeRepository()
+	orgRepo := repository.NewOrganizationRepository()
+	orgRespRepo := repository.NewOrganizationResponsibleRepository()
+	categoryRepo := repository.NewCategoryRepository()
+	tenderRepo := repository.NewTenderRepository()
+	return &ArchiveService{
+		archiveRepo:                 archiveRepo,
+		employeeRepo:                emploRepo,
+		organizationRepo:            orgRepo,
+		organizationResponsibleRepo: orgRespRepo,
+		categoryRepo:                categoryRepo,
+		tenderRepo:                  tenderRepo,
+		admins:                      newAdmins(adminUsernames),
+		auth:                        NewAuthorizer(),
+	}
+}
+
+func (s *ArchiveService) ExportAsAdmin(ctx context.Context, organizationID uuid.UUID, username string) (*model.OrganizationArchive, error) {
+	if err := s.auth.Admin(ctx, username, s.admins); err != nil {
+		return nil, err
+	}
+	return s.Export(organizationID)
+}
+
+func (s *ArchiveService) ImportAsAdmin(ctx context.Context, archive *model.OrganizationArchive, into *uuid.UUID,
+	username string) (*model.ImportResult, []model.ImportConflict, error) {
+	if err := s.auth.Admin(ctx, username, s.admins); err != nil {
+		return nil, nil, err
+	}
+	return s.Import(archive, into)
+}
+
+// Export reads the organization data from one snapshot of the database.
+func (s *ArchiveService) Export(organizationID uuid.UUID) (*model.OrganizationArchive, error) {
+	archive := model.OrganizationArchive{
+		FormatVersion: model.ArchiveFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+	}
+	err := s.archiveRepo.WithTransaction(func(tx *sql.Tx) error {
+		if err := s.archiveRepo.TxSetSnapshot(tx); err != nil {
+			return err
+		}
+		organization, err := s.archiveRepo.TxGetOrganization(tx, organizationID)
+		if err != nil {
+			return err
+		}
+		archive.Organization = *organization
+		if archive.Responsibles, err = s.archiveRepo.TxGetResponsibles(tx, organizationID); err != nil {
+			return err
+		}
+		if archive.Tenders, err = s.archiveRepo.TxGetTenders(tx, organizationID); err != nil {
+			return err
+		}
+		archive.Employees, err = s.archiveRepo.TxGetEmployees(tx, referencedUsernames(&archive))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// referencedUsernames lists the bid authors and the decision makers
+// that are not responsibles of the organization.
+func referencedUsernames(archive *model.OrganizationArchive) []string {
+	known := make(map[string]bool)
+	for _, e := range archive.Responsibles {
+		known[e.Username] = true
+	}
+	usernames := []string{}
+	add := func(username string) {
+		if username != "" && !known[username] {
+			known[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	for _, t := range archive.Tenders {
+		for _, b := range t.Bids {
+			add(b.AuthorUsername)
+			for _, d := range b.Decisions {
+				add(d.ResponsibleUsername)
+			}
+		}
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// Import restores the archive with new ids for the tenders and bids.
+// The organization keeps its id unless into names an existing organization
+// to import the tenders into. Employees are matched by username and created
+// if missing. Nothing is written if any conflicts are found.
+func (s *ArchiveService) Import(archive *model.OrganizationArchive, into *uuid.UUID) (*model.ImportResult, []model.ImportConflict, error) {
+	if archive.FormatVersion != model.ArchiveFormatVersion {
+		return nil, nil, ErrArchiveFormat
+	}
+	if err := validateArchive(archive); err != nil {
+		return nil, nil, err
+	}
+
+	organizationID := archive.Organization.ID
+	if into != nil {
+		organizationID = *into
+	}
+	result := model.ImportResult{OrganizationID: organizationID}
+	var conflicts []model.ImportConflict
+	err := s.archiveRepo.WithTransaction(func(tx *sql.Tx) error {
+		var err error
+		conflicts, err = s.findConflicts(tx, archive, into)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return ErrImportConflicts
+		}
+
+		if into == nil {
+			if err := s.archiveRepo.TxInsertOrganization(tx, &archive.Organization); err != nil {
+				return err
+			}
+		}
+
+		result.Employees = 0
+		employeeIDs := make(map[string]uuid.UUID)
+		for _, list := range [][]model.ArchiveEmployee{archive.Responsibles, archive.Employees} {
+			for i := range list {
+				id, created, err := s.archiveRepo.TxEnsureEmployee(tx, &list[i])
+				if err != nil {
+					return err
+				}
+				if created {
+					result.Employees++
+				}
+				employeeIDs[list[i].Username] = id
+			}
+		}
+		for _, e := range archive.Responsibles {
+			err := s.organizationResponsibleRepo.TxInsertResponsible(tx, organizationID, employeeIDs[e.Username])
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Tenders = make(map[uuid.UUID]uuid.UUID)
+		result.Bids = make(map[uuid.UUID]uuid.UUID)
+		for i := range archive.Tenders {
+			t := &archive.Tenders[i]
+			tenderID, err := s.archiveRepo.TxInsertTender(tx, organizationID, t)
+			if err != nil {
+				return fmt.Errorf("tender %s: %w", t.ID, err)
+			}
+			result.Tenders[t.ID] = tenderID
+
+			for j := range t.Bids {
+				b := &t.Bids[j]
+				authorID := employeeIDs[b.AuthorUsername]
+				if b.AuthorType == model.AuthorTypeOrganization {
+					authorID = *b.AuthorOrganizationID
+					// the bids of the organization on its own tenders follow its id
+					if authorID == archive.Organization.ID {
+						authorID = organizationID
+					}
+				}
+				bidID, err := s.archiveRepo.TxInsertBid(tx, tenderID, authorID, b)
+				if err != nil {
+					return fmt.Errorf("bid %s: %w", b.ID, err)
+				}
+				result.Bids[b.ID] = bidID
+				for _, d := range b.Decisions {
+					err := s.archiveRepo.TxInsertDecision(tx, bidID, employeeIDs[d.ResponsibleUsername], d.Decision)
+					if err != nil {
+						return fmt.Errorf("bid %s: %w", b.ID, err)
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err == ErrImportConflicts {
+		return nil, conflicts, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// the imported tenders may be published already
+	tenderChanged(s.tenderRepo, nil)
+	return &result, nil, nil
+}
+
+func (s *ArchiveService) findConflicts(tx *sql.Tx, archive *model.OrganizationArchive, into *uuid.UUID) ([]model.ImportConflict, error) {
+	conflicts := []model.ImportConflict{}
+	conflict := func(kind, format string, args ...any) {
+		conflicts = append(conflicts, model.ImportConflict{Kind: kind, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	organizationID := archive.Organization.ID
+	if into != nil {
+		organizationID = *into
+	}
+	isPresent, err := s.organizationRepo.GetOrganizationPresent(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if into == nil && isPresent {
+		conflict("OrganizationExists", "organization %s already exists, import into it explicitly", organizationID)
+	}
+	if into != nil && !isPresent {
+		conflict("NoOrganization", "organization %s does not exist", organizationID)
+	}
+
+	// an employee is responsible for one organization at most
+	for _, e := range archive.Responsibles {
+		employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(e.Username)
+		if err == ErrNoEmployee {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		respOrganizationID, err := s.employeeRepo.GetEmployeeRespOrganization(*employeeID)
+		if err != nil && err != ErrNoEmployee {
+			return nil, err
+		}
+		if respOrganizationID != nil && *respOrganizationID != organizationID {
+			conflict("ResponsibleOfAnotherOrganization", "%s is responsible for organization %s", e.Username, *respOrganizationID)
+		}
+	}
+
+	for _, t := range archive.Tenders {
+		isPresent, err := s.archiveRepo.TxGetTenderPresent(tx, organizationID, t.Versions[0].Name, t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if isPresent {
+			conflict("TenderExists", "tender %q created at %s is already imported", t.Versions[0].Name, t.CreatedAt)
+		}
+		for _, v := range t.Versions {
+			if v.ServiceType == "" {
+				continue
+			}
+			if err := checkServiceType(v.ServiceType, s.categoryRepo); err == ErrNoServiceType {
+				conflict("UnknownServiceType", "tender %s uses the unknown service type %s", t.ID, v.ServiceType)
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		for _, b := range t.Bids {
+			if b.AuthorType != model.AuthorTypeOrganization || *b.AuthorOrganizationID == archive.Organization.ID {
+				continue
+			}
+			isPresent, err := s.organizationRepo.GetOrganizationPresent(*b.AuthorOrganizationID)
+			if err != nil {
+				return nil, err
+			}
+			if !isPresent {
+				conflict("NoAuthorOrganization", "bid %s is authored by the missing organization %s", b.ID, *b.AuthorOrganizationID)
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// validateArchive checks the references inside the archive.
+func validateArchive(archive *model.OrganizationArchive) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidArchive, fmt.Sprintf(format, args...))
+	}
+	if archive.Organization.ID == uuid.Nil || archive.Organization.Name == "" {
+		return invalid("organization needs an id and a name")
+	}
+	usernames := make(map[string]bool)
+	for _, list := range [][]model.ArchiveEmployee{archive.Responsibles, archive.Employees} {
+		for _, e := range list {
+			if e.Username == "" {
+				return invalid("an employee has no username")
+			}
+			usernames[e.Username] = true
+		}
+	}
+	for _, t := range archive.Tenders {
+		if len(t.Versions) == 0 {
+			return invalid("tender %s has no versions", t.ID)
+		}
+		for _, b := range t.Bids {
+			if len(b.Versions) == 0 {
+				return invalid("bid %s has no versions", b.ID)
+			}
+			switch b.AuthorType {
+			case model.AuthorTypeUser:
+				if !usernames[b.AuthorUsername] {
+					return invalid("bid %s: author %q is not in the archive", b.ID, b.AuthorUsername)
+				}
+			case model.AuthorTypeOrganization:
+				if b.AuthorOrganizationID == nil {
+					return invalid("bid %s has no author organization", b.ID)
+				}
+			default:
+				return invalid("bid %s: %s", b.ID, ErrWrongAuthorType)
+			}
+			for _, d := range b.Decisions {
+				if !usernames[d.ResponsibleUsername] {
+					return invalid("bid %s: decision maker %q is not in the archive", b.ID, d.ResponsibleUsername)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// EncodeArchive writes the archive as JSON, or as a ZIP file holding the JSON.
+func EncodeArchive(w io.Writer, archive *model.OrganizationArchive, compress bool) error {
+	if !compress {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(archive)
+	}
+	zw := zip.NewWriter(w)
+	f, err := zw.Create(archiveFileName)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(archive); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// DecodeArchive reads a JSON or a ZIP archive.
+func DecodeArchive(data []byte) (*model.OrganizationArchive, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+		}
+		f, err := zr.Open(archiveFileName)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+		}
+		defer f.Close()
+		if data, err = io.ReadAll(f); err != nil {
+			return nil, err
+		}
+	}
+	var archive model.OrganizationArchive
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&archive); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	return &archive, nil
+}
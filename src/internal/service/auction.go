@@ -0,0 +1,160 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoAuction         = repository.ErrNoAuction
+	ErrInvalidAuction    = errors.New("auction start price, step and duration have to be positive")
+	ErrAuctionNotRunning = errors.New("the auction is not accepting offers")
+	ErrOfferTooHigh      = errors.New("the offer has to beat the current best price by the minimum step")
+	ErrBidNotPublished   = errors.New("the bid is not published")
+)
+
+type AuctionService struct {
+	auctionRepo    *repository.AuctionRepository
+	tenderRepo     *repository.TenderRepository
+	bidRepo        *repository.BidRepository
+	evaluationRepo *repository.EvaluationRepository
+	categoryRepo   *repository.CategoryRepository
+	auth           *Authorizer
+}
+
+func NewAuctionService() *AuctionService {
+	auctionRepo := repository.NewAuctionRepository()
+	tenderRepo := repository.NewTenderRepository()
+	bidRepo := repository.NewBidRepository()
+	evaluationRepo := repository.NewEvaluationRepository()
+	categoryRepo := repository.NewCategoryRepository()
+	return &AuctionService{
+		auctionRepo:    auctionRepo,
+		tenderRepo:     tenderRepo,
+		bidRepo:        bidRepo,
+		evaluationRepo: evaluationRepo,
+		categoryRepo:   categoryRepo,
+		auth:           NewAuthorizer(),
+	}
+}
+
+// InsertNewAuctionTender creates a reverse auction tender.
+// The auction clock starts when the tender is published.
+func (s *AuctionService) InsertNewAuctionTender(ctx context.Context, t *model.Tender, a *model.Auction, criteria []model.Criterion, username string) error {
+	if a.StartPrice <= 0 || a.MinStep <= 0 || a.DurationSeconds <= 0 || a.ExtensionSeconds < 0 {
+		return ErrInvalidAuction
+	}
+	if err := validateCriteria(criteria); err != nil {
+		return err
+	}
+	caller, err := s.auth.Caller(ctx, username)
+	if err != nil {
+		return err
+	}
+	if !caller.IsResponsible(t.OrganizationID) {
+		return ErrNotResponsible
+	}
+	if err := checkServiceType(t.ServiceType, s.categoryRepo); err != nil {
+		return err
+	}
+	return s.auctionRepo.WithTransaction(func(tx *sql.Tx) error {
+		err := s.tenderRepo.TxInsertNewTender(tx, t)
+		if err != nil {
+			return err
+		}
+		a.TenderID = t.ID
+		err = s.auctionRepo.TxInsertAuction(tx, a)
+		if err != nil {
+			return err
+		}
+		return s.evaluationRepo.TxInsertCriteria(tx, t.ID, criteria)
+	})
+}
+
+func (s *AuctionService) GetAuction(ctx context.Context, tenderID uuid.UUID, username *string) (*model.Auction, error) {
+	// the auction may have run out since the last tick of the closing loop
+	if _, err := s.closeExpiredAuctions(&tenderID); err != nil {
+		return nil, err
+	}
+	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.auth.TenderViewer(ctx, currentTender, username)
+	if err != nil {
+		return nil, err
+	}
+	return s.auctionRepo.GetAuctionByTenderID(tenderID)
+}
+
+func (s *AuctionService) SubmitOffer(ctx context.Context, bidID uuid.UUID, username string, price float64) (*model.AuctionOffer, error) {
+	currentBid, _, err := s.auth.BidAuthor(ctx, username, bidID)
+	if err != nil {
+		return nil, err
+	}
+	if currentBid.Status == model.BidCanceled {
+		return nil, ErrBidCanceled
+	}
+	if currentBid.Status != model.BidPublished {
+		return nil, ErrBidNotPublished
+	}
+
+	offer := model.AuctionOffer{
+		TenderID: currentBid.TenderID,
+		BidID:    bidID,
+		Price:    price,
+	}
+	err = s.auctionRepo.WithTransaction(func(tx *sql.Tx) error {
+		auction, running, err := s.auctionRepo.TxGetAuctionForUpdate(tx, currentBid.TenderID)
+		if err != nil {
+			return err
+		}
+		if !running {
+			return ErrAuctionNotRunning
+		}
+		threshold := auction.StartPrice
+		if auction.BestPrice != nil {
+			threshold = *auction.BestPrice - auction.MinStep
+		}
+		// prices are stored with two decimal places
+		if price <= 0 || math.Round(price*100) > math.Round(threshold*100) {
+			return ErrOfferTooHigh
+		}
+		return s.auctionRepo.TxPlaceOffer(tx, &offer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func (s *AuctionService) GetAuctionOffers(ctx context.Context, tenderID uuid.UUID, username *string, limit, offset int) ([]model.AuctionOffer, error) {
+	if _, err := s.GetAuction(ctx, tenderID, username); err != nil {
+		return nil, err
+	}
+	return s.auctionRepo.GetAuctionOffers(tenderID, limit, offset)
+}
+
+// CloseExpiredAuctions finishes the auctions whose time has run out.
+func (s *AuctionService) CloseExpiredAuctions() (int64, error) {
+	return s.closeExpiredAuctions(nil)
+}
+
+// closeExpiredAuctions finishes the auction of the tender, or all of them if tenderID is nil.
+func (s *AuctionService) closeExpiredAuctions(tenderID *uuid.UUID) (int64, error) {
+	change, err := checkTenderTransition(model.TenderPublished, model.TenderClosed, model.ActorSystem)
+	if err != nil {
+		return 0, err
+	}
+	closed, err := s.auctionRepo.CloseExpiredAuctions(tenderID, change)
+	if err == nil && closed > 0 {
+		tenderChanged(s.tenderRepo, tenderID)
+	}
+	return closed, err
+}
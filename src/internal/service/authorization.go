@@ -0,0 +1,224 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Authorizer answers whether the caller can act on a tender or a bid.
+// The first check of a request reads the bid or the tender along with the
+// caller and all of the organizations the caller is responsible for in one
+// query, the caller is then kept in the request context, see WithCallerCache,
+// and the later checks only read the bid or the tender. The bid or the tender
+// is returned for the endpoint to go on with.
+type Authorizer struct {
+	authorizationRepo           *repository.AuthorizationRepository
+	organizationResponsibleRepo *repository.OrganizationResponsibleRepository
+	invitationRepo              *repository.InvitationRepository
+}
+
+func NewAuthorizer() *Authorizer {
+	authorizationRepo := repository.NewAuthorizationRepository()
+	organizationResponsibleRepo := repository.NewOrganizationResponsibleRepository()
+	invitationRepo := repository.NewInvitationRepository()
+	return &Authorizer{
+		authorizationRepo:           authorizationRepo,
+		organizationResponsibleRepo: organizationResponsibleRepo,
+		invitationRepo:              invitationRepo,
+	}
+}
+
+type callerCacheKey struct{}
+
+type callerCache struct {
+	mu      sync.Mutex
+	callers map[string]*model.Caller
+}
+
+// WithCallerCache makes the callers resolved with the returned context
+// last until the end of the request.
+func WithCallerCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, callerCacheKey{}, &callerCache{callers: map[string]*model.Caller{}})
+}
+
+// Caller resolves the employee by username, ErrNoEmployee if there is none,
+// or ErrCertificateMismatch if the client certificate of the request wasn't
+// issued to the employee. Without WithCallerCache the employee is read every time.
+func (a *Authorizer) Caller(ctx context.Context, username string) (*model.Caller, error) {
+	cached := cachedCaller(ctx, username)
+	var resolved *model.Caller
+	if cached == nil {
+		var err error
+		if resolved, err = a.authorizationRepo.GetCaller(username); err != nil {
+			return nil, err
+		}
+	}
+	return admitCaller(ctx, cached, resolved)
+}
+
+func cachedCaller(ctx context.Context, username string) *model.Caller {
+	cache, _ := ctx.Value(callerCacheKey{}).(*callerCache)
+	if cache == nil {
+		return nil
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return cache.callers[username]
+}
+
+// callerLookup is the username the access query of a check resolves,
+// none if the caller is cached already.
+func callerLookup(username string, cached *model.Caller) string {
+	if cached != nil {
+		return ""
+	}
+	return username
+}
+
+// admitCaller takes the cached caller or else the resolved one, ErrNoEmployee
+// if neither is there, checks it against the client certificate
+// and caches it for the rest of the request.
+func admitCaller(ctx context.Context, cached, resolved *model.Caller) (*model.Caller, error) {
+	caller := cached
+	if caller == nil {
+		caller = resolved
+	}
+	if caller == nil {
+		return nil, ErrNoEmployee
+	}
+	if err := checkClientIdentity(ctx, caller); err != nil {
+		return nil, err
+	}
+	if cached == nil {
+		if cache, _ := ctx.Value(callerCacheKey{}).(*callerCache); cache != nil {
+			cache.mu.Lock()
+			cache.callers[caller.Username] = caller
+			cache.mu.Unlock()
+		}
+	}
+	addWriters(ctx, caller.Username)
+	return caller, nil
+}
+
+// BidAuthor lets the author of the bid act on it: the employee who placed
+// a User bid or a responsible of the organization of an Organization bid.
+func (a *Authorizer) BidAuthor(ctx context.Context, username string, bidID uuid.UUID) (*model.Bid, *model.Caller, error) {
+	cached := cachedCaller(ctx, username)
+	bid, _, resolved, err := a.authorizationRepo.GetBidAccess(callerLookup(username, cached), bidID)
+	if err != nil {
+		return nil, nil, err
+	}
+	caller, err := admitCaller(ctx, cached, resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !caller.IsBidAuthor(bid) {
+		return nil, nil, ErrNotResponsible
+	}
+	return bid, caller, nil
+}
+
+// BidTenderResponsible lets the responsibles of the tender act on a published bid,
+// the other bids don't exist for them.
+func (a *Authorizer) BidTenderResponsible(ctx context.Context, username string, bidID uuid.UUID) (*model.Bid, *model.Caller, error) {
+	cached := cachedCaller(ctx, username)
+	bid, tenderOrganizationID, resolved, err := a.authorizationRepo.GetBidAccess(callerLookup(username, cached), bidID)
+	if err == ErrNoBid {
+		// the caller is reported before the bid
+		if _, err := a.Caller(ctx, username); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	caller, err := admitCaller(ctx, cached, resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bid.Status != model.BidPublished {
+		return nil, nil, ErrNoBid
+	}
+	if !caller.IsResponsible(tenderOrganizationID) {
+		return nil, nil, ErrNotResponsible
+	}
+	return bid, caller, nil
+}
+
+// TenderResponsible lets the responsibles of the organization act on its tender.
+func (a *Authorizer) TenderResponsible(ctx context.Context, username string, tenderID uuid.UUID) (*model.Tender, *model.Caller, error) {
+	cached := cachedCaller(ctx, username)
+	tender, resolved, err := a.authorizationRepo.GetTenderAccess(callerLookup(username, cached), tenderID)
+	if err == ErrNoTender {
+		// the caller is reported before the tender
+		if _, err := a.Caller(ctx, username); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	caller, err := admitCaller(ctx, cached, resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !caller.IsResponsible(tender.OrganizationID) {
+		return nil, nil, ErrNotResponsible
+	}
+	return tender, caller, nil
+}
+
+// Admin lets the employees listed in ADMIN_USERNAMES through.
+func (a *Authorizer) Admin(ctx context.Context, username string, admins map[string]bool) error {
+	if _, err := a.Caller(ctx, username); err != nil {
+		return err
+	}
+	if !admins[username] {
+		return ErrNotAdmin
+	}
+	return nil
+}
+
+// TenderViewer lets anyone see a published public tender. Unpublished
+// tenders are visible to their responsibles only, invite-only tenders are
+// visible to the responsibles of the invited organizations as well.
+func (a *Authorizer) TenderViewer(ctx context.Context, tender *model.Tender, username *string) error {
+	// return immediately if the tender is public
+	if tender.Status == model.TenderPublished && tender.Visibility != model.TenderInviteOnly {
+		return nil
+	}
+	// otherwise (not public) return responsibility error if no username is provided
+	if username == nil {
+		return ErrNotResponsible
+	}
+	caller, err := a.Caller(ctx, *username)
+	if err != nil {
+		return err
+	}
+	return a.CallerViewsTender(tender, caller)
+}
+
+// CallerViewsTender is TenderViewer for the caller resolved already.
+func (a *Authorizer) CallerViewsTender(tender *model.Tender, caller *model.Caller) error {
+	if tender.Status == model.TenderPublished && tender.Visibility != model.TenderInviteOnly {
+		return nil
+	}
+	if caller.IsResponsible(tender.OrganizationID) {
+		return nil
+	}
+	if tender.Status != model.TenderPublished {
+		return ErrNotResponsible
+	}
+	isInvited, err := a.invitationRepo.GetIfEmployeeIsInvited(tender.ID, caller.EmployeeID)
+	if err != nil {
+		return err
+	}
+	if !isInvited {
+		return ErrNotResponsible
+	}
+	return nil
+}
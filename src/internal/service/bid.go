@@ -3,6 +3,7 @@ package service
 import (
 	"avito-back-test/internal/model"
 	"avito-back-test/internal/repository"
+	"context"
 	"errors"
 	"github.com/google/uuid"
 )
@@ -20,6 +21,11 @@ type BidService struct {
 	employeeRepo                *repository.EmployeeRepository
 	organizationResponsibleRepo *repository.OrganizationResponsibleRepository
 	organizationRepo            *repository.OrganizationRepository
+	lotRepo                     *repository.LotRepository
+	invitationRepo              *repository.InvitationRepository
+	qualificationRepo           *repository.QualificationRepository
+	conflictRepo                *repository.ConflictRepository
+	auth                        *Authorizer
 }
 
 func NewBidService() *BidService {
@@ -28,16 +34,27 @@ func NewBidService() *BidService {
 	organizationResponsibleRepo := repository.NewOrganizationResponsibleRepository()
 	employeeRepo := repository.NewEmployeeRepository()
 	orgagizationRepo := repository.NewOrganizationRepository()
+	lotRepo := repository.NewLotRepository()
+	invitationRepo := repository.NewInvitationRepository()
+	qualificationRepo := repository.NewQualificationRepository()
+	conflictRepo := repository.NewConflictRepository()
 	return &BidService{
 		bidRepo:                     bidRepo,
 		tenderRepo:                  tenderRepo,
 		organizationResponsibleRepo: organizationResponsibleRepo,
 		employeeRepo:                employeeRepo,
 		organizationRepo:            orgagizationRepo,
+		lotRepo:                     lotRepo,
+		invitationRepo:              invitationRepo,
+		qualificationRepo:           qualificationRepo,
+		conflictRepo:                conflictRepo,
+		auth:                        NewAuthorizer(),
 	}
 }
 
-func (s *BidService) InsertNewBid(b *model.Bid) error {
+func (s *BidService) InsertNewBid(ctx context.Context, b *model.Bid) error {
+	// the organization behind the bid
+	var organizationID *uuid.UUID
 	if b.AuthorType == model.AuthorTypeOrganization {
 		idIsPresent, err := s.organizationRepo.GetOrganizationPresent(b.AuthorID)
 		if err != nil {
@@ -46,6 +63,7 @@ func (s *BidService) InsertNewBid(b *model.Bid) error {
 		if !idIsPresent {
 			return ErrNoOrganization
 		}
+		organizationID = &b.AuthorID
 	} else if b.AuthorType == model.AuthorTypeUser {
 		idIsPresent, err := s.employeeRepo.GetEmployeePresent(b.AuthorID)
 		if err != nil {
@@ -55,7 +73,7 @@ func (s *BidService) InsertNewBid(b *model.Bid) error {
 			return ErrNoEmployee
 		}
 		// check if the user is responsible
-		_, err = s.employeeRepo.GetEmployeeRespOrganization(b.AuthorID)
+		organizationID, err = s.employeeRepo.GetEmployeeRespOrganization(b.AuthorID)
 		if err == ErrNoEmployee {
 			return ErrNotResponsible
 		}
@@ -65,6 +83,9 @@ func (s *BidService) InsertNewBid(b *model.Bid) error {
 	} else {
 		return ErrWrongAuthorType
 	}
+	if err := s.auth.ClientBidAuthor(ctx, b); err != nil {
+		return err
+	}
 	ten, err := s.tenderRepo.GetLastTenderByID(b.TenderID)
 	if err != nil {
 		return err
@@ -72,41 +93,86 @@ func (s *BidService) InsertNewBid(b *model.Bid) error {
 	if ten.Status != model.TenderPublished {
 		return ErrNoTender
 	}
+	err = checkBidderConflict(b, ten, *organizationID, s.organizationResponsibleRepo, s.conflictRepo)
+	if err != nil {
+		return err
+	}
+	// invite-only tenders accept bids from the invited organizations only
+	if ten.Visibility == model.TenderInviteOnly {
+		var isInvited bool
+		if b.AuthorType == model.AuthorTypeOrganization {
+			isInvited, err = s.invitationRepo.GetIfOrganizationIsInvited(b.TenderID, b.AuthorID)
+		} else {
+			isInvited, err = s.invitationRepo.GetIfEmployeeIsInvited(b.TenderID, b.AuthorID)
+		}
+		if err != nil {
+			return err
+		}
+		if !isInvited {
+			return ErrNoTender
+		}
+	}
+	// tenders with a questionnaire accept bids from qualified organizations only
+	hasQuestionnaire, err := s.qualificationRepo.GetTenderHasQuestionnaire(b.TenderID)
+	if err != nil {
+		return err
+	}
+	if hasQuestionnaire {
+		isQualified, err := s.qualificationRepo.GetIfOrganizationIsQualified(b.TenderID, *organizationID)
+		if err != nil {
+			return err
+		}
+		if !isQualified {
+			return ErrNotQualified
+		}
+	}
+	// bids on a tender with lots are placed against a specific open lot
+	if b.LotID != nil {
+		lot, err := s.lotRepo.GetLotByID(*b.LotID)
+		if err != nil {
+			return err
+		}
+		if lot.TenderID != b.TenderID {
+			return ErrNoLot
+		}
+		if lot.Status != model.LotOpen {
+			return ErrLotDecided
+		}
+	} else {
+		hasLots, err := s.lotRepo.GetTenderHasLots(b.TenderID)
+		if err != nil {
+			return err
+		}
+		if hasLots {
+			return ErrLotRequired
+		}
+	}
 
-	return s.bidRepo.InsertNewBid(b)
+	if err := s.bidRepo.InsertNewBid(b); err != nil {
+		return err
+	}
+	s.auth.wroteBid(ctx, b)
+	return nil
 }
 
-func (s *BidService) GetUserBids(username string, limit, offset int) ([]model.Bid, error) {
+func (s *BidService) GetUserBids(ctx context.Context, username string, includeHistory bool, limit, offset int) ([]model.Bid, error) {
 	// check username validity
-	employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(username)
+	caller, err := s.auth.Caller(ctx, username)
 	if err != nil {
 		return nil, err
 	}
-	return s.bidRepo.GetUserBids(*employeeID, limit, offset)
+	return s.bidRepo.GetUserBids(caller.EmployeeID, includeHistory, limit, offset)
 }
 
-func (s *BidService) GetBidsByTender(tenderID uuid.UUID, username string, limit, offset int) ([]model.Bid, error) {
-	// check username validity
-	employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(username)
-	if err != nil {
-		return nil, err
-	}
+func (s *BidService) GetBidsByTender(ctx context.Context, tenderID uuid.UUID, lotID *uuid.UUID, username string, limit, offset int) ([]model.Bid, error) {
 	// check if the user is responsible for the tender
-	tender, err := s.tenderRepo.GetLastTenderByID(tenderID)
-	if err != nil {
+	if _, _, err := s.auth.TenderResponsible(ctx, username, tenderID); err != nil {
 		return nil, err
 	}
-	isResponsible, err := s.organizationResponsibleRepo.GetIfEmployeeIsResponsible(employeeID, &tender.OrganizationID)
-	if err != nil {
-		return nil, err
-	}
-	if !isResponsible {
-		return nil, ErrNotResponsible
-	}
-	return s.bidRepo.GetPublicBidsByTender(tenderID, limit, offset)
+	return s.bidRepo.GetPublicBidsByTender(username, tenderID, lotID, limit, offset)
 }
 
-func (s *BidService) GetBidStatus(bidID uuid.UUID, username string) (string, error) {
+func (s *BidService) GetBidStatus(ctx context.Context, bidID uuid.UUID, username string) (string, error) {
 	currentBid, err := s.bidRepo.GetLastBidByID(bidID)
 	if err != nil {
 		return "", err
@@ -115,138 +181,93 @@ func (s *BidService) GetBidStatus(bidID uuid.UUID, username string) (string, err
 	if currentBid.Status == model.BidPublished {
 		return currentBid.Status, nil
 	}
-	err = authorizeUserForBid(username, currentBid, s.employeeRepo, s.organizationResponsibleRepo)
+	caller, err := s.auth.Caller(ctx, username)
 	if err != nil {
 		return "", err
 	}
+	if !caller.IsBidAuthor(currentBid) {
+		return "", ErrNotResponsible
+	}
 	return currentBid.Status, nil
 }
 
-func (s *BidService) UpdateBidStatus(b *model.Bid, username string) error {
-	currentBid, err := s.bidRepo.GetLastBidByID(b.ID)
+func (s *BidService) UpdateBidStatus(ctx context.Context, b *model.Bid, username string) error {
+	currentBid, _, err := s.auth.BidAuthor(ctx, username, b.ID)
 	if err != nil {
 		return err
 	}
-	err = authorizeUserForBid(username, currentBid, s.employeeRepo, s.organizationResponsibleRepo)
+	change, err := checkBidTransition(currentBid.Status, b.Status, model.ActorAuthor)
 	if err != nil {
 		return err
 	}
-	if currentBid.Status == model.BidCanceled {
-		return ErrBidCanceled
+	if err := s.bidRepo.ChangeBidStatus(b.ID, change); err != nil {
+		return changeStatusError(err)
 	}
-	return s.bidRepo.UpdateBidStatus(b)
+	updated := *currentBid
+	updated.Status = b.Status
+	*b = updated
+	return nil
 }
 
-func (s *BidService) PatchBid(bidID uuid.UUID, username string, update *model.BidUpdate) (*model.Bid, error) {
-	currentBid, err := s.bidRepo.GetLastBidByID(bidID)
+// GetBidTransitions lists the statuses the bid author can move the bid to.
+func (s *BidService) GetBidTransitions(ctx context.Context, bidID uuid.UUID, username string) (*model.StatusTransitions, error) {
+	currentBid, _, err := s.auth.BidAuthor(ctx, username, bidID)
 	if err != nil {
 		return nil, err
 	}
-	err = authorizeUserForBid(username, currentBid, s.employeeRepo, s.organizationResponsibleRepo)
+	statuses, err := availableBidStatuses(s, currentBid, model.ActorAuthor)
 	if err != nil {
 		return nil, err
 	}
-	if currentBid.Status == model.BidCanceled {
-		return nil, ErrBidCanceled
-	}
-	return s.bidRepo.PatchBid(currentBid.ID, update)
+	return &model.StatusTransitions{
+		Status:            currentBid.Status,
+		AvailableStatuses: statuses,
+	}, nil
 }
 
-func (s *BidService) RollbackBid(bidID uuid.UUID, username string, version int) (*model.Bid, error) {
-	currentBid, err := s.bidRepo.GetLastBidByID(bidID)
-	if err != nil {
-		return nil, err
-	}
-	err = authorizeUserForBid(username, currentBid, s.employeeRepo, s.organizationResponsibleRepo)
+func (s *BidService) PatchBid(ctx context.Context, bidID uuid.UUID, username string, update *model.BidUpdate) (*model.Bid, error) {
+	currentBid, _, err := s.auth.BidAuthor(ctx, username, bidID)
 	if err != nil {
 		return nil, err
 	}
 	if currentBid.Status == model.BidCanceled {
 		return nil, ErrBidCanceled
 	}
-	return s.bidRepo.RollbackBid(bidID, version)
+	return s.bidRepo.PatchBid(currentBid, update)
 }
 
-func (s *BidService) LeaveFeedback(username string, bidID uuid.UUID, feedback string) (*model.Bid, error) {
-	userID, err := s.employeeRepo.GetEmployeeIDByUsername(username)
-	if err != nil {
-		return nil, err
-	}
-	err = authorizeTenderResponsibleForBid(*userID, bidID, s.tenderRepo, s.bidRepo, s.organizationResponsibleRepo)
+func (s *BidService) RollbackBid(ctx context.Context, bidID uuid.UUID, username string, version int) (*model.Bid, error) {
+	currentBid, _, err := s.auth.BidAuthor(ctx, username, bidID)
 	if err != nil {
 		return nil, err
 	}
-	return s.bidRepo.LeaveReview(bidID, feedback)
-}
-
-func authorizeUserForBid(username string, bid *model.Bid,
-	employeeRepo *repository.EmployeeRepository,
-	organizationResponsibleRepo *repository.OrganizationResponsibleRepository) error {
-	employeeID, err := employeeRepo.GetEmployeeIDByUsername(username)
-	if err != nil {
-		return err
-	}
-	if bid.AuthorType == model.AuthorTypeUser && bid.AuthorID == *employeeID {
-		return nil
-	} else if bid.AuthorType == model.AuthorTypeOrganization {
-		isResponsible, err := organizationResponsibleRepo.GetIfEmployeeIsResponsible(employeeID, &bid.AuthorID)
-		if err != nil {
-			return err
-		}
-		if isResponsible {
-			return nil
-		}
+	if currentBid.Status == model.BidCanceled {
+		return nil, ErrBidCanceled
 	}
-	return ErrNotResponsible
+	return s.bidRepo.RollbackBid(currentBid, version)
 }
 
-func authorizeTenderResponsibleForBid(userID, bidID uuid.UUID,
-	tenderRepo *repository.TenderRepository, bidRepo *repository.BidRepository,
-	organizationResponsibleRepo *repository.OrganizationResponsibleRepository) error {
-	currenctBid, err := bidRepo.GetLastBidByID(bidID)
-	if err != nil {
-		return err
-	}
-	if currenctBid.Status != model.BidPublished {
-		return ErrNoBid
-	}
-	// authorize tender responsible
-	tender, err := tenderRepo.GetLastTenderByID(currenctBid.TenderID)
+func (s *BidService) LeaveFeedback(ctx context.Context, username string, bidID uuid.UUID, feedback string) (*model.Bid, error) {
+	currentBid, _, err := s.auth.BidTenderResponsible(ctx, username, bidID)
 	if err != nil {
-		return err
-	}
-	isResponsible, err := organizationResponsibleRepo.GetIfEmployeeIsResponsible(&userID, &tender.OrganizationID)
-	if err != nil {
-		return err
+		return nil, err
 	}
-	if !isResponsible {
-		return ErrNotResponsible
+	if err := s.bidRepo.LeaveReview(bidID, feedback); err != nil {
+		return nil, err
 	}
-	return nil
+	return currentBid, nil
 }
 
-func (s *BidService) GetTenderReviewsOnUser(tenderID uuid.UUID, authorUsername, requesterUsername string,
+func (s *BidService) GetTenderReviewsOnUser(ctx context.Context, tenderID uuid.UUID, authorUsername, requesterUsername string,
 	limit, offset int) ([]model.BidReview, error) {
 
-	requesterID, err := s.employeeRepo.GetEmployeeIDByUsername(requesterUsername)
-	if err != nil {
-		return nil, err
-	}
-	tender, err := s.tenderRepo.GetLastTenderByID(tenderID)
-	if err != nil {
+	if _, _, err := s.auth.TenderResponsible(ctx, requesterUsername, tenderID); err != nil {
 		return nil, err
 	}
-	isResponsible, err := s.organizationResponsibleRepo.GetIfEmployeeIsResponsible(requesterID, &tender.OrganizationID)
-	if err != nil {
-		return nil, err
-	}
-	if !isResponsible {
-		return nil, ErrNotResponsible
-	}
 	bidUserID, err := s.employeeRepo.GetEmployeeIDByUsername(authorUsername)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.bidRepo.GetTenderReviewsOnUser(tenderID, *bidUserID, limit, offset)
+	return s.bidRepo.GetTenderReviewsOnUser(requesterUsername, tenderID, *bidUserID, limit, offset)
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"avito-back-test/internal/model"
 	"avito-back-test/internal/repository"
+	"context"
 	"database/sql"
 	"sync"
 
@@ -17,43 +18,75 @@ type BidDecisionService struct {
 	tenderRepo              *repository.TenderRepository
 	employeeRepo            *repository.EmployeeRepository
 	organizationResponsRepo *repository.OrganizationResponsibleRepository
+	lotRepo                 *repository.LotRepository
+	conflictRepo            *repository.ConflictRepository
+	auth                    *Authorizer
+	// quorum caps the number of approvals a bid needs
+	quorum int
 }
 
-func NewBidDecisionService() *BidDecisionService {
+func NewBidDecisionService(quorum int) *BidDecisionService {
 	bidDesRepo := repository.NewBidDecisionRepository()
 	bidRepo := repository.NewBidRepository()
 	tenderRepo := repository.NewTenderRepository()
 	emploRepo := repository.NewEmployeeRepository()
 	orgRespRepo := repository.NewOrganizationResponsibleRepository()
+	lotRepo := repository.NewLotRepository()
+	conflictRepo := repository.NewConflictRepository()
 	return &BidDecisionService{
 		bidDecisionRepo:         bidDesRepo,
 		bidRepo:                 bidRepo,
 		tenderRepo:              tenderRepo,
 		employeeRepo:            emploRepo,
 		organizationResponsRepo: orgRespRepo,
+		lotRepo:                 lotRepo,
+		conflictRepo:            conflictRepo,
+		auth:                    NewAuthorizer(),
+		quorum:                  quorum,
 	}
 }
 
-func (s *BidDecisionService) SubmitDecision(bidID uuid.UUID, username string, decision string) (*model.Bid, error) {
+func (s *BidDecisionService) SubmitDecision(ctx context.Context, bidID uuid.UUID, username string, decision string) (*model.Bid, error) {
 	bidDecisionMutex.Lock()
 	defer bidDecisionMutex.Unlock()
 
-	userID, err := s.employeeRepo.GetEmployeeIDByUsername(username)
+	currentBid, caller, err := s.auth.BidTenderResponsible(ctx, username, bidID)
 	if err != nil {
 		return nil, err
 	}
-	err = authorizeTenderResponsibleForBid(*userID, bidID, s.tenderRepo, s.bidRepo, s.organizationResponsRepo)
+	userID := caller.EmployeeID
+	err = checkEvaluatorConflict(userID, currentBid, model.ConflictDecision,
+		s.employeeRepo, s.organizationResponsRepo, s.conflictRepo)
 	if err != nil {
 		return nil, err
 	}
-	currentBid, err := s.bidRepo.GetLastBidByID(bidID)
-	if err != nil {
-		return nil, err
+	if currentBid.LotID != nil {
+		lot, err := s.lotRepo.GetLotByID(*currentBid.LotID)
+		if err != nil {
+			return nil, err
+		}
+		if lot.Status != model.LotOpen {
+			return nil, ErrLotDecided
+		}
 	}
 
+	closed := false
 	err = s.bidDecisionRepo.WithTransaction(func(tx *sql.Tx) error {
+		// the transaction may run again after a retryable failure
+		closed = false
 
-		err := s.bidDecisionRepo.TxInsertUpdateDecision(tx, bidID, *userID, decision)
+		// a bid on the whole tender can't close it while its lots are open
+		if currentBid.LotID == nil {
+			openLots, err := s.lotRepo.TxCountOpenLots(tx, currentBid.TenderID)
+			if err != nil {
+				return err
+			}
+			if openLots > 0 {
+				return ErrLotRequired
+			}
+		}
+
+		err := s.bidDecisionRepo.TxInsertUpdateDecision(tx, bidID, userID, decision)
 		if err != nil {
 			return err
 		}
@@ -63,25 +96,56 @@ func (s *BidDecisionService) SubmitDecision(bidID uuid.UUID, username string, de
 			return err
 		}
 		if contra > 0 {
-			err = s.bidRepo.TxSetBidStatus(tx, bidID, model.BidCanceled)
+			change, err := checkBidTransition(model.BidPublished, model.BidCanceled, model.ActorSystem)
 			if err != nil {
 				return err
 			}
+			return changeStatusError(s.bidRepo.TxChangeBidStatus(tx, bidID, change))
+		}
+		organizationRespCount, err := s.organizationResponsRepo.TxGetResponsibleCountByEmployee(tx, userID)
+		if err != nil {
+			return err
+		}
+		// recused responsibles don't vote, so they don't count towards the quorum
+		recusedCount, err := s.conflictRepo.TxCountRecusals(tx, currentBid.TenderID)
+		if err != nil {
+			return err
+		}
+		quorum := max(min(organizationRespCount-recusedCount, s.quorum), 1)
+		if pro < quorum {
 			return nil
 		}
-		organizationRespCount, err := s.organizationResponsRepo.TxGetResponsibleCountByEmployee(tx, *userID)
+		// a bid on a lot decides the lot only,
+		// the tender is closed once all of its lots are decided
+		if currentBid.LotID != nil {
+			err = s.lotRepo.TxDecideLot(tx, *currentBid.LotID, bidID)
+			if err == repository.ErrNoLot {
+				return ErrLotDecided
+			}
+			if err != nil {
+				return err
+			}
+			openLots, err := s.lotRepo.TxCountOpenLots(tx, currentBid.TenderID)
+			if err != nil || openLots > 0 {
+				return err
+			}
+		}
+		change, err := checkTenderTransition(model.TenderPublished, model.TenderClosed, model.ActorSystem)
 		if err != nil {
 			return err
 		}
-		quorum := min(organizationRespCount, 3)
-		if pro >= quorum {
-			err = s.tenderRepo.TxUpdateTenderStatus(tx, currentBid.TenderID, model.TenderClosed)
+		if err := s.tenderRepo.TxChangeTenderStatus(tx, currentBid.TenderID, change); err != nil {
+			return changeStatusError(err)
 		}
-		return err
+		closed = true
+		return nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
+	if closed {
+		tenderChanged(s.tenderRepo, &currentBid.TenderID)
+	}
 	return s.bidRepo.GetLastBidByID(bidID)
 }
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+)
+
+var (
+	ErrNoCategory      = repository.ErrNoCategory
+	ErrNoServiceType   = errors.New("unknown service type")
+	ErrNotAdmin        = errors.New("the employee is not an administrator")
+	ErrInvalidCategory = errors.New("category needs a code of letters, digits, dots, dashes or underscores and display names")
+	ErrCategoryCycle   = errors.New("a category can't be moved under itself or its subcategories")
+	ErrCategoryInUse   = errors.New("the category has subcategories or is used by tenders")
+	ErrCategoryExists  = errors.New("a category with this code already exists")
+)
+
+var (
+	categoryCodeRegexp = regexp.MustCompile(`^[A-Za-z0-9._-]{1,50}$`)
+	localeRegexp       = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+)
+
+type CategoryService struct {
+	categoryRepo *repository.CategoryRepository
+	tenderRepo   *repository.TenderRepository
+	admins       map[string]bool
+	auth         *Authorizer
+}
+
+// NewCategoryService creates the catalog service, only the employees
+// listed in adminUsernames may change the catalog.
+func NewCategoryService(adminUsernames []string) *CategoryService {
+	categoryRepo := repository.NewCategoryRepository()
+	tenderRepo := repository.NewTenderRepository()
+	return &CategoryService{
+		categoryRepo: categoryRepo,
+		tenderRepo:   tenderRepo,
+		admins:       newAdmins(adminUsernames),
+		auth:         NewAuthorizer(),
+	}
+}
+
+func (s *CategoryService) GetCategories(locale string) ([]model.ServiceCategory, error) {
+	categories, err := s.categoryRepo.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	for i := range categories {
+		localizeCategory(&categories[i], locale)
+	}
+	return categories, nil
+}
+
+func (s *CategoryService) GetCategory(code, locale string) (*model.ServiceCategory, error) {
+	c, err := s.categoryRepo.GetCategoryByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	localizeCategory(c, locale)
+	return c, nil
+}
+
+func (s *CategoryService) InsertCategory(ctx context.Context, c *model.ServiceCategory, username, locale string) error {
+	if err := s.authorizeAdmin(ctx, username); err != nil {
+		return err
+	}
+	if !categoryCodeRegexp.MatchString(c.Code) || len(c.Names) == 0 || !validCategoryNames(c.Names) {
+		return ErrInvalidCategory
+	}
+	exists, err := s.categoryRepo.GetCategoryPresent(c.Code)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrCategoryExists
+	}
+	if c.ParentCode != nil {
+		isPresent, err := s.categoryRepo.GetCategoryPresent(*c.ParentCode)
+		if err != nil {
+			return err
+		}
+		if !isPresent {
+			return ErrNoCategory
+		}
+	}
+	err = s.categoryRepo.WithTransaction(func(tx *sql.Tx) error {
+		err := s.categoryRepo.TxInsertCategory(tx, c)
+		if err != nil {
+			return err
+		}
+		return s.categoryRepo.TxReplaceNames(tx, c.Code, c.Names)
+	})
+	if err != nil {
+		return err
+	}
+	localizeCategory(c, locale)
+	return nil
+}
+
+// UpdateCategory moves the category under another parent or to the top level
+// when the parent code is empty, and replaces its display names if given.
+func (s *CategoryService) UpdateCategory(ctx context.Context, code string, update *model.ServiceCategoryUpdate,
+	username, locale string) (*model.ServiceCategory, error) {
+
+	if err := s.authorizeAdmin(ctx, username); err != nil {
+		return nil, err
+	}
+	if update.Names != nil && (len(update.Names) == 0 || !validCategoryNames(update.Names)) {
+		return nil, ErrInvalidCategory
+	}
+	if _, err := s.categoryRepo.GetCategoryByCode(code); err != nil {
+		return nil, err
+	}
+	var parentCode *string
+	if update.ParentCode != nil && *update.ParentCode != "" {
+		parentCode = update.ParentCode
+		isPresent, err := s.categoryRepo.GetCategoryPresent(*parentCode)
+		if err != nil {
+			return nil, err
+		}
+		if !isPresent {
+			return nil, ErrNoCategory
+		}
+		isDescendant, err := s.categoryRepo.GetIfCategoryIsDescendant(*parentCode, code)
+		if err != nil {
+			return nil, err
+		}
+		if isDescendant {
+			return nil, ErrCategoryCycle
+		}
+	}
+	err := s.categoryRepo.WithTransaction(func(tx *sql.Tx) error {
+		if update.ParentCode != nil {
+			if err := s.categoryRepo.TxUpdateParent(tx, code, parentCode); err != nil {
+				return err
+			}
+		}
+		if update.Names != nil {
+			return s.categoryRepo.TxReplaceNames(tx, code, update.Names)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the listings of a service type include the tenders of its subcategories
+	if update.ParentCode != nil {
+		tenderChanged(s.tenderRepo, nil)
+	}
+	return s.GetCategory(code, locale)
+}
+
+// DeleteCategory removes a leaf category nobody refers to.
+func (s *CategoryService) DeleteCategory(ctx context.Context, code, username string) error {
+	if err := s.authorizeAdmin(ctx, username); err != nil {
+		return err
+	}
+	inUse, err := s.categoryRepo.GetCategoryInUse(code)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrCategoryInUse
+	}
+	return s.categoryRepo.DeleteCategory(code)
+}
+
+func (s *CategoryService) authorizeAdmin(ctx context.Context, username string) error {
+	return s.auth.Admin(ctx, username, s.admins)
+}
+
+func newAdmins(adminUsernames []string) map[string]bool {
+	admins := make(map[string]bool, len(adminUsernames))
+	for _, username := range adminUsernames {
+		admins[username] = true
+	}
+	return admins
+}
+
+func validCategoryNames(names map[string]string) bool {
+	for locale, name := range names {
+		if !localeRegexp.MatchString(locale) || len(name) == 0 || len([]rune(name)) > 100 {
+			return false
+		}
+	}
+	return true
+}
+
+// localizeCategory picks the display name for the locale, falling back
+// to the language without the region, the default locale and the code.
+func localizeCategory(c *model.ServiceCategory, locale string) {
+	if c.Names == nil {
+		c.Names = map[string]string{}
+	}
+	candidates := []string{locale}
+	if len(locale) > 2 {
+		candidates = append(candidates, locale[:2])
+	}
+	candidates = append(candidates, model.DefaultLocale)
+	for _, l := range candidates {
+		if name, ok := c.Names[l]; ok {
+			c.Name = name
+			return
+		}
+	}
+	c.Name = c.Code
+}
+
+// checkServiceType makes sure tenders and lots refer to a known category.
+func checkServiceType(serviceType string, categoryRepo *repository.CategoryRepository) error {
+	isPresent, err := categoryRepo.GetCategoryPresent(serviceType)
+	if err != nil {
+		return err
+	}
+	if !isPresent {
+		return ErrNoServiceType
+	}
+	return nil
+}
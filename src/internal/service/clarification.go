@@ -0,0 +1,152 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoQuestion             = repository.ErrNoQuestion
+	ErrQuestionDeadlinePassed = errors.New("the deadline for questions on the tender has passed")
+	ErrOwnTenderQuestion      = errors.New("tender responsibles can't ask questions on their tender")
+)
+
+type ClarificationService struct {
+	clarificationRepo *repository.ClarificationRepository
+	tenderRepo        *repository.TenderRepository
+	auth              *Authorizer
+}
+
+func NewClarificationService() *ClarificationService {
+	clarificationRepo := repository.NewClarificationRepository()
+	tenderRepo := repository.NewTenderRepository()
+	return &ClarificationService{
+		clarificationRepo: clarificationRepo,
+		tenderRepo:        tenderRepo,
+		auth:              NewAuthorizer(),
+	}
+}
+
+func (s *ClarificationService) AskQuestion(ctx context.Context, tenderID uuid.UUID, username, question string) (*model.Clarification, error) {
+	caller, err := s.auth.Caller(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	// questions are asked on published tenders only
+	if currentTender.Status != model.TenderPublished {
+		return nil, ErrNoTender
+	}
+	if caller.IsResponsible(currentTender.OrganizationID) {
+		return nil, ErrOwnTenderQuestion
+	}
+	err = s.auth.TenderViewer(ctx, currentTender, &username)
+	if err == ErrNotResponsible {
+		return nil, ErrNoTender
+	}
+	if err != nil {
+		return nil, err
+	}
+	deadline, err := s.clarificationRepo.GetQuestionDeadline(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	if deadline != nil && !time.Now().UTC().Before(*deadline) {
+		return nil, ErrQuestionDeadlinePassed
+	}
+
+	c := model.Clarification{
+		TenderID: tenderID,
+		Question: question,
+		AskerID:  caller.EmployeeID,
+	}
+	if err := s.clarificationRepo.InsertNewQuestion(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *ClarificationService) AnswerQuestion(ctx context.Context, tenderID, questionID uuid.UUID, username, answer string) (*model.Clarification, error) {
+	employeeID, err := s.authorizeTenderResponsible(ctx, tenderID, username)
+	if err != nil {
+		return nil, err
+	}
+	question, err := s.clarificationRepo.GetQuestionByID(questionID)
+	if err != nil {
+		return nil, err
+	}
+	if question.TenderID != tenderID {
+		return nil, ErrNoQuestion
+	}
+	clarification, err := s.clarificationRepo.AnswerQuestion(questionID, *employeeID, answer)
+	if err != nil {
+		return nil, err
+	}
+	// the tender shows the number of answered questions
+	tenderChanged(s.tenderRepo, &tenderID)
+	return clarification, nil
+}
+
+// GetQuestions lists the answered questions of the tender. Tender responsibles
+// also see the questions still waiting for an answer, and the asker sees their own.
+func (s *ClarificationService) GetQuestions(ctx context.Context, tenderID uuid.UUID, username *string, limit, offset int) ([]model.Clarification, error) {
+	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.auth.TenderViewer(ctx, currentTender, username)
+	if err != nil {
+		return nil, err
+	}
+	if username == nil {
+		return s.clarificationRepo.GetTenderQuestions(tenderID, nil, false, limit, offset)
+	}
+	caller, err := s.auth.Caller(ctx, *username)
+	if err != nil {
+		return nil, err
+	}
+	isResponsible := caller.IsResponsible(currentTender.OrganizationID)
+	return s.clarificationRepo.GetTenderQuestions(tenderID, &caller.EmployeeID, isResponsible, limit, offset)
+}
+
+func (s *ClarificationService) SetQuestionDeadline(ctx context.Context, tenderID uuid.UUID, username string, deadline *time.Time) error {
+	if _, err := s.authorizeTenderResponsible(ctx, tenderID, username); err != nil {
+		return err
+	}
+	if deadline != nil {
+		utc := deadline.UTC()
+		deadline = &utc
+	}
+	return s.clarificationRepo.SetQuestionDeadline(tenderID, deadline)
+}
+
+func (s *ClarificationService) GetQuestionDeadline(ctx context.Context, tenderID uuid.UUID, username *string) (*time.Time, error) {
+	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.auth.TenderViewer(ctx, currentTender, username)
+	if err != nil {
+		return nil, err
+	}
+	return s.clarificationRepo.GetQuestionDeadline(tenderID)
+}
+
+func (s *ClarificationService) authorizeTenderResponsible(ctx context.Context, tenderID uuid.UUID, username string) (*uuid.UUID, error) {
+	currentTender, caller, err := s.auth.TenderResponsible(ctx, username, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	if currentTender.Status == model.TenderClosed {
+		return nil, ErrTenderClosed
+	}
+	return &caller.EmployeeID, nil
+}
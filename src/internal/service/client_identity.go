@@ -0,0 +1,77 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"context"
+	"errors"
+)
+
+var ErrCertificateMismatch = errors.New("the caller doesn't match the client certificate")
+
+type clientIdentityKey struct{}
+
+// WithClientIdentity binds the request to the identity of its client certificate,
+// the callers and the authors the request acts as are checked against it.
+func WithClientIdentity(ctx context.Context, identity model.ClientIdentity) context.Context {
+	return context.WithValue(ctx, clientIdentityKey{}, identity)
+}
+
+// ClientIdentityFromContext returns the identity of the client certificate
+// of the request, if any.
+func ClientIdentityFromContext(ctx context.Context) (model.ClientIdentity, bool) {
+	identity, ok := ctx.Value(clientIdentityKey{}).(model.ClientIdentity)
+	return identity, ok
+}
+
+// checkClientIdentity lets an employee certificate act as that employee only
+// and an organization certificate as the responsibles of the organization.
+func checkClientIdentity(ctx context.Context, caller *model.Caller) error {
+	identity, ok := ClientIdentityFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if identity.Username != "" && caller.Username != identity.Username {
+		return ErrCertificateMismatch
+	}
+	if identity.OrganizationID != nil && !caller.IsResponsible(*identity.OrganizationID) {
+		return ErrCertificateMismatch
+	}
+	return nil
+}
+
+// ClientBidAuthor checks the author of a new bid, which comes in the body
+// rather than as a caller, against the client certificate of the request.
+func (a *Authorizer) ClientBidAuthor(ctx context.Context, b *model.Bid) error {
+	identity, ok := ClientIdentityFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if identity.Username != "" {
+		caller, err := a.Caller(ctx, identity.Username)
+		if err != nil {
+			return err
+		}
+		if !caller.IsBidAuthor(b) {
+			return ErrCertificateMismatch
+		}
+		return nil
+	}
+	if identity.OrganizationID == nil {
+		return nil
+	}
+	switch b.AuthorType {
+	case model.AuthorTypeOrganization:
+		if b.AuthorID != *identity.OrganizationID {
+			return ErrCertificateMismatch
+		}
+	case model.AuthorTypeUser:
+		isResponsible, err := a.organizationResponsibleRepo.GetIfEmployeeIsResponsible(&b.AuthorID, identity.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if !isResponsible {
+			return ErrCertificateMismatch
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrConflictOfInterest = errors.New("the action is blocked due to a conflict of interest")
+)
+
+type ConflictService struct {
+	conflictRepo *repository.ConflictRepository
+	tenderRepo   *repository.TenderRepository
+	auth         *Authorizer
+	admins       map[string]bool
+}
+
+// NewConflictService creates the conflict of interest service, the employees
+// listed in adminUsernames review the conflicts of any tender.
+func NewConflictService(adminUsernames []string) *ConflictService {
+	conflictRepo := repository.NewConflictRepository()
+	tenderRepo := repository.NewTenderRepository()
+	return &ConflictService{
+		conflictRepo: conflictRepo,
+		tenderRepo:   tenderRepo,
+		auth:         NewAuthorizer(),
+		admins:       newAdmins(adminUsernames),
+	}
+}
+
+// DeclareRecusal excludes the evaluator from voting and scoring on the tender.
+func (s *ConflictService) DeclareRecusal(ctx context.Context, tenderID uuid.UUID, username, reason string) (*model.Recusal, error) {
+	currentTender, caller, err := s.auth.TenderResponsible(ctx, username, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	if currentTender.Status == model.TenderClosed {
+		return nil, ErrTenderClosed
+	}
+	recusal := model.Recusal{
+		TenderID:   tenderID,
+		EmployeeID: caller.EmployeeID,
+		Reason:     reason,
+	}
+	if err := s.conflictRepo.InsertRecusal(&recusal); err != nil {
+		return nil, err
+	}
+	return &recusal, nil
+}
+
+func (s *ConflictService) GetRecusals(ctx context.Context, tenderID uuid.UUID, username string) ([]model.Recusal, error) {
+	if _, _, err := s.auth.TenderResponsible(ctx, username, tenderID); err != nil {
+		return nil, err
+	}
+	return s.conflictRepo.GetTenderRecusals(tenderID)
+}
+
+// GetConflicts lists the blocked actions on the tender for compliance:
+// the administrators and the tender responsibles see them.
+func (s *ConflictService) GetConflicts(ctx context.Context, tenderID uuid.UUID, username string, limit, offset int) ([]model.ConflictRecord, error) {
+	if s.admins[username] {
+		if err := s.auth.Admin(ctx, username, s.admins); err != nil {
+			return nil, err
+		}
+		if _, err := s.tenderRepo.GetLastTenderByID(tenderID); err != nil {
+			return nil, err
+		}
+	} else if _, _, err := s.auth.TenderResponsible(ctx, username, tenderID); err != nil {
+		return nil, err
+	}
+	return s.conflictRepo.GetTenderConflicts(tenderID, limit, offset)
+}
+
+// checkBidderConflict blocks bids of the organization owning the tender,
+// including bids of its responsibles on their own behalf.
+func checkBidderConflict(b *model.Bid, tender *model.Tender, organizationID uuid.UUID,
+	organizationResponsibleRepo *repository.OrganizationResponsibleRepository,
+	conflictRepo *repository.ConflictRepository) error {
+
+	conflict := model.ConflictRecord{
+		TenderID:       tender.ID,
+		OrganizationID: &organizationID,
+		Action:         model.ConflictBidCreation,
+	}
+	if b.AuthorType == model.AuthorTypeUser {
+		conflict.EmployeeID = &b.AuthorID
+	}
+	if organizationID == tender.OrganizationID {
+		conflict.Reason = "the organization bids on its own tender"
+		return recordConflict(&conflict, conflictRepo)
+	}
+	if b.AuthorType == model.AuthorTypeUser {
+		isResponsible, err := organizationResponsibleRepo.GetIfEmployeeIsResponsible(&b.AuthorID, &tender.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if isResponsible {
+			conflict.Reason = "the employee is responsible for the organization owning the tender"
+			return recordConflict(&conflict, conflictRepo)
+		}
+	}
+	return nil
+}
+
+// checkEvaluatorConflict blocks evaluators who recused themselves from the tender,
+// authored the bid or are responsible for the organization behind it.
+func checkEvaluatorConflict(employeeID uuid.UUID, bid *model.Bid, action string,
+	employeeRepo *repository.EmployeeRepository,
+	organizationResponsibleRepo *repository.OrganizationResponsibleRepository,
+	conflictRepo *repository.ConflictRepository) error {
+
+	conflict := model.ConflictRecord{
+		TenderID:   bid.TenderID,
+		BidID:      &bid.ID,
+		EmployeeID: &employeeID,
+		Action:     action,
+	}
+	isRecused, err := conflictRepo.GetIfEmployeeRecused(bid.TenderID, employeeID)
+	if err != nil {
+		return err
+	}
+	if isRecused {
+		conflict.Reason = "the evaluator has recused themselves from the tender"
+		return recordConflict(&conflict, conflictRepo)
+	}
+	if bid.AuthorType == model.AuthorTypeUser && bid.AuthorID == employeeID {
+		conflict.Reason = "the evaluator is the author of the bid"
+		return recordConflict(&conflict, conflictRepo)
+	}
+	bidOrganizationID := &bid.AuthorID
+	if bid.AuthorType == model.AuthorTypeUser {
+		bidOrganizationID, err = employeeRepo.GetEmployeeRespOrganization(bid.AuthorID)
+		if err == ErrNoEmployee {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	isResponsible, err := organizationResponsibleRepo.GetIfEmployeeIsResponsible(&employeeID, bidOrganizationID)
+	if err != nil {
+		return err
+	}
+	if isResponsible {
+		conflict.OrganizationID = bidOrganizationID
+		conflict.Reason = "the evaluator is responsible for the bidding organization"
+		return recordConflict(&conflict, conflictRepo)
+	}
+	return nil
+}
+
+func recordConflict(c *model.ConflictRecord, conflictRepo *repository.ConflictRepository) error {
+	if err := conflictRepo.InsertConflict(c); err != nil {
+		return err
+	}
+	return ErrConflictOfInterest
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCriteria = errors.New("criteria need unique names and positive weights adding up to 100")
+	ErrNoCriterion     = errors.New("criterion not found for the tender")
+	ErrInvalidScore    = errors.New("score has to be between 0 and 10")
+)
+
+type EvaluationService struct {
+	evaluationRepo              *repository.EvaluationRepository
+	bidRepo                     *repository.BidRepository
+	tenderRepo                  *repository.TenderRepository
+	employeeRepo                *repository.EmployeeRepository
+	organizationResponsibleRepo *repository.OrganizationResponsibleRepository
+	conflictRepo                *repository.ConflictRepository
+	auth                        *Authorizer
+}
+
+func NewEvaluationService() *EvaluationService {
+	evaluationRepo := repository.NewEvaluationRepository()
+	bidRepo := repository.NewBidRepository()
+	tenderRepo := repository.NewTenderRepository()
+	employeeRepo := repository.NewEmployeeRepository()
+	organizationResponsibleRepo := repository.NewOrganizationResponsibleRepository()
+	conflictRepo := repository.NewConflictRepository()
+	return &EvaluationService{
+		evaluationRepo:              evaluationRepo,
+		bidRepo:                     bidRepo,
+		tenderRepo:                  tenderRepo,
+		employeeRepo:                employeeRepo,
+		organizationResponsibleRepo: organizationResponsibleRepo,
+		conflictRepo:                conflictRepo,
+		auth:                        NewAuthorizer(),
+	}
+}
+
+func validateCriteria(criteria []model.Criterion) error {
+	if len(criteria) == 0 {
+		return nil
+	}
+	var (
+		total float64
+		names = make(map[string]bool, len(criteria))
+	)
+	for _, c := range criteria {
+		if len(c.Name) == 0 || names[c.Name] || c.Weight <= 0 {
+			return ErrInvalidCriteria
+		}
+		names[c.Name] = true
+		total += c.Weight
+	}
+	// weights are stored with two decimal places
+	if math.Round(total*100) != model.CriteriaTotalWeight*100 {
+		return ErrInvalidCriteria
+	}
+	return nil
+}
+
+func (s *EvaluationService) GetCriteria(ctx context.Context, tenderID uuid.UUID, username *string) ([]model.Criterion, error) {
+	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.auth.TenderViewer(ctx, currentTender, username)
+	if err != nil {
+		return nil, err
+	}
+	return s.evaluationRepo.GetCriteriaByTender(tenderID)
+}
+
+func (s *EvaluationService) SubmitScores(ctx context.Context, bidID uuid.UUID, username string, scores []model.BidScore) error {
+	currentBid, caller, err := s.auth.BidTenderResponsible(ctx, username, bidID)
+	if err != nil {
+		return err
+	}
+	userID := caller.EmployeeID
+	err = checkEvaluatorConflict(userID, currentBid, model.ConflictScoring,
+		s.employeeRepo, s.organizationResponsibleRepo, s.conflictRepo)
+	if err != nil {
+		return err
+	}
+	currentTender, err := s.tenderRepo.GetLastTenderByID(currentBid.TenderID)
+	if err != nil {
+		return err
+	}
+	// scores are locked once the tender is closed
+	if currentTender.Status == model.TenderClosed {
+		return ErrTenderClosed
+	}
+	criteria, err := s.evaluationRepo.GetCriteriaByTender(currentTender.ID)
+	if err != nil {
+		return err
+	}
+	tenderCriteria := make(map[uuid.UUID]bool, len(criteria))
+	for _, c := range criteria {
+		tenderCriteria[c.ID] = true
+	}
+	for _, score := range scores {
+		if !tenderCriteria[score.CriterionID] {
+			return ErrNoCriterion
+		}
+		if score.Score < 0 || score.Score > model.MaxBidScore {
+			return ErrInvalidScore
+		}
+	}
+
+	return s.evaluationRepo.WithTransaction(func(tx *sql.Tx) error {
+		// a decision may have closed the tender since it was read
+		status, err := s.tenderRepo.TxGetTenderStatusForUpdate(tx, currentTender.ID)
+		if err != nil {
+			return err
+		}
+		if status == model.TenderClosed {
+			return ErrTenderClosed
+		}
+		for i := range scores {
+			if err := s.evaluationRepo.TxUpsertScore(tx, bidID, userID, &scores[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetTenderEvaluation ranks the published bids of the tender by their
+// weighted score. Criteria without scores count as zero.
+func (s *EvaluationService) GetTenderEvaluation(ctx context.Context, tenderID uuid.UUID, username string) ([]model.BidEvaluation, error) {
+	if _, _, err := s.auth.TenderResponsible(ctx, username, tenderID); err != nil {
+		return nil, err
+	}
+
+	evaluations, err := s.evaluationRepo.GetTenderEvaluation(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range evaluations {
+		var total float64
+		for _, c := range evaluations[i].Criteria {
+			if c.AverageScore != nil {
+				total += c.Weight * *c.AverageScore / model.CriteriaTotalWeight
+			}
+		}
+		evaluations[i].WeightedScore = math.Round(total*100) / 100
+	}
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		return evaluations[i].WeightedScore > evaluations[j].WeightedScore
+	})
+	for i := range evaluations {
+		evaluations[i].Rank = i + 1
+		if i > 0 && evaluations[i].WeightedScore == evaluations[i-1].WeightedScore {
+			evaluations[i].Rank = evaluations[i-1].Rank
+		}
+	}
+	return evaluations, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"avito-back-test/internal/migration"
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type HealthService struct {
+	healthRepo *repository.HealthRepository
+	timeout    time.Duration
+}
+
+func NewHealthService(timeout time.Duration) *HealthService {
+	healthRepo := repository.NewHealthRepository()
+	return &HealthService{
+		healthRepo: healthRepo,
+		timeout:    timeout,
+	}
+}
+
+// CheckReadiness checks the database and the schema within the timeout.
+// The pool stats are reported but never fail the check.
+func (s *HealthService) CheckReadiness(ctx context.Context) model.Readiness {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	expectedVersion := migration.LatestVersion()
+	readiness := model.Readiness{
+		Status:   model.HealthStatusOk,
+		Database: model.HealthCheck{Status: model.HealthStatusOk},
+		Schema: model.SchemaCheck{
+			Status:          model.HealthStatusOk,
+			ExpectedVersion: expectedVersion,
+		},
+		Pool:     poolStats(s.healthRepo.PoolStats()),
+		Replicas: s.healthRepo.CheckReplicas(),
+	}
+
+	if err := s.healthRepo.Ping(ctx); err != nil {
+		readiness.Status = model.HealthStatusFail
+		readiness.Database = model.HealthCheck{Status: model.HealthStatusFail, Error: err.Error()}
+		readiness.Schema.Status = model.HealthStatusFail
+		readiness.Schema.Error = "database is unreachable"
+		return readiness
+	}
+
+	version, dirty, err := s.healthRepo.GetSchemaVersion(ctx)
+	readiness.Schema.Version = version
+	readiness.Schema.Dirty = dirty
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		readiness.Schema.Error = "no migrations are applied"
+	case err != nil:
+		readiness.Schema.Error = err.Error()
+	case dirty:
+		readiness.Schema.Error = fmt.Sprintf("migration %d failed halfway", version)
+	case version != expectedVersion:
+		readiness.Schema.Error = fmt.Sprintf("schema version is %d, expected %d", version, expectedVersion)
+	}
+	if readiness.Schema.Error != "" {
+		readiness.Status = model.HealthStatusFail
+		readiness.Schema.Status = model.HealthStatusFail
+	}
+	return readiness
+}
+
+func poolStats(stats sql.DBStats) model.PoolStats {
+	pool := model.PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.String(),
+	}
+	if stats.MaxOpenConnections > 0 {
+		pool.Saturation = float64(stats.InUse) / float64(stats.MaxOpenConnections)
+	}
+	return pool
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// errDryRun rolls back the repairs of a dry run.
+var errDryRun = errors.New("dry run")
+
+type IntegrityService struct {
+	integrityRepo *repository.IntegrityRepository
+	tenderRepo    *repository.TenderRepository
+	admins        map[string]bool
+	auth          *Authorizer
+}
+
+// NewIntegrityService creates the checker, only the employees listed
+// in adminUsernames may run it through the API.
+func NewIntegrityService(adminUsernames []string) *IntegrityService {
+	integrityRepo := repository.NewIntegrityRepository()
+	tenderRepo := repository.NewTenderRepository()
+	return &IntegrityService{
+		integrityRepo: integrityRepo,
+		tenderRepo:    tenderRepo,
+		admins:        newAdmins(adminUsernames),
+		auth:          NewAuthorizer(),
+	}
+}
+
+func (s *IntegrityService) CheckAsAdmin(ctx context.Context, username string) (*model.IntegrityReport, error) {
+	if err := s.auth.Admin(ctx, username, s.admins); err != nil {
+		return nil, err
+	}
+	return s.Check()
+}
+
+func (s *IntegrityService) RepairAsAdmin(ctx context.Context, username string, dryRun bool) (*model.IntegrityReport, error) {
+	if err := s.auth.Admin(ctx, username, s.admins); err != nil {
+		return nil, err
+	}
+	return s.Repair(dryRun)
+}
+
+func (s *IntegrityService) Check() (*model.IntegrityReport, error) {
+	report := model.IntegrityReport{CheckedAt: time.Now().UTC()}
+	err := s.integrityRepo.WithTransaction(func(tx *sql.Tx) (err error) {
+		report.Issues, err = s.integrityRepo.TxGetIssues(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Repair finds the issues and repairs them in one transaction.
+// A dry run makes the same changes and rolls them back.
+func (s *IntegrityService) Repair(dryRun bool) (*model.IntegrityReport, error) {
+	report := model.IntegrityReport{CheckedAt: time.Now().UTC(), DryRun: dryRun}
+	err := s.integrityRepo.WithTransaction(func(tx *sql.Tx) error {
+		issues, err := s.integrityRepo.TxGetIssues(tx)
+		if err != nil {
+			return err
+		}
+		report.Issues = issues
+		if err := s.repair(tx, issues); err != nil {
+			return err
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return nil, err
+	}
+	report.Repaired = !dryRun
+	if report.Repaired && len(report.Issues) > 0 {
+		// the repairs delete tenders and renumber their versions
+		tenderChanged(s.tenderRepo, nil)
+	}
+	return &report, nil
+}
+
+func (s *IntegrityService) repair(tx *sql.Tx, issues []model.IntegrityIssue) error {
+	ids := make(map[model.IntegrityIssueKind][]uuid.UUID)
+	for _, issue := range issues {
+		ids[issue.Kind] = append(ids[issue.Kind], issue.ID)
+	}
+	// the deletions go first, the bids of a deleted tender are gone with it
+	repairs := []struct {
+		kind   model.IntegrityIssueKind
+		repair func(tx *sql.Tx, ids []uuid.UUID) error
+	}{
+		{model.IssueTenderWithoutInformation, s.integrityRepo.TxDeleteTenders},
+		{model.IssueBidWithoutTender, s.integrityRepo.TxDeleteBids},
+		{model.IssueBidWithoutInformation, s.integrityRepo.TxDeleteBids},
+		{model.IssueTenderVersionGap, s.integrityRepo.TxRenumberTenderVersions},
+		{model.IssueBidVersionGap, s.integrityRepo.TxRenumberBidVersions},
+		{model.IssueTenderCurrentVersion, s.integrityRepo.TxSyncTenderCurrentVersions},
+		{model.IssueBidCurrentVersion, s.integrityRepo.TxSyncBidCurrentVersions},
+		{model.IssuePublishedBidOnClosedTender, s.integrityRepo.TxCancelBids},
+	}
+	for _, r := range repairs {
+		if len(ids[r.kind]) == 0 {
+			continue
+		}
+		if err := r.repair(tx, ids[r.kind]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
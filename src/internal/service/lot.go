@@ -0,0 +1,74 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoLot       = repository.ErrNoLot
+	ErrLotRequired = errors.New("the tender has lots, a bid has to be placed against one of them")
+	ErrLotDecided  = errors.New("the lot is already decided")
+	ErrLotlessBids = errors.New("the tender already has bids on the whole tender, lots can't be added")
+)
+
+type LotService struct {
+	lotRepo      *repository.LotRepository
+	tenderRepo   *repository.TenderRepository
+	categoryRepo *repository.CategoryRepository
+	auth         *Authorizer
+}
+
+func NewLotService() *LotService {
+	lotRepo := repository.NewLotRepository()
+	tenderRepo := repository.NewTenderRepository()
+	categoryRepo := repository.NewCategoryRepository()
+	return &LotService{
+		lotRepo:      lotRepo,
+		tenderRepo:   tenderRepo,
+		categoryRepo: categoryRepo,
+		auth:         NewAuthorizer(),
+	}
+}
+
+func (s *LotService) InsertNewLot(ctx context.Context, l *model.Lot, username string) error {
+	currentTender, _, err := s.auth.TenderResponsible(ctx, username, l.TenderID)
+	if err != nil {
+		return err
+	}
+	if currentTender.Status == model.TenderClosed {
+		return ErrTenderClosed
+	}
+	// a bid on the whole tender could close it over the new lots
+	hasLotlessBids, err := s.lotRepo.GetTenderHasLotlessBids(l.TenderID)
+	if err != nil {
+		return err
+	}
+	if hasLotlessBids {
+		return ErrLotlessBids
+	}
+	// lots default to the service type of the tender
+	if l.ServiceType == "" {
+		l.ServiceType = currentTender.ServiceType
+	}
+	if err := checkServiceType(l.ServiceType, s.categoryRepo); err != nil {
+		return err
+	}
+	return s.lotRepo.InsertNewLot(l)
+}
+
+func (s *LotService) GetLots(ctx context.Context, tenderID uuid.UUID, username *string) ([]model.Lot, error) {
+	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.auth.TenderViewer(ctx, currentTender, username)
+	if err != nil {
+		return nil, err
+	}
+	return s.lotRepo.GetLotsByTender(tenderID)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoQualification            = repository.ErrNoQualification
+	ErrNotQualified               = errors.New("the organization has no approved qualification for the tender")
+	ErrQuestionnaireInUse         = errors.New("the questionnaire already has submissions or bids and can't be changed")
+	ErrIncompleteAnswers          = errors.New("required answers or documents are missing")
+	ErrAlreadyQualified           = errors.New("the organization is already qualified for the tender")
+	ErrWrongQualificationDecision = errors.New("decision has to be Approved or Rejected")
+	ErrNoQuestionnaire            = errors.New("the tender has no questionnaire")
+)
+
+type QualificationService struct {
+	qualificationRepo *repository.QualificationRepository
+	tenderRepo        *repository.TenderRepository
+	auth              *Authorizer
+}
+
+func NewQualificationService() *QualificationService {
+	qualificationRepo := repository.NewQualificationRepository()
+	tenderRepo := repository.NewTenderRepository()
+	return &QualificationService{
+		qualificationRepo: qualificationRepo,
+		tenderRepo:        tenderRepo,
+		auth:              NewAuthorizer(),
+	}
+}
+
+// SetQuestionnaire replaces the questionnaire of the tender until the organizations
+// answer it or bid, an empty questionnaire lets any organization bid.
+func (s *QualificationService) SetQuestionnaire(ctx context.Context, tenderID uuid.UUID, username string,
+	items []model.QuestionnaireItem) ([]model.QuestionnaireItem, error) {
+
+	if _, err := s.authorizeTenderResponsible(ctx, tenderID, username); err != nil {
+		return nil, err
+	}
+	err := s.qualificationRepo.WithTransaction(func(tx *sql.Tx) error {
+		// the bids and the submissions were made under the current questionnaire
+		if _, err := s.tenderRepo.TxGetTenderStatusForUpdate(tx, tenderID); err != nil {
+			return err
+		}
+		inUse, err := s.qualificationRepo.TxGetQuestionnaireInUse(tx, tenderID)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return ErrQuestionnaireInUse
+		}
+		return s.qualificationRepo.TxReplaceQuestionnaire(tx, tenderID, items)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *QualificationService) GetQuestionnaire(ctx context.Context, tenderID uuid.UUID, username *string) ([]model.QuestionnaireItem, error) {
+	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.auth.TenderViewer(ctx, currentTender, username)
+	if err != nil {
+		return nil, err
+	}
+	return s.qualificationRepo.GetQuestionnaire(tenderID)
+}
+
+func (s *QualificationService) SubmitQualification(ctx context.Context, q *model.Qualification, username string) error {
+	caller, err := s.auth.Caller(ctx, username)
+	if err != nil {
+		return err
+	}
+	// the submission is made on behalf of an organization the employee is responsible for
+	if !caller.IsResponsible(q.OrganizationID) {
+		return ErrNotResponsible
+	}
+	currentTender, err := s.tenderRepo.GetLastTenderByID(q.TenderID)
+	if err != nil {
+		return err
+	}
+	err = s.auth.TenderViewer(ctx, currentTender, &username)
+	if err == ErrNotResponsible || currentTender.Status != model.TenderPublished {
+		return ErrNoTender
+	}
+	if err != nil {
+		return err
+	}
+	items, err := s.qualificationRepo.GetQuestionnaire(q.TenderID)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return ErrNoQuestionnaire
+	}
+	if err := checkAnswers(items, q.Answers); err != nil {
+		return err
+	}
+	isQualified, err := s.qualificationRepo.GetIfOrganizationIsQualified(q.TenderID, q.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if isQualified {
+		return ErrAlreadyQualified
+	}
+	return s.qualificationRepo.WithTransaction(func(tx *sql.Tx) error {
+		return s.qualificationRepo.TxUpsertQualification(tx, q, caller.EmployeeID)
+	})
+}
+
+func checkAnswers(items []model.QuestionnaireItem, answers []model.QualificationAnswer) error {
+	byItem := make(map[uuid.UUID]model.QualificationAnswer, len(answers))
+	for _, a := range answers {
+		byItem[a.ItemID] = a
+	}
+	if len(byItem) != len(answers) {
+		return ErrIncompleteAnswers
+	}
+	known := make(map[uuid.UUID]bool, len(items))
+	for _, item := range items {
+		known[item.ID] = true
+		a, ok := byItem[item.ID]
+		if item.Required && (!ok || len(a.Answer) == 0) {
+			return ErrIncompleteAnswers
+		}
+		if item.RequiresDocument && (!ok || a.Document == nil || len(a.Document.Content) == 0) {
+			return ErrIncompleteAnswers
+		}
+	}
+	for _, a := range answers {
+		if !known[a.ItemID] {
+			return ErrIncompleteAnswers
+		}
+	}
+	return nil
+}
+
+func (s *QualificationService) GetQualifications(ctx context.Context, tenderID uuid.UUID, username string, status *string,
+	limit, offset int) ([]model.Qualification, error) {
+
+	if _, _, err := s.auth.TenderResponsible(ctx, username, tenderID); err != nil {
+		return nil, err
+	}
+	return s.qualificationRepo.GetTenderQualifications(tenderID, status, limit, offset)
+}
+
+// GetQualification shows the submission with its answers and documents
+// to the tender responsibles and to the submitting organization.
+func (s *QualificationService) GetQualification(ctx context.Context, tenderID, qualificationID uuid.UUID, username string) (*model.Qualification, error) {
+	caller, err := s.auth.Caller(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	q, err := s.qualificationRepo.GetQualificationByID(qualificationID)
+	if err != nil {
+		return nil, err
+	}
+	if q.TenderID != tenderID {
+		return nil, ErrNoQualification
+	}
+	if caller.IsResponsible(q.OrganizationID) {
+		return q, nil
+	}
+	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+	if err != nil {
+		return nil, err
+	}
+	if !caller.IsResponsible(currentTender.OrganizationID) {
+		return nil, ErrNotResponsible
+	}
+	return q, nil
+}
+
+func (s *QualificationService) ReviewQualification(ctx context.Context, tenderID, qualificationID uuid.UUID, username, decision string,
+	comment *string) (*model.Qualification, error) {
+
+	if decision != model.QualificationApproved && decision != model.QualificationRejected {
+		return nil, ErrWrongQualificationDecision
+	}
+	employeeID, err := s.authorizeTenderResponsible(ctx, tenderID, username)
+	if err != nil {
+		return nil, err
+	}
+	q, err := s.qualificationRepo.GetQualificationByID(qualificationID)
+	if err != nil {
+		return nil, err
+	}
+	if q.TenderID != tenderID {
+		return nil, ErrNoQualification
+	}
+	return s.qualificationRepo.ReviewQualification(qualificationID, *employeeID, decision, comment)
+}
+
+func (s *QualificationService) authorizeTenderResponsible(ctx context.Context, tenderID uuid.UUID, username string) (*uuid.UUID, error) {
+	currentTender, caller, err := s.auth.TenderResponsible(ctx, username, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	if currentTender.Status == model.TenderClosed {
+		return nil, ErrTenderClosed
+	}
+	return &caller.EmployeeID, nil
+}
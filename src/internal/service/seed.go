@@ -0,0 +1,202 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidFixture = errors.New("invalid fixture")
+
+type SeedService struct {
+	employeeRepo                *repository.EmployeeRepository
+	organizationRepo            *repository.OrganizationRepository
+	organizationResponsibleRepo *repository.OrganizationResponsibleRepository
+	tenderRepo                  *repository.TenderRepository
+	bidRepo                     *repository.BidRepository
+	categoryRepo                *repository.CategoryRepository
+}
+
+func NewSeedService() *SeedService {
+	emploRepo := repository.NewEmployeeRepository()
+	orgRepo := repository.NewOrganizationRepository()
+	orgRespRepo := repository.NewOrganizationResponsibleRepository()
+	tenderRepo := repository.NewTenderRepository()
+	bidRepo := repository.NewBidRepository()
+	categoryRepo := repository.NewCategoryRepository()
+	return &SeedService{
+		employeeRepo:                emploRepo,
+		organizationRepo:            orgRepo,
+		organizationResponsibleRepo: orgRespRepo,
+		tenderRepo:                  tenderRepo,
+		bidRepo:                     bidRepo,
+		categoryRepo:                categoryRepo,
+	}
+}
+
+// Seed loads the fixture in one transaction. Employees and organizations
+// are upserted, tenders and bids already present by name are skipped,
+// so the same fixture can be loaded again.
+func (s *SeedService) Seed(f *model.Fixture) (*model.SeedResult, error) {
+	if err := s.validateFixture(f); err != nil {
+		return nil, err
+	}
+
+	var result model.SeedResult
+	err := s.employeeRepo.WithTransaction(func(tx *sql.Tx) error {
+		result = model.SeedResult{}
+		employeeIDs := make(map[string]uuid.UUID)
+		for _, fe := range f.Employees {
+			employee := model.Employee{Username: fe.Username, FirstName: fe.FirstName, LastName: fe.LastName}
+			if err := s.employeeRepo.TxUpsertEmployee(tx, &employee); err != nil {
+				return fmt.Errorf("employee %s: %w", fe.Username, err)
+			}
+			employeeIDs[fe.Username] = employee.ID
+			result.Employees++
+		}
+
+		for _, fo := range f.Organizations {
+			organization := model.Organization{ID: fo.ID, Name: fo.Name, Description: fo.Description, Type: fo.Type}
+			if err := s.organizationRepo.TxUpsertOrganization(tx, &organization); err != nil {
+				return fmt.Errorf("organization %s: %w", fo.Name, err)
+			}
+			for _, username := range fo.Responsibles {
+				err := s.organizationResponsibleRepo.TxInsertResponsible(tx, fo.ID, employeeIDs[username])
+				if err != nil {
+					return fmt.Errorf("responsible %s: %w", username, err)
+				}
+			}
+			result.Organizations++
+		}
+
+		tenderIDs := make(map[string]uuid.UUID)
+		for _, ft := range f.Tenders {
+			id, err := s.tenderRepo.TxGetTenderIDByName(tx, ft.OrganizationID, ft.Name)
+			if err == nil {
+				tenderIDs[ft.Name] = *id
+				continue
+			}
+			if err != repository.ErrNoTender {
+				return err
+			}
+			tender := model.Tender{
+				Name:           ft.Name,
+				Description:    ft.Description,
+				ServiceType:    ft.ServiceType,
+				Visibility:     ft.Visibility,
+				OrganizationID: ft.OrganizationID,
+			}
+			if err := s.tenderRepo.TxInsertNewTender(tx, &tender); err != nil {
+				return fmt.Errorf("tender %s: %w", ft.Name, err)
+			}
+			if ft.Status != "" && ft.Status != model.TenderCreated {
+				if err := s.tenderRepo.TxUpdateTenderStatus(tx, tender.ID, ft.Status); err != nil {
+					return fmt.Errorf("tender %s: %w", ft.Name, err)
+				}
+			}
+			tenderIDs[ft.Name] = tender.ID
+			result.Tenders++
+		}
+
+		for _, fb := range f.Bids {
+			authorID := employeeIDs[fb.Author]
+			if fb.AuthorType == model.AuthorTypeOrganization {
+				authorID = uuid.MustParse(fb.Author)
+			}
+			_, err := s.bidRepo.TxGetBidIDByName(tx, tenderIDs[fb.Tender], authorID, fb.Name)
+			if err == nil {
+				continue
+			}
+			if err != repository.ErrNoBid {
+				return err
+			}
+			bid := model.Bid{
+				Name:        fb.Name,
+				Description: fb.Description,
+				TenderID:    tenderIDs[fb.Tender],
+				AuthorType:  fb.AuthorType,
+				AuthorID:    authorID,
+			}
+			if err := s.bidRepo.TxInsertNewBid(tx, &bid); err != nil {
+				return fmt.Errorf("bid %s: %w", fb.Name, err)
+			}
+			if fb.Status != "" && fb.Status != model.BidCreated {
+				if err := s.bidRepo.TxSetBidStatus(tx, bid.ID, fb.Status); err != nil {
+					return fmt.Errorf("bid %s: %w", fb.Name, err)
+				}
+			}
+			result.Bids++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Tenders > 0 {
+		tenderChanged(s.tenderRepo, nil)
+	}
+	return &result, nil
+}
+
+// validateFixture checks the references before anything is written.
+func (s *SeedService) validateFixture(f *model.Fixture) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidFixture, fmt.Sprintf(format, args...))
+	}
+
+	usernames := make(map[string]bool)
+	for _, fe := range f.Employees {
+		if fe.Username == "" {
+			return invalid("an employee has no username")
+		}
+		usernames[fe.Username] = true
+	}
+	organizations := make(map[uuid.UUID]bool)
+	for _, fo := range f.Organizations {
+		if fo.ID == uuid.Nil || fo.Name == "" {
+			return invalid("organization %q needs an id and a name", fo.Name)
+		}
+		for _, username := range fo.Responsibles {
+			if !usernames[username] {
+				return invalid("responsible %s of %s is not an employee of the fixture", username, fo.Name)
+			}
+		}
+		organizations[fo.ID] = true
+	}
+	tenders := make(map[string]bool)
+	for _, ft := range f.Tenders {
+		if ft.Name == "" || tenders[ft.Name] {
+			return invalid("tender names have to be set and unique, got %q", ft.Name)
+		}
+		if !organizations[ft.OrganizationID] {
+			return invalid("tender %s: organization %s is not in the fixture", ft.Name, ft.OrganizationID)
+		}
+		if err := checkServiceType(ft.ServiceType, s.categoryRepo); err != nil {
+			return fmt.Errorf("tender %s: %w", ft.Name, err)
+		}
+		tenders[ft.Name] = true
+	}
+	for _, fb := range f.Bids {
+		if fb.Name == "" || !tenders[fb.Tender] {
+			return invalid("bid %q needs a name and a tender of the fixture", fb.Name)
+		}
+		switch fb.AuthorType {
+		case model.AuthorTypeUser:
+			if !usernames[fb.Author] {
+				return invalid("bid %s: author %s is not an employee of the fixture", fb.Name, fb.Author)
+			}
+		case model.AuthorTypeOrganization:
+			id, err := uuid.Parse(fb.Author)
+			if err != nil || !organizations[id] {
+				return invalid("bid %s: author %s is not an organization of the fixture", fb.Name, fb.Author)
+			}
+		default:
+			return fmt.Errorf("bid %s: %w", fb.Name, ErrWrongAuthorType)
+		}
+	}
+	return nil
+}
@@ -3,168 +3,279 @@ package service
 import (
 	"avito-back-test/internal/model"
 	"avito-back-test/internal/repository"
+	"context"
+	"database/sql"
 	"errors"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrNotResponsible = errors.New("the employee is not responsible")
-	ErrNoEmployee     = repository.ErrNoEmployee
-	ErrNoTender       = repository.ErrNoTender
-	ErrTenderClosed   = errors.New("the tender is closed and can't be changed")
+	ErrNotResponsible  = errors.New("the employee is not responsible")
+	ErrNoEmployee      = repository.ErrNoEmployee
+	ErrNoTender        = repository.ErrNoTender
+	ErrTenderClosed    = errors.New("the tender is closed and can't be changed")
+	ErrNoInvitation    = repository.ErrNoInvitation
+	ErrWrongVisibility = errors.New("visibility not supported")
 )
 
 type TenderService struct {
-	tenderRepo                  *repository.TenderRepository
-	organizationResponsibleRepo *repository.OrganizationResponsibleRepository
-	employeeRepo                *repository.EmployeeRepository
+	tenderRepo       *repository.TenderRepository
+	auctionRepo      *repository.AuctionRepository
+	evaluationRepo   *repository.EvaluationRepository
+	invitationRepo   *repository.InvitationRepository
+	organizationRepo *repository.OrganizationRepository
+	categoryRepo     *repository.CategoryRepository
+	auth             *Authorizer
 }
 
 func NewTenderService() *TenderService {
 	tenderRepo := repository.NewTenderRepository()
-	organizationResponsibleRepo := repository.NewOrganizationResponsibleRepository()
-	employeeRepo := repository.NewEmployeeRepository()
+	auctionRepo := repository.NewAuctionRepository()
+	evaluationRepo := repository.NewEvaluationRepository()
+	invitationRepo := repository.NewInvitationRepository()
+	organizationRepo := repository.NewOrganizationRepository()
+	categoryRepo := repository.NewCategoryRepository()
 	return &TenderService{
-		tenderRepo:                  tenderRepo,
-		organizationResponsibleRepo: organizationResponsibleRepo,
-		employeeRepo:                employeeRepo,
+		tenderRepo:       tenderRepo,
+		auctionRepo:      auctionRepo,
+		evaluationRepo:   evaluationRepo,
+		invitationRepo:   invitationRepo,
+		organizationRepo: organizationRepo,
+		categoryRepo:     categoryRepo,
+		auth:             NewAuthorizer(),
 	}
 }
 
-func (s *TenderService) GetTenders(limit, offset int) ([]model.Tender, error) {
-	return s.tenderRepo.GetAllPublicTenders(limit, offset)
+func (s *TenderService) GetTenders(ctx context.Context, username *string, limit, offset int) ([]model.Tender, error) {
+	viewerID, err := s.getViewerID(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return getCachedListing(listingKey{limit: limit, offset: offset}, username, func(reader string) ([]model.Tender, error) {
+		return s.tenderRepo.GetAllPublicTenders(reader, viewerID, limit, offset)
+	})
 }
 
-func (s *TenderService) GetTendersOfService(service string, limit, offset int) ([]model.Tender, error) {
-	return s.tenderRepo.GetPublicTendersOfService(service, limit, offset)
+func (s *TenderService) GetTendersOfService(ctx context.Context, service string, username *string, limit, offset int) ([]model.Tender, error) {
+	viewerID, err := s.getViewerID(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkServiceType(service, s.categoryRepo); err != nil {
+		return nil, err
+	}
+	key := listingKey{serviceType: service, limit: limit, offset: offset}
+	return getCachedListing(key, username, func(reader string) ([]model.Tender, error) {
+		return s.tenderRepo.GetPublicTendersOfService(reader, service, viewerID, limit, offset)
+	})
 }
 
-func (s *TenderService) InsertNewTender(t *model.Tender, username string) error {
-	// Get id by username
-	employeeId, err := s.employeeRepo.GetEmployeeIDByUsername(username)
+// getViewerID resolves the employee looking at the tender listing,
+// anonymous viewers only see public tenders.
+func (s *TenderService) getViewerID(ctx context.Context, username *string) (*uuid.UUID, error) {
+	if username == nil {
+		return nil, nil
+	}
+	caller, err := s.auth.Caller(ctx, *username)
 	if err != nil {
+		return nil, err
+	}
+	return &caller.EmployeeID, nil
+}
+
+func (s *TenderService) InsertNewTender(ctx context.Context, t *model.Tender, criteria []model.Criterion, username string) error {
+	if err := validateCriteria(criteria); err != nil {
 		return err
 	}
-	// Check if the employee is responsible
-	isResponsible, err := s.organizationResponsibleRepo.GetIfEmployeeIsResponsible(employeeId, &t.OrganizationID)
+	caller, err := s.auth.Caller(ctx, username)
 	if err != nil {
 		return err
 	}
-	if !isResponsible {
+	// Check if the employee is responsible
+	if !caller.IsResponsible(t.OrganizationID) {
 		return ErrNotResponsible
 	}
-	return s.tenderRepo.InsertNewTender(t)
+	if err := checkServiceType(t.ServiceType, s.categoryRepo); err != nil {
+		return err
+	}
+	if len(criteria) == 0 {
+		return s.tenderRepo.InsertNewTender(t)
+	}
+	return s.evaluationRepo.WithTransaction(func(tx *sql.Tx) error {
+		err := s.tenderRepo.TxInsertNewTender(tx, t)
+		if err != nil {
+			return err
+		}
+		return s.evaluationRepo.TxInsertCriteria(tx, t.ID, criteria)
+	})
 }
 
-func (s *TenderService) GetUserTenders(username string, limit, offset int) ([]model.Tender, error) {
+func (s *TenderService) GetUserTenders(ctx context.Context, username string, includeHistory bool, limit, offset int) ([]model.Tender, error) {
 	// check username validity
-	employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(username)
+	caller, err := s.auth.Caller(ctx, username)
 	if err != nil {
 		return nil, err
 	}
-	return s.tenderRepo.GetUserTenders(*employeeID, limit, offset)
+	return s.tenderRepo.GetUserTenders(caller.EmployeeID, includeHistory, limit, offset)
 }
 
-func (s *TenderService) GetTenderStatus(tenderID uuid.UUID, username *string) (string, error) {
-	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+func (s *TenderService) GetTenderStatus(ctx context.Context, tenderID uuid.UUID, username *string) (string, error) {
+	currentTender, err := getCachedTender(s.tenderRepo, tenderID)
 	if err != nil {
 		return "", err
 	}
-	// return immediately if the tender is public
-	if currentTender.Status == model.TenderPublished {
-		return currentTender.Status, nil
+	err = s.auth.TenderViewer(ctx, currentTender, username)
+	if err != nil {
+		return "", err
 	}
-	// otherwise (not public) return responsibility error if no username is provided
-	if username == nil {
-		return "", ErrNotResponsible
+	return currentTender.Status, nil
+}
+
+func (s *TenderService) UpdateTenderStatus(ctx context.Context, t *model.Tender, username string) error {
+	currentTender, _, err := s.auth.TenderResponsible(ctx, username, t.ID)
+	if err != nil {
+		return err
 	}
-	// get user id by username
-	employeeId, err := s.employeeRepo.GetEmployeeIDByUsername(*username)
+	change, err := checkTenderTransition(currentTender.Status, t.Status, model.ActorResponsible)
 	if err != nil {
-		return "", err
+		return err
 	}
-	// Check if the employee is responsible
-	isResponsible, err := s.organizationResponsibleRepo.GetIfEmployeeIsResponsible(employeeId, &currentTender.OrganizationID)
+	err = s.tenderRepo.ChangeTenderStatus(t.ID, change)
 	if err != nil {
-		return "", err
+		return changeStatusError(err)
 	}
-	if !isResponsible {
-		return "", ErrNotResponsible
+	updated := *currentTender
+	updated.Status = t.Status
+	*t = updated
+	tenderChanged(s.tenderRepo, &t.ID)
+	// reverse auctions start running on publication
+	if t.Status == model.TenderPublished {
+		return s.auctionRepo.StartAuction(t.ID)
 	}
-	// at this point, the user is responsible and can see the response
-	return currentTender.Status, nil
+	return nil
 }
 
-func (s *TenderService) UpdateTenderStatus(t *model.Tender, username string) error {
-	// Get id by username
-	employeeId, err := s.employeeRepo.GetEmployeeIDByUsername(username)
+// GetTenderTransitions lists the statuses the employee can move the tender to,
+// employees who can see the tender but aren't responsible for it get none.
+func (s *TenderService) GetTenderTransitions(ctx context.Context, tenderID uuid.UUID, username string) (*model.StatusTransitions, error) {
+	// the tender comes from the cache, so the caller is resolved on its own, once
+	caller, err := s.auth.Caller(ctx, username)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	currentTender, err := s.tenderRepo.GetLastTenderByID(t.ID)
+	currentTender, err := getCachedTender(s.tenderRepo, tenderID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	// Check if the employee is responsible
-	isResponsible, err := s.organizationResponsibleRepo.GetIfEmployeeIsResponsible(employeeId, &currentTender.OrganizationID)
+	err = s.auth.CallerViewsTender(currentTender, caller)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if !isResponsible {
-		return ErrNotResponsible
+	transitions := model.StatusTransitions{
+		Status:            currentTender.Status,
+		AvailableStatuses: []string{},
 	}
-	if currentTender.Status == model.TenderClosed {
-		return ErrTenderClosed
+	if !caller.IsResponsible(currentTender.OrganizationID) {
+		return &transitions, nil
+	}
+	transitions.AvailableStatuses, err = availableTenderStatuses(s, currentTender, model.ActorResponsible)
+	if err != nil {
+		return nil, err
 	}
-	t.Version = currentTender.Version
-	return s.tenderRepo.UpdateTenderStatus(t)
+	return &transitions, nil
 }
 
-func (s *TenderService) PatchTender(tenderID uuid.UUID, username string, update *model.TenderUpdate) (*model.Tender, error) {
-	// Get id by username
-	employeeId, err := s.employeeRepo.GetEmployeeIDByUsername(username)
+func (s *TenderService) PatchTender(ctx context.Context, tenderID uuid.UUID, username string, update *model.TenderUpdate) (*model.Tender, error) {
+	currentTender, err := s.authorizeTenderResponsible(ctx, tenderID, username)
 	if err != nil {
 		return nil, err
 	}
-	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+	if update.ServiceType != nil {
+		if err := checkServiceType(*update.ServiceType, s.categoryRepo); err != nil {
+			return nil, err
+		}
+	}
+	tender, err := s.tenderRepo.PatchTender(currentTender, update)
 	if err != nil {
 		return nil, err
 	}
-	// Check if the employee is responsible
-	isResponsible, err := s.organizationResponsibleRepo.GetIfEmployeeIsResponsible(employeeId, &currentTender.OrganizationID)
+	tenderChanged(s.tenderRepo, &tenderID)
+	return tender, nil
+}
+
+func (s *TenderService) RollbackTender(ctx context.Context, tenderID uuid.UUID, username string, version int) (*model.Tender, error) {
+	currentTender, err := s.authorizeTenderResponsible(ctx, tenderID, username)
 	if err != nil {
 		return nil, err
 	}
-	if !isResponsible {
-		return nil, ErrNotResponsible
-	}
-	if currentTender.Status == model.TenderClosed {
-		return nil, ErrTenderClosed
+	tender, err := s.tenderRepo.RollbackTender(currentTender, version)
+	if err != nil {
+		return nil, err
 	}
-	return s.tenderRepo.PatchTender(currentTender.ID, update)
+	tenderChanged(s.tenderRepo, &tenderID)
+	return tender, nil
 }
 
-func (s *TenderService) RollbackTender(tenderID uuid.UUID, username string, version int) (*model.Tender, error) {
-	// Get id by username
-	employeeId, err := s.employeeRepo.GetEmployeeIDByUsername(username)
+func (s *TenderService) UpdateTenderVisibility(ctx context.Context, tenderID uuid.UUID, username, visibility string) (*model.Tender, error) {
+	if visibility != model.TenderPublic && visibility != model.TenderInviteOnly {
+		return nil, ErrWrongVisibility
+	}
+	currentTender, err := s.authorizeTenderResponsible(ctx, tenderID, username)
 	if err != nil {
 		return nil, err
 	}
-	currentTender, err := s.tenderRepo.GetLastTenderByID(tenderID)
+	tender, err := s.tenderRepo.UpdateTenderVisibility(currentTender, visibility)
 	if err != nil {
 		return nil, err
 	}
-	// Check if the employee is responsible
-	isResponsible, err := s.organizationResponsibleRepo.GetIfEmployeeIsResponsible(employeeId, &currentTender.OrganizationID)
+	tenderChanged(s.tenderRepo, &tenderID)
+	return tender, nil
+}
+
+func (s *TenderService) InviteOrganization(ctx context.Context, tenderID, organizationID uuid.UUID, username string) (*model.TenderInvitation, error) {
+	if _, err := s.authorizeTenderResponsible(ctx, tenderID, username); err != nil {
+		return nil, err
+	}
+	isPresent, err := s.organizationRepo.GetOrganizationPresent(organizationID)
 	if err != nil {
 		return nil, err
 	}
-	if !isResponsible {
-		return nil, ErrNotResponsible
+	if !isPresent {
+		return nil, ErrNoOrganization
+	}
+	invitation := model.TenderInvitation{
+		TenderID:       tenderID,
+		OrganizationID: organizationID,
+	}
+	if err := s.invitationRepo.InsertInvitation(&invitation); err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (s *TenderService) RevokeInvitation(ctx context.Context, tenderID, organizationID uuid.UUID, username string) error {
+	if _, err := s.authorizeTenderResponsible(ctx, tenderID, username); err != nil {
+		return err
+	}
+	return s.invitationRepo.DeleteInvitation(tenderID, organizationID)
+}
+
+func (s *TenderService) GetInvitations(ctx context.Context, tenderID uuid.UUID, username string, limit, offset int) ([]model.TenderInvitation, error) {
+	if _, _, err := s.auth.TenderResponsible(ctx, username, tenderID); err != nil {
+		return nil, err
+	}
+	return s.invitationRepo.GetTenderInvitations(tenderID, limit, offset)
+}
+
+// authorizeTenderResponsible checks that the employee may change the tender.
+func (s *TenderService) authorizeTenderResponsible(ctx context.Context, tenderID uuid.UUID, username string) (*model.Tender, error) {
+	currentTender, _, err := s.auth.TenderResponsible(ctx, username, tenderID)
+	if err != nil {
+		return nil, err
 	}
 	if currentTender.Status == model.TenderClosed {
 		return nil, ErrTenderClosed
 	}
-	return s.tenderRepo.RollbackTender(tenderID, version)
+	return currentTender, nil
 }
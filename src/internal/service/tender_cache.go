@@ -0,0 +1,96 @@
+package service
+
+import (
+	"avito-back-test/internal/cache"
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The public tender catalog is cached in the process: the anonymous listings
+// and the tender lookups of the read-only endpoints. Changing a tender drops it
+// here and, through NOTIFY, in the other instances. The TTL bounds the staleness
+// when a notification is lost. The caches are nil, so disabled, until SetTenderCache.
+var (
+	cachedTenders  *cache.LRU[uuid.UUID, model.Tender]
+	cachedListings *cache.LRU[listingKey, []model.Tender]
+)
+
+// listingKey identifies a page of the public tenders, of a service
+// category or of all of them if serviceType is empty.
+type listingKey struct {
+	serviceType   string
+	limit, offset int
+}
+
+// SetTenderCache enables the cache, it is called once before the server starts.
+func SetTenderCache(size int, ttl time.Duration) {
+	cachedTenders = cache.NewLRU[uuid.UUID, model.Tender]("tenders", size, ttl)
+	cachedListings = cache.NewLRU[listingKey, []model.Tender]("tender_listings", size, ttl)
+}
+
+// ListenTenderChanges drops the tenders changed by the other instances
+// until stop is closed.
+func ListenTenderChanges(dsn string, stop <-chan struct{}) {
+	repository.NewTenderRepository().ListenTenderChanges(dsn, stop, dropCachedTender)
+}
+
+// dropCachedTender invalidates the tender, or all of them if tenderID is nil.
+func dropCachedTender(tenderID *uuid.UUID) {
+	// any change may move the tender in or out of a listing page
+	cachedListings.Purge()
+	if tenderID == nil {
+		cachedTenders.Purge()
+	} else {
+		cachedTenders.Remove(*tenderID)
+	}
+}
+
+// tenderChanged is called once a change of the tender is committed,
+// a nil tenderID stands for any tender.
+func tenderChanged(tenderRepo *repository.TenderRepository, tenderID *uuid.UUID) {
+	dropCachedTender(tenderID)
+	if err := tenderRepo.NotifyTenderChanged(tenderID); err != nil {
+		// the other instances catch up after the TTL
+		log.Println("tender cache:", err)
+	}
+}
+
+// getCachedTender is GetLastTenderByID for the endpoints that don't change the tender,
+// the others need the latest version.
+func getCachedTender(tenderRepo *repository.TenderRepository, tenderID uuid.UUID) (*model.Tender, error) {
+	tender, err := cachedTenders.Load(tenderID, func() (model.Tender, error) {
+		tender, err := tenderRepo.GetLastTenderByID(tenderID)
+		if err != nil {
+			return model.Tender{}, err
+		}
+		return *tender, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tender, nil
+}
+
+// getCachedListing caches the listing pages of the anonymous viewers, the pages
+// of employees depend on the invitations of their organizations. fill reads
+// for the reader, the pages to cache are read from the primary: a replica
+// lagging behind an invalidation would put the stale page back for the TTL.
+func getCachedListing(key listingKey, username *string, fill func(reader string) ([]model.Tender, error)) ([]model.Tender, error) {
+	if username != nil {
+		return fill(*username)
+	}
+	if cachedListings == nil {
+		// anonymous viewers never read their own writes
+		return fill("")
+	}
+	tenders, err := cachedListings.Load(key, func() ([]model.Tender, error) {
+		return fill(repository.PrimaryReader)
+	})
+	// the handlers get a copy, the cached page is shared
+	return slices.Clone(tenders), err
+}
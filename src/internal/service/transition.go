@@ -0,0 +1,110 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"errors"
+)
+
+var (
+	ErrIllegalTransition = errors.New("the status transition is not allowed")
+)
+
+// transition is an edge of a state machine. The guard is checked by the
+// status update itself, so that it can't change between the check and the update.
+type transition struct {
+	From  string
+	To    string
+	Actor model.TransitionActor
+	// Guard must hold for the transition to be allowed, empty means always
+	Guard repository.StatusGuard
+}
+
+// tenderTransitions is the tender state machine. Closed is final.
+var tenderTransitions = []transition{
+	{From: model.TenderCreated, To: model.TenderPublished, Actor: model.ActorResponsible},
+	{From: model.TenderCreated, To: model.TenderClosed, Actor: model.ActorResponsible},
+	// a tender can be unpublished as long as nobody has bid on it
+	{From: model.TenderPublished, To: model.TenderCreated, Actor: model.ActorResponsible, Guard: repository.TenderHasNoBids},
+	{From: model.TenderPublished, To: model.TenderClosed, Actor: model.ActorResponsible},
+	// a bid reaching the quorum or an expired auction
+	{From: model.TenderPublished, To: model.TenderClosed, Actor: model.ActorSystem},
+}
+
+// bidTransitions is the bid state machine. Canceled is final.
+var bidTransitions = []transition{
+	{From: model.BidCreated, To: model.BidPublished, Actor: model.ActorAuthor, Guard: repository.BidTenderIsPublished},
+	{From: model.BidCreated, To: model.BidCanceled, Actor: model.ActorAuthor},
+	// a bid can be withdrawn for edits until the first decision on it
+	{From: model.BidPublished, To: model.BidCreated, Actor: model.ActorAuthor, Guard: repository.BidHasNoDecisions},
+	{From: model.BidPublished, To: model.BidCanceled, Actor: model.ActorAuthor},
+	// a rejecting decision
+	{From: model.BidPublished, To: model.BidCanceled, Actor: model.ActorSystem},
+}
+
+// findTransition returns the status change the actor has to run to move
+// an entity between the statuses, or ErrIllegalTransition if there is none.
+func findTransition(transitions []transition, from, to string, actor model.TransitionActor) (repository.StatusChange, error) {
+	for _, tr := range transitions {
+		if tr.From == from && tr.To == to && tr.Actor == actor {
+			return repository.StatusChange{From: tr.From, To: tr.To, Guard: tr.Guard}, nil
+		}
+	}
+	return repository.StatusChange{}, ErrIllegalTransition
+}
+
+// checkTenderTransition returns the guarded status change moving the tender
+// to the status, or ErrIllegalTransition unless the actor may do it.
+func checkTenderTransition(from, to string, actor model.TransitionActor) (repository.StatusChange, error) {
+	return findTransition(tenderTransitions, from, to, actor)
+}
+
+// checkBidTransition returns the guarded status change moving the bid
+// to the status, or ErrIllegalTransition unless the actor may do it.
+func checkBidTransition(from, to string, actor model.TransitionActor) (repository.StatusChange, error) {
+	return findTransition(bidTransitions, from, to, actor)
+}
+
+// availableStatuses lists the statuses the actor may move an entity to now,
+// holds tells whether a guard of the entity holds.
+func availableStatuses(transitions []transition, from string, actor model.TransitionActor,
+	holds func(repository.StatusGuard) (bool, error)) ([]string, error) {
+	statuses := []string{}
+	for _, tr := range transitions {
+		if tr.From != from || tr.Actor != actor {
+			continue
+		}
+		if tr.Guard != "" {
+			ok, err := holds(tr.Guard)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		statuses = append(statuses, tr.To)
+	}
+	return statuses, nil
+}
+
+func availableTenderStatuses(s *TenderService, t *model.Tender, actor model.TransitionActor) ([]string, error) {
+	return availableStatuses(tenderTransitions, t.Status, actor, func(guard repository.StatusGuard) (bool, error) {
+		return s.tenderRepo.TenderGuardHolds(t.ID, guard)
+	})
+}
+
+func availableBidStatuses(s *BidService, b *model.Bid, actor model.TransitionActor) ([]string, error) {
+	return availableStatuses(bidTransitions, b.Status, actor, func(guard repository.StatusGuard) (bool, error) {
+		return s.bidRepo.BidGuardHolds(b.ID, guard)
+	})
+}
+
+// changeStatusError reports a status change that lost a race
+// with another one, or whose guard failed, as an illegal transition.
+func changeStatusError(err error) error {
+	if err == repository.ErrStatusChanged {
+		return ErrIllegalTransition
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"avito-back-test/internal/repository"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidUsername    = errors.New("username is required")
+	ErrAlreadyResponsible = errors.New("the employee is already responsible for another organization")
+)
+
+// UserService manages the employees and the responsibles from the terminal,
+// the API only reads them.
+type UserService struct {
+	employeeRepo                *repository.EmployeeRepository
+	organizationRepo            *repository.OrganizationRepository
+	organizationResponsibleRepo *repository.OrganizationResponsibleRepository
+}
+
+func NewUserService() *UserService {
+	emploRepo := repository.NewEmployeeRepository()
+	orgRepo := repository.NewOrganizationRepository()
+	orgRespRepo := repository.NewOrganizationResponsibleRepository()
+	return &UserService{
+		employeeRepo:                emploRepo,
+		organizationRepo:            orgRepo,
+		organizationResponsibleRepo: orgRespRepo,
+	}
+}
+
+func (s *UserService) GetEmployees() ([]model.Employee, error) {
+	return s.employeeRepo.GetEmployees()
+}
+
+// SaveEmployee creates the employee or renames the existing one.
+func (s *UserService) SaveEmployee(username, firstName, lastName string) (*model.Employee, error) {
+	if username == "" || len(username) > 50 {
+		return nil, ErrInvalidUsername
+	}
+	employee := model.Employee{Username: username, FirstName: firstName, LastName: lastName}
+	err := s.employeeRepo.WithTransaction(func(tx *sql.Tx) error {
+		return s.employeeRepo.TxUpsertEmployee(tx, &employee)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &employee, nil
+}
+
+func (s *UserService) DeleteEmployee(username string) error {
+	return s.employeeRepo.DeleteEmployee(username)
+}
+
+// GrantResponsible makes the employee responsible for the organization.
+// An employee is responsible for one organization at most.
+func (s *UserService) GrantResponsible(username string, organizationID uuid.UUID) error {
+	employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(username)
+	if err != nil {
+		return err
+	}
+	isPresent, err := s.organizationRepo.GetOrganizationPresent(organizationID)
+	if err != nil {
+		return err
+	}
+	if !isPresent {
+		return ErrNoOrganization
+	}
+	respOrganizationID, err := s.employeeRepo.GetEmployeeRespOrganization(*employeeID)
+	if err != nil && err != ErrNoEmployee {
+		return err
+	}
+	if respOrganizationID != nil && *respOrganizationID != organizationID {
+		return ErrAlreadyResponsible
+	}
+	return s.organizationResponsibleRepo.WithTransaction(func(tx *sql.Tx) error {
+		return s.organizationResponsibleRepo.TxInsertResponsible(tx, organizationID, *employeeID)
+	})
+}
+
+func (s *UserService) RevokeResponsible(username string, organizationID uuid.UUID) error {
+	employeeID, err := s.employeeRepo.GetEmployeeIDByUsername(username)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.organizationResponsibleRepo.DeleteResponsible(organizationID, *employeeID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotResponsible
+	}
+	return nil
+}
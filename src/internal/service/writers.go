@@ -0,0 +1,57 @@
+package service
+
+import (
+	"avito-back-test/internal/model"
+	"context"
+	"log"
+	"sync"
+)
+
+type writersKey struct{}
+
+type writers struct {
+	mu        sync.Mutex
+	usernames []string
+}
+
+// TrackWriters records the employees the request acts as, the callers resolved
+// with Authorizer.Caller and the authors of the created bids, see WrittenBy.
+func TrackWriters(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writersKey{}, &writers{})
+}
+
+// WrittenBy lists the employees recorded since TrackWriters.
+func WrittenBy(ctx context.Context) []string {
+	w, _ := ctx.Value(writersKey{}).(*writers)
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.usernames...)
+}
+
+func addWriters(ctx context.Context, usernames ...string) {
+	w, _ := ctx.Value(writersKey{}).(*writers)
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.usernames = append(w.usernames, usernames...)
+	w.mu.Unlock()
+}
+
+// wroteBid records the employees writing as the author of the new bid,
+// which comes in the body rather than as a caller.
+func (a *Authorizer) wroteBid(ctx context.Context, b *model.Bid) {
+	if ctx.Value(writersKey{}) == nil {
+		return
+	}
+	usernames, err := a.authorizationRepo.GetAuthorUsernames(b.AuthorID)
+	if err != nil {
+		// the bid is in, only its author may read a replica lagging behind
+		log.Println("bid authors:", err)
+		return
+	}
+	addWriters(ctx, usernames...)
+}
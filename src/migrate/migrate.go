@@ -1,41 +0,0 @@
-package main
-
-import (
-	"avito-back-test/internal/config"
-	"database/sql"
-	"log"
-
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/lib/pq"
-)
-
-func main() {
-	config, err := config.LoadConfig()
-	if err != nil {
-		log.Fatal(err)
-	}
-	db, err := sql.Open("postgres", config.PostgresConnUrl)
-	if err != nil {
-		log.Fatal(err)
-	}
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		db.Close()
-		log.Fatal(err)
-	}
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres", driver)
-	if err != nil {
-		driver.Close()
-		log.Fatal(err)
-	}
-	err = m.Up()
-	if err != nil && err != migrate.ErrNoChange {
-		m.Close()
-		log.Fatal(err)
-	}
-	m.Close()
-}
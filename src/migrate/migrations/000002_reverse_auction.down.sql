@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS auction_offer;
+
+DROP TABLE IF EXISTS tender_auction;
+
+COMMIT;
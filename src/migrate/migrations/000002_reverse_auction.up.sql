@@ -0,0 +1,28 @@
+BEGIN;
+
+CREATE TABLE tender_auction (
+    tender_id UUID PRIMARY KEY REFERENCES tender(id) ON DELETE CASCADE,
+    start_price NUMERIC(15, 2) NOT NULL CHECK (start_price > 0),
+    min_step NUMERIC(15, 2) NOT NULL CHECK (min_step > 0),
+    duration_seconds INT NOT NULL CHECK (duration_seconds > 0),
+    extension_seconds INT NOT NULL DEFAULT 0 CHECK (extension_seconds >= 0),
+    ends_at TIMESTAMP without time zone,
+    best_price NUMERIC(15, 2),
+    best_bid_id UUID REFERENCES bid(id) ON DELETE SET NULL,
+    winner_bid_id UUID REFERENCES bid(id) ON DELETE SET NULL,
+    finished BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE auction_offer (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tender_id UUID REFERENCES tender_auction(tender_id) ON DELETE CASCADE,
+    bid_id UUID REFERENCES bid(id) ON DELETE CASCADE,
+    price NUMERIC(15, 2) NOT NULL CHECK (price > 0),
+    created_at TIMESTAMP without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX auction_offer_tender_idx ON auction_offer (tender_id, created_at);
+
+CREATE INDEX tender_auction_running_idx ON tender_auction (ends_at) WHERE NOT finished;
+
+COMMIT;
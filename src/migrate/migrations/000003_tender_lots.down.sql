@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE bid DROP COLUMN IF EXISTS lot_id;
+
+DROP TABLE IF EXISTS tender_lot;
+
+DROP TYPE IF EXISTS tender_lot_status;
+
+COMMIT;
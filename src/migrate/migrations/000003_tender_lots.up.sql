@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TYPE tender_lot_status AS ENUM (
+    'Open',
+    'Decided'
+);
+
+CREATE TABLE tender_lot (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tender_id UUID REFERENCES tender(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    service_type tender_service_type,
+    status tender_lot_status DEFAULT 'Open',
+    winner_bid_id UUID REFERENCES bid(id) ON DELETE SET NULL,
+    created_at TIMESTAMP without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX tender_lot_tender_idx ON tender_lot (tender_id);
+
+ALTER TABLE bid ADD COLUMN lot_id UUID REFERENCES tender_lot(id) ON DELETE CASCADE;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS bid_score;
+
+DROP TABLE IF EXISTS tender_criterion;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE tender_criterion (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tender_id UUID REFERENCES tender(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    weight NUMERIC(5, 2) NOT NULL CHECK (weight > 0 AND weight <= 100),
+    created_at TIMESTAMP without time zone DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tender_id, name)
+);
+
+CREATE TABLE bid_score (
+    bid_id UUID REFERENCES bid(id) ON DELETE CASCADE,
+    criterion_id UUID REFERENCES tender_criterion(id) ON DELETE CASCADE,
+    evaluator_id UUID REFERENCES employee(id) ON DELETE CASCADE,
+    score NUMERIC(4, 2) NOT NULL CHECK (score >= 0 AND score <= 10),
+    updated_at TIMESTAMP without time zone DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (bid_id, criterion_id, evaluator_id)
+);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS tender_question;
+
+ALTER TABLE tender DROP COLUMN IF EXISTS question_deadline;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE tender ADD COLUMN question_deadline TIMESTAMP without time zone;
+
+CREATE TABLE tender_question (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tender_id UUID REFERENCES tender(id) ON DELETE CASCADE,
+    asker_id UUID REFERENCES employee(id) ON DELETE SET NULL,
+    question TEXT NOT NULL,
+    answer TEXT,
+    answered_by UUID REFERENCES employee(id) ON DELETE SET NULL,
+    created_at TIMESTAMP without time zone DEFAULT CURRENT_TIMESTAMP,
+    answered_at TIMESTAMP without time zone
+);
+
+CREATE INDEX tender_question_tender_idx ON tender_question (tender_id, created_at);
+
+COMMIT;
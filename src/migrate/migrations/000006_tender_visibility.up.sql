@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TYPE tender_visibility AS ENUM (
+    'Public',
+    'InviteOnly'
+);
+
+ALTER TABLE tender ADD COLUMN visibility tender_visibility NOT NULL DEFAULT 'Public';
+
+CREATE TABLE tender_invitation (
+    tender_id UUID REFERENCES tender(id) ON DELETE CASCADE,
+    organization_id UUID REFERENCES organization(id) ON DELETE CASCADE,
+    created_at TIMESTAMP without time zone DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tender_id, organization_id)
+);
+
+CREATE INDEX tender_invitation_organization_idx ON tender_invitation (organization_id);
+
+COMMIT;
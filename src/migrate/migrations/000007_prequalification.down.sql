@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP TABLE IF EXISTS qualification_answer;
+
+DROP TABLE IF EXISTS qualification;
+
+DROP TABLE IF EXISTS questionnaire_item;
+
+DROP TYPE IF EXISTS qualification_status;
+
+COMMIT;
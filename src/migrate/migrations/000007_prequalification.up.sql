@@ -0,0 +1,43 @@
+BEGIN;
+
+CREATE TYPE qualification_status AS ENUM (
+    'Pending',
+    'Approved',
+    'Rejected'
+);
+
+CREATE TABLE questionnaire_item (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tender_id UUID REFERENCES tender(id) ON DELETE CASCADE,
+    question TEXT NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT TRUE,
+    requires_document BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL
+);
+
+CREATE INDEX questionnaire_item_tender_idx ON questionnaire_item (tender_id, position);
+
+CREATE TABLE qualification (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tender_id UUID REFERENCES tender(id) ON DELETE CASCADE,
+    organization_id UUID REFERENCES organization(id) ON DELETE CASCADE,
+    status qualification_status NOT NULL DEFAULT 'Pending',
+    submitted_by UUID REFERENCES employee(id) ON DELETE SET NULL,
+    reviewed_by UUID REFERENCES employee(id) ON DELETE SET NULL,
+    review_comment TEXT,
+    created_at TIMESTAMP without time zone DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP without time zone,
+    UNIQUE (tender_id, organization_id)
+);
+
+CREATE TABLE qualification_answer (
+    qualification_id UUID REFERENCES qualification(id) ON DELETE CASCADE,
+    item_id UUID REFERENCES questionnaire_item(id) ON DELETE CASCADE,
+    answer TEXT,
+    document_name VARCHAR(255),
+    document_content_type VARCHAR(100),
+    document_content BYTEA,
+    PRIMARY KEY (qualification_id, item_id)
+);
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS conflict_of_interest;
+
+DROP TABLE IF EXISTS tender_recusal;
+
+DROP TYPE IF EXISTS conflict_action;
+
+COMMIT;
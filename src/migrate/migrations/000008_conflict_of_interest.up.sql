@@ -0,0 +1,30 @@
+BEGIN;
+
+CREATE TYPE conflict_action AS ENUM (
+    'BidCreation',
+    'Decision',
+    'Scoring'
+);
+
+CREATE TABLE tender_recusal (
+    tender_id UUID REFERENCES tender(id) ON DELETE CASCADE,
+    employee_id UUID REFERENCES employee(id) ON DELETE CASCADE,
+    reason TEXT,
+    created_at TIMESTAMP without time zone DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tender_id, employee_id)
+);
+
+CREATE TABLE conflict_of_interest (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tender_id UUID REFERENCES tender(id) ON DELETE CASCADE,
+    bid_id UUID REFERENCES bid(id) ON DELETE SET NULL,
+    employee_id UUID REFERENCES employee(id) ON DELETE SET NULL,
+    organization_id UUID REFERENCES organization(id) ON DELETE SET NULL,
+    action conflict_action NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX conflict_of_interest_tender_idx ON conflict_of_interest (tender_id, created_at);
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TYPE tender_service_type AS ENUM (
+    'Construction',
+    'Delivery',
+    'Manufacture'
+);
+
+ALTER TABLE tender_information DROP CONSTRAINT IF EXISTS tender_information_service_type_fkey;
+ALTER TABLE tender_information
+    ALTER COLUMN service_type TYPE tender_service_type USING service_type::tender_service_type;
+
+ALTER TABLE tender_lot DROP CONSTRAINT IF EXISTS tender_lot_service_type_fkey;
+ALTER TABLE tender_lot
+    ALTER COLUMN service_type TYPE tender_service_type USING service_type::tender_service_type;
+
+DROP TABLE IF EXISTS service_category_name;
+DROP TABLE IF EXISTS service_category;
+
+COMMIT;
@@ -0,0 +1,42 @@
+BEGIN;
+
+CREATE TABLE service_category (
+    code VARCHAR(50) PRIMARY KEY,
+    parent_code VARCHAR(50) REFERENCES service_category(code) ON UPDATE CASCADE,
+    created_at TIMESTAMP without time zone DEFAULT CURRENT_TIMESTAMP,
+    CHECK (parent_code <> code)
+);
+
+CREATE INDEX service_category_parent_idx ON service_category (parent_code);
+
+CREATE TABLE service_category_name (
+    code VARCHAR(50) REFERENCES service_category(code) ON UPDATE CASCADE ON DELETE CASCADE,
+    locale VARCHAR(10) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    PRIMARY KEY (code, locale)
+);
+
+INSERT INTO service_category (code) VALUES
+    ('Construction'),
+    ('Delivery'),
+    ('Manufacture');
+
+INSERT INTO service_category_name (code, locale, name) VALUES
+    ('Construction', 'en', 'Construction'),
+    ('Construction', 'ru', 'Строительство'),
+    ('Delivery', 'en', 'Delivery'),
+    ('Delivery', 'ru', 'Доставка'),
+    ('Manufacture', 'en', 'Manufacture'),
+    ('Manufacture', 'ru', 'Производство');
+
+ALTER TABLE tender_information
+    ALTER COLUMN service_type TYPE VARCHAR(50) USING service_type::text,
+    ADD FOREIGN KEY (service_type) REFERENCES service_category(code) ON UPDATE CASCADE;
+
+ALTER TABLE tender_lot
+    ALTER COLUMN service_type TYPE VARCHAR(50) USING service_type::text,
+    ADD FOREIGN KEY (service_type) REFERENCES service_category(code) ON UPDATE CASCADE;
+
+DROP TYPE tender_service_type;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+CREATE TABLE rate_limit_bucket (
+    key VARCHAR(300) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX rate_limit_bucket_updated_idx ON rate_limit_bucket (updated_at);
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+DROP INDEX IF EXISTS bid_author_idx;
+DROP INDEX IF EXISTS bid_tender_idx;
+DROP INDEX IF EXISTS tender_organization_idx;
+
+DROP TRIGGER IF EXISTS bid_information_current_version ON bid_information;
+DROP FUNCTION IF EXISTS bid_information_current_version();
+DROP TRIGGER IF EXISTS tender_information_current_version ON tender_information;
+DROP FUNCTION IF EXISTS tender_information_current_version();
+
+ALTER TABLE bid DROP COLUMN IF EXISTS current_version;
+ALTER TABLE tender DROP COLUMN IF EXISTS current_version;
+
+COMMIT;
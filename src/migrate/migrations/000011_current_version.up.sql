@@ -0,0 +1,46 @@
+BEGIN;
+
+-- tender and bid point at their latest information row, so that the reads
+-- join it by the primary key instead of looking for the maximum version
+ALTER TABLE tender ADD COLUMN current_version INT;
+ALTER TABLE bid ADD COLUMN current_version INT;
+
+UPDATE tender t
+SET current_version = (SELECT MAX(version) FROM tender_information ti WHERE ti.id = t.id);
+UPDATE bid b
+SET current_version = (SELECT MAX(version) FROM bid_information bi WHERE bi.id = b.id);
+
+-- a new information row moves the pointer in the same statement, whoever
+-- inserts it; the versions imported out of order never move it back
+CREATE FUNCTION tender_information_current_version() RETURNS trigger AS $$
+BEGIN
+    UPDATE tender
+    SET current_version = NEW.version
+    WHERE id = NEW.id AND (current_version IS NULL OR current_version < NEW.version);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tender_information_current_version
+    AFTER INSERT ON tender_information
+    FOR EACH ROW EXECUTE FUNCTION tender_information_current_version();
+
+CREATE FUNCTION bid_information_current_version() RETURNS trigger AS $$
+BEGIN
+    UPDATE bid
+    SET current_version = NEW.version
+    WHERE id = NEW.id AND (current_version IS NULL OR current_version < NEW.version);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER bid_information_current_version
+    AFTER INSERT ON bid_information
+    FOR EACH ROW EXECUTE FUNCTION bid_information_current_version();
+
+-- the "my" listings and the bids of a tender
+CREATE INDEX tender_organization_idx ON tender (organization_id);
+CREATE INDEX bid_tender_idx ON bid (tender_id, status);
+CREATE INDEX bid_author_idx ON bid (author_id);
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- the first response to a request with an Idempotency-Key,
+-- the status code is NULL while the request is in progress
+CREATE TABLE idempotency_key (
+    caller VARCHAR(300) NOT NULL,
+    request_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    status_code INT,
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    body BYTEA,
+    created_at TIMESTAMP without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (caller, request_key)
+);
+
+CREATE INDEX idempotency_key_created_idx ON idempotency_key (created_at);
+
+COMMIT;
@@ -0,0 +1,19 @@
+// Package migrations embeds the SQL migrations, so the binaries
+// don't depend on the working directory.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS holds the Postgres migrations.
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// SQLite holds the SQLite migrations, they keep the versions of the Postgres ones.
+var SQLite, _ = fs.Sub(sqliteFS, "sqlite")
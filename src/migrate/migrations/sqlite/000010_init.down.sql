@@ -0,0 +1,24 @@
+DROP TABLE rate_limit_bucket;
+DROP TABLE conflict_of_interest;
+DROP TABLE tender_recusal;
+DROP TABLE qualification_answer;
+DROP TABLE qualification;
+DROP TABLE questionnaire_item;
+DROP TABLE tender_invitation;
+DROP TABLE tender_question;
+DROP TABLE bid_score;
+DROP TABLE tender_criterion;
+DROP TABLE auction_offer;
+DROP TABLE tender_auction;
+DROP TABLE bid_decision;
+DROP TABLE bid_review;
+DROP TABLE bid_information;
+DROP TABLE tender_lot;
+DROP TABLE bid;
+DROP TABLE tender_information;
+DROP TABLE tender;
+DROP TABLE service_category_name;
+DROP TABLE service_category;
+DROP TABLE organization_responsible;
+DROP TABLE organization;
+DROP TABLE employee;
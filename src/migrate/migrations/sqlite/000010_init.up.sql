@@ -0,0 +1,249 @@
+-- The SQLite schema starts at the version of the Postgres migrations
+-- it matches, every later migration has to be added to both directories.
+-- The employees and the organizations are created by the migrations
+-- here, there is no external schema. The enums are CHECK constraints
+-- and uuid_generate_v4 is registered by the service, the ids are TEXT.
+
+CREATE TABLE employee (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    username VARCHAR(50) UNIQUE NOT NULL,
+    first_name VARCHAR(50),
+    last_name VARCHAR(50),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE organization (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    type VARCHAR(10) CHECK (type IN ('IE', 'LLC', 'JSC')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE organization_responsible (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    organization_id TEXT REFERENCES organization(id) ON DELETE CASCADE,
+    user_id TEXT REFERENCES employee(id) ON DELETE CASCADE
+);
+
+CREATE TABLE service_category (
+    code VARCHAR(50) PRIMARY KEY,
+    parent_code VARCHAR(50) REFERENCES service_category(code) ON UPDATE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (parent_code <> code)
+);
+
+CREATE INDEX service_category_parent_idx ON service_category (parent_code);
+
+CREATE TABLE service_category_name (
+    code VARCHAR(50) REFERENCES service_category(code) ON UPDATE CASCADE ON DELETE CASCADE,
+    locale VARCHAR(10) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    PRIMARY KEY (code, locale)
+);
+
+INSERT INTO service_category (code) VALUES
+    ('Construction'),
+    ('Delivery'),
+    ('Manufacture');
+
+INSERT INTO service_category_name (code, locale, name) VALUES
+    ('Construction', 'en', 'Construction'),
+    ('Construction', 'ru', 'Строительство'),
+    ('Delivery', 'en', 'Delivery'),
+    ('Delivery', 'ru', 'Доставка'),
+    ('Manufacture', 'en', 'Manufacture'),
+    ('Manufacture', 'ru', 'Производство');
+
+CREATE TABLE tender (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    status VARCHAR(20) DEFAULT 'Created' CHECK (status IN ('Created', 'Published', 'Closed')),
+    organization_id TEXT REFERENCES organization(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    question_deadline TIMESTAMP,
+    visibility VARCHAR(20) NOT NULL DEFAULT 'Public' CHECK (visibility IN ('Public', 'InviteOnly'))
+);
+
+CREATE TABLE tender_information (
+    id TEXT REFERENCES tender(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    service_type VARCHAR(50) REFERENCES service_category(code) ON UPDATE CASCADE,
+    version INT DEFAULT 1 CHECK (version > 0),
+    PRIMARY KEY (id, version)
+);
+
+CREATE TABLE tender_lot (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    tender_id TEXT REFERENCES tender(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    service_type VARCHAR(50) REFERENCES service_category(code) ON UPDATE CASCADE,
+    status VARCHAR(20) DEFAULT 'Open' CHECK (status IN ('Open', 'Decided')),
+    winner_bid_id TEXT REFERENCES bid(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX tender_lot_tender_idx ON tender_lot (tender_id);
+
+CREATE TABLE bid (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    status VARCHAR(20) DEFAULT 'Created' CHECK (status IN ('Created', 'Published', 'Canceled')),
+    tender_id TEXT REFERENCES tender(id) ON DELETE CASCADE,
+    author_type VARCHAR(20) CHECK (author_type IN ('Organization', 'User')),
+    author_id TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    lot_id TEXT REFERENCES tender_lot(id) ON DELETE CASCADE
+);
+
+CREATE TABLE bid_information (
+    id TEXT REFERENCES bid(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    version INT DEFAULT 1 CHECK (version > 0),
+    PRIMARY KEY (id, version)
+);
+
+CREATE TABLE bid_review (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    bid_id TEXT REFERENCES bid(id) ON DELETE CASCADE,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE bid_decision (
+    bid_id TEXT REFERENCES bid(id) ON DELETE CASCADE,
+    responsible_id TEXT REFERENCES employee(id) ON DELETE CASCADE,
+    decision VARCHAR(20) NOT NULL CHECK (decision IN ('Approved', 'Rejected')),
+    PRIMARY KEY (bid_id, responsible_id)
+);
+
+CREATE TABLE tender_auction (
+    tender_id TEXT PRIMARY KEY REFERENCES tender(id) ON DELETE CASCADE,
+    start_price NUMERIC(15, 2) NOT NULL CHECK (start_price > 0),
+    min_step NUMERIC(15, 2) NOT NULL CHECK (min_step > 0),
+    duration_seconds INT NOT NULL CHECK (duration_seconds > 0),
+    extension_seconds INT NOT NULL DEFAULT 0 CHECK (extension_seconds >= 0),
+    ends_at TIMESTAMP,
+    best_price NUMERIC(15, 2),
+    best_bid_id TEXT REFERENCES bid(id) ON DELETE SET NULL,
+    winner_bid_id TEXT REFERENCES bid(id) ON DELETE SET NULL,
+    finished BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE auction_offer (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    tender_id TEXT REFERENCES tender_auction(tender_id) ON DELETE CASCADE,
+    bid_id TEXT REFERENCES bid(id) ON DELETE CASCADE,
+    price NUMERIC(15, 2) NOT NULL CHECK (price > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX auction_offer_tender_idx ON auction_offer (tender_id, created_at);
+
+CREATE INDEX tender_auction_running_idx ON tender_auction (ends_at) WHERE NOT finished;
+
+CREATE TABLE tender_criterion (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    tender_id TEXT REFERENCES tender(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    weight NUMERIC(5, 2) NOT NULL CHECK (weight > 0 AND weight <= 100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tender_id, name)
+);
+
+CREATE TABLE bid_score (
+    bid_id TEXT REFERENCES bid(id) ON DELETE CASCADE,
+    criterion_id TEXT REFERENCES tender_criterion(id) ON DELETE CASCADE,
+    evaluator_id TEXT REFERENCES employee(id) ON DELETE CASCADE,
+    score NUMERIC(4, 2) NOT NULL CHECK (score >= 0 AND score <= 10),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (bid_id, criterion_id, evaluator_id)
+);
+
+CREATE TABLE tender_question (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    tender_id TEXT REFERENCES tender(id) ON DELETE CASCADE,
+    asker_id TEXT REFERENCES employee(id) ON DELETE SET NULL,
+    question TEXT NOT NULL,
+    answer TEXT,
+    answered_by TEXT REFERENCES employee(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    answered_at TIMESTAMP
+);
+
+CREATE INDEX tender_question_tender_idx ON tender_question (tender_id, created_at);
+
+CREATE TABLE tender_invitation (
+    tender_id TEXT REFERENCES tender(id) ON DELETE CASCADE,
+    organization_id TEXT REFERENCES organization(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tender_id, organization_id)
+);
+
+CREATE INDEX tender_invitation_organization_idx ON tender_invitation (organization_id);
+
+CREATE TABLE questionnaire_item (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    tender_id TEXT REFERENCES tender(id) ON DELETE CASCADE,
+    question TEXT NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT TRUE,
+    requires_document BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL
+);
+
+CREATE INDEX questionnaire_item_tender_idx ON questionnaire_item (tender_id, position);
+
+CREATE TABLE qualification (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    tender_id TEXT REFERENCES tender(id) ON DELETE CASCADE,
+    organization_id TEXT REFERENCES organization(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'Pending' CHECK (status IN ('Pending', 'Approved', 'Rejected')),
+    submitted_by TEXT REFERENCES employee(id) ON DELETE SET NULL,
+    reviewed_by TEXT REFERENCES employee(id) ON DELETE SET NULL,
+    review_comment TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP,
+    UNIQUE (tender_id, organization_id)
+);
+
+CREATE TABLE qualification_answer (
+    qualification_id TEXT REFERENCES qualification(id) ON DELETE CASCADE,
+    item_id TEXT REFERENCES questionnaire_item(id) ON DELETE CASCADE,
+    answer TEXT,
+    document_name VARCHAR(255),
+    document_content_type VARCHAR(100),
+    document_content BLOB,
+    PRIMARY KEY (qualification_id, item_id)
+);
+
+CREATE TABLE tender_recusal (
+    tender_id TEXT REFERENCES tender(id) ON DELETE CASCADE,
+    employee_id TEXT REFERENCES employee(id) ON DELETE CASCADE,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tender_id, employee_id)
+);
+
+CREATE TABLE conflict_of_interest (
+    id TEXT PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    tender_id TEXT REFERENCES tender(id) ON DELETE CASCADE,
+    bid_id TEXT REFERENCES bid(id) ON DELETE SET NULL,
+    employee_id TEXT REFERENCES employee(id) ON DELETE SET NULL,
+    organization_id TEXT REFERENCES organization(id) ON DELETE SET NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('BidCreation', 'Decision', 'Scoring')),
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX conflict_of_interest_tender_idx ON conflict_of_interest (tender_id, created_at);
+
+CREATE TABLE rate_limit_bucket (
+    key VARCHAR(300) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX rate_limit_bucket_updated_idx ON rate_limit_bucket (updated_at);
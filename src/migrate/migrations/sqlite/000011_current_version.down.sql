@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS bid_author_idx;
+DROP INDEX IF EXISTS bid_tender_idx;
+DROP INDEX IF EXISTS tender_organization_idx;
+
+DROP TRIGGER IF EXISTS bid_information_current_version;
+DROP TRIGGER IF EXISTS tender_information_current_version;
+
+ALTER TABLE bid DROP COLUMN current_version;
+ALTER TABLE tender DROP COLUMN current_version;
@@ -0,0 +1,28 @@
+-- see the Postgres migration of the same version
+ALTER TABLE tender ADD COLUMN current_version INTEGER;
+ALTER TABLE bid ADD COLUMN current_version INTEGER;
+
+UPDATE tender
+SET current_version = (SELECT MAX(version) FROM tender_information ti WHERE ti.id = tender.id);
+UPDATE bid
+SET current_version = (SELECT MAX(version) FROM bid_information bi WHERE bi.id = bid.id);
+
+CREATE TRIGGER tender_information_current_version
+    AFTER INSERT ON tender_information
+BEGIN
+    UPDATE tender
+    SET current_version = NEW.version
+    WHERE id = NEW.id AND (current_version IS NULL OR current_version < NEW.version);
+END;
+
+CREATE TRIGGER bid_information_current_version
+    AFTER INSERT ON bid_information
+BEGIN
+    UPDATE bid
+    SET current_version = NEW.version
+    WHERE id = NEW.id AND (current_version IS NULL OR current_version < NEW.version);
+END;
+
+CREATE INDEX tender_organization_idx ON tender (organization_id);
+CREATE INDEX bid_tender_idx ON bid (tender_id, status);
+CREATE INDEX bid_author_idx ON bid (author_id);
@@ -0,0 +1,13 @@
+-- see the Postgres migration of the same version
+CREATE TABLE idempotency_key (
+    caller VARCHAR(300) NOT NULL,
+    request_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    status_code INT,
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    body BLOB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (caller, request_key)
+);
+
+CREATE INDEX idempotency_key_created_idx ON idempotency_key (created_at);